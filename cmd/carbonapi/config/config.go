@@ -87,6 +87,12 @@ type ConfigType struct {
 	HTTPResponseStackTrace     bool               `mapstructure:"httpResponseStackTrace"`
 	UseCachingDNSResolver      bool               `mapstructure:"useCachingDNSResolver"`
 	CachingDNSRefreshTime      time.Duration      `mapstructure:"cachingDNSRefreshTime"`
+	MaxExpressionDepth         int                `mapstructure:"maxExpressionDepth"`
+	MaxFunctionCalls           int                `mapstructure:"maxFunctionCalls"`
+	MaxSeries                  int                `mapstructure:"maxSeries"`
+	StreamJSON                 bool               `mapstructure:"streamJSON"`
+	SlowQueryThreshold         time.Duration      `mapstructure:"slowQueryThreshold"`
+	DefaultConsolidateBy       string             `mapstructure:"defaultConsolidateBy"`
 
 	ResponseCache cache.BytesCache `mapstructure:"-" json:"-"`
 	BackendCache  cache.BytesCache `mapstructure:"-" json:"-"`
@@ -115,6 +121,8 @@ var Config = ConfigType{
 	Buckets:               10,
 	Concurency:            1000,
 	MaxBatchSize:          100,
+	MaxExpressionDepth:    100,
+	MaxFunctionCalls:      10000,
 	ResponseCacheConfig: CacheConfig{
 		Type:              "mem",
 		DefaultTimeoutSec: 60,
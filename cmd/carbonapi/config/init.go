@@ -15,6 +15,7 @@ import (
 	"github.com/ansel1/merry"
 	"github.com/facebookgo/pidfile"
 	"github.com/go-graphite/carbonapi/cache"
+	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/functions"
 	"github.com/go-graphite/carbonapi/expr/functions/cairo/png"
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -227,7 +228,17 @@ func SetUpConfig(logger *zap.Logger, BuildVersion string) {
 		}
 	}
 
+	if Config.DefaultConsolidateBy != "" {
+		if _, ok := consolidations.ConsolidationToFunc[Config.DefaultConsolidateBy]; !ok && Config.DefaultConsolidateBy != consolidations.LTTB {
+			logger.Fatal("unknown consolidation function",
+				zap.String("defaultConsolidateBy", Config.DefaultConsolidateBy),
+				zap.Strings("supported_functions", append([]string{consolidations.LTTB}, consolidations.AvailableConsolidationFuncs()...)),
+			)
+		}
+	}
+
 	helper.ExtrapolatePoints = Config.ExtrapolateExperiment
+	parser.MaxExpressionDepth = Config.MaxExpressionDepth
 	if Config.ExtrapolateExperiment {
 		logger.Warn("extraploation experiment is enabled",
 			zap.String("reason", "this feature is highly experimental and untested"),
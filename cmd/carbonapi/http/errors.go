@@ -0,0 +1,194 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ansel1/merry"
+	"github.com/go-graphite/carbonapi/carbonapipb"
+	"github.com/go-graphite/carbonapi/expr"
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+// errorCode tells clients what kind of problem failed the target, so it can be shown without parsing the message
+type errorCode string
+
+const (
+	errorCodeParse           errorCode = "parse_error"
+	errorCodeUnknownFunction errorCode = "unknown_function"
+	errorCodeArgumentCount   errorCode = "wrong_argument_count"
+	errorCodeBadArgument     errorCode = "bad_argument"
+	errorCodeInvalidRegex    errorCode = "invalid_regex"
+	errorCodeStepMismatch    errorCode = "step_mismatch"
+	errorCodeTooManyCalls    errorCode = "too_many_function_calls"
+	errorCodeTooManySeries   errorCode = "too_many_series"
+	errorCodeNotFound        errorCode = "not_found"
+	errorCodeTimeout         errorCode = "timeout"
+	errorCodeInternal        errorCode = "internal_error"
+)
+
+// errorClasses maps errors of parsing and evaluation to codes and http statuses, the first match wins
+var errorClasses = []struct {
+	code   errorCode
+	status int
+	errs   []error
+}{
+	{errorCodeParse, http.StatusBadRequest, []error{
+		parser.ErrMissingExpr,
+		parser.ErrMissingComma,
+		parser.ErrMissingQuote,
+		parser.ErrUnexpectedCharacter,
+		parser.ErrExpressionTooDeep,
+	}},
+	{errorCodeArgumentCount, http.StatusBadRequest, []error{
+		parser.ErrMissingArgument,
+		parser.ErrMissingTimeseries,
+		types.ErrTooManyArguments,
+	}},
+	{errorCodeBadArgument, http.StatusBadRequest, []error{
+		parser.ErrBadType,
+		parser.ErrUnknownTimeUnits,
+		types.ErrWildcardNotAllowed,
+		types.ErrListLengthMismatch,
+	}},
+	{errorCodeInvalidRegex, http.StatusBadRequest, []error{parser.ErrInvalidRegex}},
+	{errorCodeStepMismatch, http.StatusBadRequest, []error{types.ErrStepMismatch}},
+	{errorCodeTooManyCalls, http.StatusBadRequest, []error{expr.ErrTooManyFunctionCalls}},
+	{errorCodeTooManySeries, http.StatusBadRequest, []error{expr.ErrTooManySeries}},
+	{errorCodeNotFound, http.StatusNotFound, []error{parser.ErrSeriesDoesNotExist}},
+	{errorCodeTimeout, http.StatusGatewayTimeout, []error{context.DeadlineExceeded}},
+}
+
+// validationCodes are used to find the position of evaluation errors, as they don't keep it
+var validationCodes = map[errorCode]expr.ValidationErrorType{
+	errorCodeParse:           expr.ValidationParseError,
+	errorCodeUnknownFunction: expr.ValidationUnknownFunction,
+	errorCodeArgumentCount:   expr.ValidationWrongArgumentCount,
+	errorCodeBadArgument:     expr.ValidationBadConstant,
+}
+
+// errorOffsetKey is a merry value with the offset in target where it failed to parse
+type errorOffsetKey struct{}
+
+// newParseError keeps both the report for text responses and the details for the envelope
+func newParseError(target, e string, err error) merry.Error {
+	base, msg := err, ""
+	if err == nil {
+		base, msg = parser.ErrUnexpectedCharacter, "unexpected trailing characters"
+	} else {
+		msg = err.Error()
+	}
+	return merry.WithMessage(base, buildParseErrorString(target, e, err)).
+		WithUserMessage(msg).
+		WithValue(errorOffsetKey{}, len(target)-len(e)).
+		WithHTTPCode(http.StatusBadRequest)
+}
+
+// classifyError returns code and http status of target error. Errors that are not known keep their http code.
+func classifyError(err error) (errorCode, int) {
+	if _, ok := merry.Unwrap(err).(helper.ErrUnknownFunction); ok {
+		return errorCodeUnknownFunction, http.StatusBadRequest
+	}
+	for _, c := range errorClasses {
+		if merry.Is(err, c.errs...) {
+			return c.code, c.status
+		}
+	}
+	status := merry.HTTPCode(err)
+	if status == http.StatusNotFound {
+		return errorCodeNotFound, status
+	}
+	return errorCodeInternal, status
+}
+
+// errorOffset returns byte offset in target where err was found or -1 if it can't be determined
+func errorOffset(target string, err error, code errorCode) int {
+	if offset, ok := merry.Value(err, errorOffsetKey{}).(int); ok {
+		return offset
+	}
+	validationType, ok := validationCodes[code]
+	if !ok {
+		return -1
+	}
+	if verrs, ok := expr.ParseAndValidate(target).(expr.ValidationErrors); ok {
+		for _, verr := range verrs {
+			if verr.Type == validationType {
+				return verr.Position
+			}
+		}
+	}
+	return -1
+}
+
+// errorEnvelope is the body of failed render request in json format
+type errorEnvelope struct {
+	Error  string    `json:"error"`
+	Code   errorCode `json:"code"`
+	Target string    `json:"target,omitempty"`
+	// Offset is a byte offset in target where error was found or -1 if it can't be determined
+	Offset int `json:"offset"`
+	// Errors lists all failed targets when there is more than one, the first one is also the envelope itself
+	Errors []*errorEnvelope `json:"errors,omitempty"`
+}
+
+func newTargetErrorEnvelope(target string, err error) *errorEnvelope {
+	code, _ := classifyError(err)
+	// parse errors have a short user message in addition to the human-readable report
+	msg := merry.UserMessage(err)
+	if msg == "" {
+		msg = err.Error()
+	}
+	return &errorEnvelope{
+		Error:  msg,
+		Code:   code,
+		Target: target,
+		Offset: errorOffset(target, err, code),
+	}
+}
+
+// newErrorEnvelope describes failed targets in order of request, missing data is not reported as an error
+func newErrorEnvelope(targets []string, errors map[string]merry.Error) *errorEnvelope {
+	failed := failedTargets(targets, errors)
+	if len(failed) == 0 {
+		return &errorEnvelope{Error: "no data", Code: errorCodeNotFound, Offset: -1}
+	}
+	env := *newTargetErrorEnvelope(failed[0], errors[failed[0]])
+	if len(failed) > 1 {
+		for _, target := range failed {
+			env.Errors = append(env.Errors, newTargetErrorEnvelope(target, errors[target]))
+		}
+	}
+	return &env
+}
+
+// errorStatus returns the http status for the failed targets, client errors take precedence
+func errorStatus(targets []string, errors map[string]merry.Error) int {
+	status := 0
+	for _, target := range failedTargets(targets, errors) {
+		_, s := classifyError(errors[target])
+		if s < 500 {
+			return s
+		}
+		if status == 0 {
+			status = s
+		}
+	}
+	if status == 0 {
+		return http.StatusBadRequest
+	}
+	return status
+}
+
+func setErrorEnvelope(w http.ResponseWriter, accessLogDetails *carbonapipb.AccessLogDetails, env *errorEnvelope, status int, jsonp string) {
+	b, err := json.Marshal(env)
+	if err != nil {
+		setError(w, accessLogDetails, env.Error, status)
+		return
+	}
+	writeResponse(w, status, b, jsonFormat, jsonp)
+	accessLogDetails.Reason = env.Error
+	accessLogDetails.HTTPCode = int32(status)
+}
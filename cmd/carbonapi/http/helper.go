@@ -3,10 +3,12 @@ package http
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/ansel1/merry"
 	"github.com/go-graphite/carbonapi/carbonapipb"
 	"github.com/go-graphite/carbonapi/cmd/carbonapi/config"
 	"github.com/go-graphite/carbonapi/pkg/parser"
@@ -238,6 +240,53 @@ func buildParseErrorString(target, e string, err error) string {
 	return msg
 }
 
+// warningsHeader lists targets that failed to parse or evaluate when the rest of targets are returned.
+// Each failed target is a separate header value in form "<target>": "<error>", where both parts are
+// double-quoted strings with Go (and JSON compatible) escaping of quotes, non-ASCII and control characters.
+const warningsHeader = "X-Carbonapi-Warnings"
+
+// isTargetError returns true if err is an error of target parsing or evaluation, not just missing data
+func isTargetError(err error) bool {
+	return merry.HTTPCode(err) != http.StatusNotFound && !merry.Is(err, parser.ErrSeriesDoesNotExist)
+}
+
+func hasTargetErrors(errors map[string]merry.Error) bool {
+	for _, err := range errors {
+		if isTargetError(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// failedTargets returns targets with errors in order of request, each one once
+func failedTargets(targets []string, errors map[string]merry.Error) []string {
+	failed := make([]string, 0, len(errors))
+	seen := make(map[string]bool, len(errors))
+	for _, target := range targets {
+		if err, ok := errors[target]; ok && !seen[target] && isTargetError(err) {
+			seen[target] = true
+			failed = append(failed, target)
+		}
+	}
+	return failed
+}
+
+func formatTargetErrors(targets []string, errors map[string]merry.Error) []string {
+	failed := failedTargets(targets, errors)
+	msgs := make([]string, 0, len(failed))
+	for _, target := range failed {
+		msgs = append(msgs, target+": "+errors[target].Error())
+	}
+	return msgs
+}
+
+func setWarningsHeader(w http.ResponseWriter, targets []string, errors map[string]merry.Error) {
+	for _, target := range failedTargets(targets, errors) {
+		w.Header().Add(warningsHeader, strconv.QuoteToASCII(target)+": "+strconv.QuoteToASCII(errors[target].Error()))
+	}
+}
+
 func deferredAccessLogging(accessLogger *zap.Logger, accessLogDetails *carbonapipb.AccessLogDetails, t time.Time, logAsError bool) {
 	accessLogDetails.Runtime = time.Since(t).Seconds()
 	if logAsError {
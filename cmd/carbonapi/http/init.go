@@ -29,6 +29,9 @@ func InitHandlers(headersToPass, headersToLog []string) *http.ServeMux {
 	r.HandleFunc(config.Config.Prefix+"/functions", enrichContextWithHeaders(headersToPass, headersToLog, functionsHandler))
 	r.HandleFunc(config.Config.Prefix+"/functions/", enrichContextWithHeaders(headersToPass, headersToLog, functionsHandler))
 
+	r.HandleFunc(config.Config.Prefix+"/validate", enrichContextWithHeaders(headersToPass, headersToLog, validateHandler))
+	r.HandleFunc(config.Config.Prefix+"/validate/", enrichContextWithHeaders(headersToPass, headersToLog, validateHandler))
+
 	r.HandleFunc(config.Config.Prefix+"/tags", enrichContextWithHeaders(headersToPass, headersToLog, tagHandler))
 	r.HandleFunc(config.Config.Prefix+"/tags/", enrichContextWithHeaders(headersToPass, headersToLog, tagHandler))
 
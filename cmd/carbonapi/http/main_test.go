@@ -3,13 +3,18 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/ansel1/merry"
 	"github.com/go-graphite/carbonapi/cmd/carbonapi/config"
+	"github.com/go-graphite/carbonapi/expr"
 	"github.com/go-graphite/carbonapi/expr/types"
 	zipperTypes "github.com/go-graphite/carbonapi/zipper/types"
 	pb "github.com/go-graphite/protocol/carbonapi_v3_pb"
@@ -188,3 +193,468 @@ func TestInfoHandler(t *testing.T) {
 		t.Error("Http response should be same.")
 	}
 }
+
+func TestRenderHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		code int
+	}{
+		{"parse error", "/render/?target=sum(foo.bar&format=json", http.StatusBadRequest},
+		{"unknown function", "/render/?target=noSuchFunction(foo.bar)&format=json", http.StatusBadRequest},
+		{"bad maxDataPoints", "/render/?target=foo.bar&format=json&maxDataPoints=abc", http.StatusBadRequest},
+		{"negative maxDataPoints", "/render/?target=foo.bar&format=json&maxDataPoints=-1", http.StatusBadRequest},
+		{"unsupported format", "/render/?target=foo.bar&format=xml", http.StatusBadRequest},
+		{"valid maxDataPoints", "/render/?target=foo.bar&from=-10minutes&format=json&maxDataPoints=1", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, rr := setUpRequest(t, tt.url)
+			renderHandler(rr, req)
+			assert.Equal(t, tt.code, rr.Code, rr.Body.String())
+		})
+	}
+}
+
+type countingCarbonZipper struct {
+	mockCarbonZipper
+	renderCalls int
+	requested   []string
+}
+
+func (z *countingCarbonZipper) Render(ctx context.Context, request pb.MultiFetchRequest) ([]*types.MetricData, *zipperTypes.Stats, merry.Error) {
+	z.renderCalls++
+	for _, m := range request.Metrics {
+		z.requested = append(z.requested, m.PathExpression)
+	}
+	return z.mockCarbonZipper.Render(ctx, request)
+}
+
+func TestRenderHandlerMultipleTargets(t *testing.T) {
+	zipper := &countingCarbonZipper{}
+	saved := config.Config.ZipperInstance
+	config.Config.ZipperInstance = zipper
+	defer func() { config.Config.ZipperInstance = saved }()
+
+	req, rr := setUpRequest(t, "/render/?target=foo.bar&target=sumSeries(foo.bar,foo.baz)&target=foo.baz&from=-10minutes&format=json&noCache=1")
+	renderHandler(rr, req)
+
+	expected := `[{"target":"foo.bar","datapoints":[[null,1510913280],[1510913759,1510913340],[1510913818,1510913400]],"tags":{}},` +
+		`{"target":"sumSeries(foo.bar)","datapoints":[[null,1510913280],[1510913759,1510913340],[1510913818,1510913400]],"tags":{}}]`
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, expected, rr.Body.String())
+	assert.Equal(t, 1, zipper.renderCalls, "all targets should be fetched with a single request")
+	assert.ElementsMatch(t, []string{"foo.bar", "foo.baz"}, zipper.requested)
+}
+
+func TestRenderHandlerGraphOptions(t *testing.T) {
+	tests := []struct {
+		target   string
+		expected string
+	}{
+		{
+			"drawAsInfinite(foo.bar)",
+			`[{"target":"drawAsInfinite(foo.bar)","datapoints":[[null,1510913280],[1510913759,1510913340],[1510913818,1510913400]],"tags":{},"drawAsInfinite":true}]`,
+		},
+		{
+			`color(foo.bar,"#ff0000")`,
+			`[{"target":"foo.bar","datapoints":[[null,1510913280],[1510913759,1510913340],[1510913818,1510913400]],"tags":{},"color":"#ff0000"}]`,
+		},
+		{
+			"alpha(foo.bar,0.5)",
+			`[{"target":"foo.bar","datapoints":[[null,1510913280],[1510913759,1510913340],[1510913818,1510913400]],"tags":{},"alpha":0.5}]`,
+		},
+		{
+			"alpha(foo.bar,0)",
+			`[{"target":"foo.bar","datapoints":[[null,1510913280],[1510913759,1510913340],[1510913818,1510913400]],"tags":{},"alpha":0}]`,
+		},
+		{
+			"secondYAxis(foo.bar)",
+			`[{"target":"secondYAxis(foo.bar)","datapoints":[[null,1510913280],[1510913759,1510913340],[1510913818,1510913400]],"tags":{},"secondYAxis":true}]`,
+		},
+		{
+			// hints set by different functions are all returned
+			"secondYAxis(drawAsInfinite(foo.bar))",
+			`[{"target":"secondYAxis(drawAsInfinite(foo.bar))","datapoints":[[null,1510913280],[1510913759,1510913340],[1510913818,1510913400]],"tags":{},"drawAsInfinite":true,"secondYAxis":true}]`,
+		},
+		{
+			"stacked(foo.bar)",
+			`[{"target":"stacked(foo.bar)","datapoints":[[null,1510913280],[1510913759,1510913340],[1510913818,1510913400]],"tags":{},"stacked":true,"stackName":"__DEFAULT__"}]`,
+		},
+		{
+			`stacked(foo.bar,"dc1")`,
+			`[{"target":"stacked(foo.bar)","datapoints":[[null,1510913280],[1510913759,1510913340],[1510913818,1510913400]],"tags":{},"stacked":true,"stackName":"dc1"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			req, rr := setUpRequest(t, "/render/?target="+url.QueryEscape(tt.target)+"&from=-10minutes&format=json&noCache=1")
+			renderHandler(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+			assert.Equal(t, tt.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestValidateHandler(t *testing.T) {
+	req, rr := setUpRequest(t, "/validate/?target=sumSeries(foo.bar)&target=noSuchFunction(foo.bar)")
+	validateHandler(rr, req)
+
+	expected := `[{"target":"sumSeries(foo.bar)","valid":true},` +
+		`{"target":"noSuchFunction(foo.bar)","valid":false,"errors":[{"type":"unknown function","function":"noSuchFunction","position":0,"message":"unknown function \"noSuchFunction\""}]}]`
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, expected, rr.Body.String())
+
+	req, rr = setUpRequest(t, "/validate/")
+	validateHandler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestRenderHandlerStreamJSON(t *testing.T) {
+	config.Config.StreamJSON = true
+	defer func() { config.Config.StreamJSON = false }()
+
+	tests := []struct {
+		name     string
+		url      string
+		code     int
+		expected string
+	}{
+		{
+			"streamed",
+			"/render/?target=scale(foo.bar,2)&target=sumSeries(foo.bar,foo.baz)&from=-10minutes&format=json&noCache=1",
+			http.StatusOK,
+			`[{"target":"scale(foo.bar,2)","datapoints":[[null,1510913280],[3021827518,1510913340],[3021827636,1510913400]],"tags":{}},` +
+				`{"target":"sumSeries(foo.bar)","datapoints":[[null,1510913280],[1510913759,1510913340],[1510913818,1510913400]],"tags":{}}]`,
+		},
+		{
+			"error before any series",
+			"/render/?target=scale(foo.bar)&from=-10minutes&format=json&noCache=1",
+			http.StatusBadRequest,
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, rr := setUpRequest(t, tt.url)
+			renderHandler(rr, req)
+			assert.Equal(t, tt.code, rr.Code, rr.Body.String())
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, rr.Body.String())
+				assert.Equal(t, contentTypeJSON, rr.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestRenderHandlerMovingLookback(t *testing.T) {
+	zipper := &countingCarbonZipper{}
+	saved := config.Config.ZipperInstance
+	config.Config.ZipperInstance = zipper
+	defer func() { config.Config.ZipperInstance = saved }()
+
+	req, rr := setUpRequest(t, "/render/?target=movingAverage(foo.bar,2)&from=-10minutes&format=json&noCache=1")
+	renderHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	// the second request fetches 2 points before from, when step is known
+	assert.Equal(t, 2, zipper.renderCalls)
+	assert.Equal(t, []string{"foo.bar", "foo.bar"}, zipper.requested)
+}
+
+func TestRenderHandlerPartialErrors(t *testing.T) {
+	const targets = "target=foo.bar&target=sum(foo.bar&target=noSuchFunction(foo.bar)&from=-10minutes&format=json&noCache=1"
+	expected := `[{"target":"foo.bar","datapoints":[[null,1510913280],[1510913759,1510913340],[1510913818,1510913400]],"tags":{}}]`
+
+	tests := []struct {
+		name      string
+		url       string
+		stream    bool
+		code      int
+		expected  string
+		warnings  int
+		errorMsgs []string
+	}{
+		{
+			name:     "warnings",
+			url:      "/render/?" + targets,
+			code:     http.StatusOK,
+			expected: expected,
+			warnings: 2,
+		},
+		{
+			name:     "streamed warnings",
+			url:      "/render/?" + targets,
+			stream:   true,
+			code:     http.StatusOK,
+			expected: expected,
+			// evaluation error of the last target happens after the response is written
+			warnings: 1,
+		},
+		{
+			name:      "strict",
+			url:       "/render/?strict=1&" + targets,
+			code:      http.StatusBadRequest,
+			errorMsgs: []string{`"target":"sum(foo.bar"`, `"code":"parse_error"`},
+		},
+		{
+			name:      "strict multiple errors",
+			url:       "/render/?strict=1&target=sum(foo.bar&target=foo.bar)&from=-10minutes&format=json&noCache=1",
+			code:      http.StatusBadRequest,
+			errorMsgs: []string{`"errors":[{"error":"missing comma"`, `"target":"foo.bar)"`},
+		},
+		{
+			name:      "strict text",
+			url:       "/render/?strict=1&" + strings.Replace(targets, "format=json", "format=raw", 1),
+			code:      http.StatusBadRequest,
+			errorMsgs: []string{"sum(foo.bar: Bad Request"},
+		},
+		{
+			name:      "strict evaluation error",
+			url:       "/render/?strict=1&target=foo.bar&target=noSuchFunction(foo.bar)&from=-10minutes&format=json&noCache=1",
+			code:      http.StatusBadRequest,
+			errorMsgs: []string{`"target":"noSuchFunction(foo.bar)"`, "unknown function"},
+		},
+		{
+			name:     "strict success",
+			url:      "/render/?strict=1&target=foo.bar&from=-10minutes&format=json&noCache=1",
+			code:     http.StatusOK,
+			expected: expected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Config.StreamJSON = tt.stream
+			defer func() { config.Config.StreamJSON = false }()
+
+			req, rr := setUpRequest(t, tt.url)
+			renderHandler(rr, req)
+			assert.Equal(t, tt.code, rr.Code, rr.Body.String())
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, rr.Body.String())
+			}
+			for _, msg := range tt.errorMsgs {
+				assert.Contains(t, rr.Body.String(), msg)
+			}
+
+			warnings := rr.Header().Values(warningsHeader)
+			assert.Len(t, warnings, tt.warnings)
+			if tt.warnings > 0 {
+				assert.Equal(t, `"sum(foo.bar": "Bad Request\n\nTarget              : sum(foo.bar\nError               : missing comma\n"`, warnings[0])
+			}
+			if tt.warnings > 1 {
+				assert.True(t, strings.HasPrefix(warnings[1], `"noSuchFunction(foo.bar)": "`), warnings[1])
+			}
+		})
+	}
+}
+
+func TestRenderHandlerErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		params string
+		status int
+		code   errorCode
+		offset int
+	}{
+		{"parse error", "sum(foo.bar", "", http.StatusBadRequest, errorCodeParse, 11},
+		{"trailing characters", "foo.bar)", "", http.StatusBadRequest, errorCodeParse, 7},
+		{"unknown function", "sumSeries(noSuchFunction(foo.bar))", "", http.StatusBadRequest, errorCodeUnknownFunction, 10},
+		{"argument count", "alias(foo.bar)", "", http.StatusBadRequest, errorCodeArgumentCount, 0},
+		{"invalid regex", "aliasSub(foo.bar,'(','x')", "", http.StatusBadRequest, errorCodeInvalidRegex, -1},
+		{"step mismatch", "sumSeries(foo.bar,summarize(foo.bar,'2min'))", "&strictStep=1", http.StatusBadRequest, errorCodeStepMismatch, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, rr := setUpRequest(t, "/render/?target="+url.QueryEscape(tt.target)+"&from=-10minutes&format=json&noCache=1"+tt.params)
+			renderHandler(rr, req)
+			assert.Equal(t, tt.status, rr.Code, rr.Body.String())
+			assert.Equal(t, contentTypeJSON, rr.Header().Get("Content-Type"))
+
+			var env errorEnvelope
+			if assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &env), rr.Body.String()) {
+				assert.Equal(t, tt.code, env.Code)
+				assert.Equal(t, tt.target, env.Target)
+				assert.Equal(t, tt.offset, env.Offset)
+				assert.NotEmpty(t, env.Error)
+				assert.Empty(t, env.Errors)
+			}
+		})
+	}
+}
+
+func TestRenderHandlerDebugTree(t *testing.T) {
+	zipper := &countingCarbonZipper{}
+	saved := config.Config.ZipperInstance
+	config.Config.ZipperInstance = zipper
+	defer func() { config.Config.ZipperInstance = saved }()
+
+	req, rr := setUpRequest(t, "/render/?target=sumSeries(foo.bar,%20foo.baz)&target=sum(foo.bar&debug=tree&format=png&noCache=1")
+	renderHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, contentTypeJSON, rr.Header().Get("Content-Type"))
+	assert.Equal(t, 0, zipper.renderCalls, "data should not be fetched")
+
+	var response []struct {
+		Target string          `json:"target"`
+		String string          `json:"string"`
+		Tree   json.RawMessage `json:"tree"`
+		Error  string          `json:"error"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	if assert.Len(t, response, 2) {
+		assert.Equal(t, "sumSeries(foo.bar, foo.baz)", response[0].Target)
+		assert.Equal(t, "sumSeries(foo.bar, foo.baz)", response[0].String)
+		assert.True(t, strings.HasPrefix(string(response[0].Tree), `{"target":"sumSeries","etype":"func"`), string(response[0].Tree))
+		assert.Empty(t, response[0].Error)
+
+		assert.Equal(t, "sum(foo.bar", response[1].Target)
+		assert.Empty(t, response[1].Tree)
+		assert.Contains(t, response[1].Error, "missing comma")
+	}
+}
+
+func TestRenderHandlerDebugPlan(t *testing.T) {
+	zipper := &countingCarbonZipper{}
+	saved := config.Config.ZipperInstance
+	config.Config.ZipperInstance = zipper
+	defer func() { config.Config.ZipperInstance = saved }()
+
+	req, rr := setUpRequest(t, "/render/?target=sumSeries(foo.bar)&target=sum(foo.bar&from=-10minutes&debug=plan&format=png")
+	renderHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, contentTypeJSON, rr.Header().Get("Content-Type"))
+	assert.Equal(t, 1, zipper.renderCalls)
+
+	var plan expr.QueryPlan
+	if !assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &plan), rr.Body.String()) {
+		return
+	}
+	if assert.Len(t, plan.Fetches, 1) {
+		assert.Equal(t, "foo.bar", plan.Fetches[0].Metric)
+		assert.Equal(t, 1, plan.Fetches[0].Series)
+		assert.True(t, plan.Fetches[0].Prefetched)
+	}
+	targets := make(map[string]*expr.PlanTarget)
+	for _, target := range plan.Targets {
+		targets[target.Target] = target
+	}
+	assert.Len(t, targets, 2)
+	if target, ok := targets["sumSeries(foo.bar)"]; assert.True(t, ok) {
+		assert.Equal(t, 1, target.Series)
+		assert.Empty(t, target.Error)
+	}
+	if target, ok := targets["sum(foo.bar"]; assert.True(t, ok) {
+		assert.Equal(t, 0, target.Series)
+		assert.Contains(t, target.Error, "missing comma")
+	}
+	assert.Greater(t, plan.Runtime, 0.0)
+}
+
+func TestRenderHandlerTargetRewriters(t *testing.T) {
+	zipper := &countingCarbonZipper{}
+	saved := config.Config.ZipperInstance
+	config.Config.ZipperInstance = zipper
+	defer func() { config.Config.ZipperInstance = saved }()
+
+	savedChain := targetRewriters.chain
+	targetRewriters.chain = nil
+	defer func() { targetRewriters.chain = savedChain }()
+
+	var seen []string
+	RegisterTargetRewriter(func(target string) (string, error) {
+		if strings.HasPrefix(target, "old.") {
+			return "new." + strings.TrimPrefix(target, "old."), nil
+		}
+		return target, nil
+	})
+	RegisterTargetRewriter(func(target string) (string, error) {
+		if strings.HasPrefix(target, "forbidden.") {
+			return "", errors.New("forbidden metric: " + target)
+		}
+		return target, nil
+	})
+	RegisterTargetRewriter(func(target string) (string, error) {
+		// rewriters are called in order, so this one gets the result of the first one
+		seen = append(seen, target)
+		return target, nil
+	})
+
+	req, rr := setUpRequest(t, "/render/?target=old.foo.bar&target=sumSeries(foo.baz)&from=-10minutes&format=json&noCache=1")
+	renderHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.ElementsMatch(t, []string{"new.foo.bar", "foo.baz"}, zipper.requested)
+	assert.Equal(t, []string{"new.foo.bar", "sumSeries(foo.baz)"}, seen)
+
+	// an error short-circuits the chain and fails the request
+	seen = nil
+	zipper.requested = nil
+	req, rr = setUpRequest(t, "/render/?target=foo.bar&target=forbidden.foo&from=-10minutes&format=json&noCache=1")
+	renderHandler(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), "forbidden metric: forbidden.foo")
+	assert.Empty(t, zipper.requested)
+	assert.Equal(t, []string{"foo.bar"}, seen)
+}
+
+// wildcardCarbonZipper returns the given number of series for every requested metric
+type wildcardCarbonZipper struct {
+	countingCarbonZipper
+	seriesPerMetric int
+}
+
+func (z *wildcardCarbonZipper) Render(ctx context.Context, request pb.MultiFetchRequest) ([]*types.MetricData, *zipperTypes.Stats, merry.Error) {
+	z.renderCalls++
+	var result []*types.MetricData
+	for _, m := range request.Metrics {
+		z.requested = append(z.requested, m.PathExpression)
+		for i := 0; i < z.seriesPerMetric; i++ {
+			r := getMultiFetchResponse().Metrics[0]
+			r.Name = fmt.Sprintf("%s.%d", strings.TrimSuffix(m.PathExpression, ".*"), i)
+			r.PathExpression = m.PathExpression
+			result = append(result, &types.MetricData{FetchResponse: r})
+		}
+	}
+	return result, nil, nil
+}
+
+func TestRenderHandlerMaxSeries(t *testing.T) {
+	zipper := &wildcardCarbonZipper{seriesPerMetric: 3}
+	saved := config.Config.ZipperInstance
+	config.Config.ZipperInstance = zipper
+	defer func() { config.Config.ZipperInstance = saved }()
+	savedMaxSeries := config.Config.MaxSeries
+	defer func() { config.Config.MaxSeries = savedMaxSeries }()
+
+	// the limit is shared by all targets of the request: 2 targets fetch 6 series
+	config.Config.MaxSeries = 6
+	req, rr := setUpRequest(t, "/render/?target=foo.*&target=sumSeries(bar.*)&from=-10minutes&format=json&noCache=1")
+	renderHandler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	config.Config.MaxSeries = 5
+	zipper.renderCalls = 0
+	req, rr = setUpRequest(t, "/render/?target=foo.*&target=sumSeries(bar.*)&from=-10minutes&format=json&noCache=1")
+	renderHandler(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	var env errorEnvelope
+	if assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &env), rr.Body.String()) {
+		assert.Equal(t, errorCodeTooManySeries, env.Code)
+		assert.Contains(t, env.Error, "6 series matched, maximum is 5")
+	}
+	// series of prefetch are dropped, targets fail without fetching them again
+	assert.Equal(t, 1, zipper.renderCalls)
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"bytes"
 	"encoding/gob"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -39,6 +40,30 @@ func cleanupParams(r *http.Request) {
 	r.Form.Del("_t") // Used by jquery.graphite.js
 }
 
+// exprTreeResponse is a response for debug=tree, it shows how target was parsed
+type exprTreeResponse struct {
+	Target string      `json:"target"`
+	String string      `json:"string,omitempty"`
+	Tree   parser.Expr `json:"tree,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+func marshalExprTrees(targets []string) ([]byte, error) {
+	response := make([]exprTreeResponse, 0, len(targets))
+	for _, target := range targets {
+		res := exprTreeResponse{Target: target}
+		exp, e, err := parser.ParseExpr(target)
+		if err != nil || e != "" {
+			res.Error = buildParseErrorString(target, e, err)
+		} else {
+			res.String = exp.ToString()
+			res.Tree = exp
+		}
+		response = append(response, res)
+	}
+	return json.Marshal(response)
+}
+
 func setError(w http.ResponseWriter, accessLogDetails *carbonapipb.AccessLogDetails, msg string, status int) {
 	http.Error(w, http.StatusText(status)+": "+msg, status)
 	accessLogDetails.Reason = msg
@@ -111,10 +136,31 @@ func renderHandler(w http.ResponseWriter, r *http.Request) {
 	from := r.FormValue("from")
 	until := r.FormValue("until")
 	template := r.FormValue("template")
-	maxDataPoints, _ := strconv.ParseInt(r.FormValue("maxDataPoints"), 10, 64)
+	var maxDataPoints int64
+	if s := r.FormValue("maxDataPoints"); s != "" {
+		maxDataPoints, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || maxDataPoints < 0 {
+			setError(w, accessLogDetails, "maxDataPoints must be a non-negative integer", http.StatusBadRequest)
+			logAsError = true
+			return
+		}
+	}
 	ctx = utilctx.SetMaxDatapoints(ctx, maxDataPoints)
 	useCache := !parser.TruthyBool(r.FormValue("noCache"))
+	// query plan is returned instead of data, so targets are always evaluated
+	debugPlan := r.FormValue("debug") == "plan"
+	if debugPlan {
+		useCache = false
+	}
 	noNullPoints := parser.TruthyBool(r.FormValue("noNullPoints"))
+	// in strict mode request fails if any of targets can't be parsed or evaluated
+	strict := parser.TruthyBool(r.FormValue("strict"))
+	// series with different steps are not resampled, functions that combine them fail instead
+	strictStep := parser.TruthyBool(r.FormValue("strictStep"))
+	ctx = utilctx.SetStrictStep(ctx, strictStep)
+	if config.Config.DefaultConsolidateBy != "" {
+		ctx = utilctx.SetConsolidateBy(ctx, config.Config.DefaultConsolidateBy)
+	}
 	// status will be checked later after we'll setup everything else
 	format, ok, formatRaw := getFormat(r, pngFormat)
 
@@ -157,6 +203,17 @@ func renderHandler(w http.ResponseWriter, r *http.Request) {
 	qtz := r.FormValue("tz")
 	from32 := date.DateParamToEpoch(from, qtz, timeNow().Add(-24*time.Hour).Unix(), config.Config.DefaultTimeZone)
 	until32 := date.DateParamToEpoch(until, qtz, timeNow().Unix(), config.Config.DefaultTimeZone)
+	// functions that align to local time (e.x. summarize) use UTC unless timezone is configured or passed by client
+	tz := time.UTC
+	if config.Config.TimezoneString != "" {
+		tz = config.Config.DefaultTimeZone
+	}
+	if qtz != "" {
+		if loc, err := time.LoadLocation(qtz); err == nil {
+			tz = loc
+		}
+	}
+	ctx = utilctx.SetTimezone(ctx, tz)
 
 	accessLogDetails.UseCache = useCache
 	accessLogDetails.FromRaw = from
@@ -166,8 +223,28 @@ func renderHandler(w http.ResponseWriter, r *http.Request) {
 	accessLogDetails.Tz = qtz
 	accessLogDetails.CacheTimeout = responseCacheTimeout
 	accessLogDetails.Format = formatRaw
+	// targets are rewritten before anything is done with them, so logs, trees and cache keys show the rewritten ones
+	targets, err = rewriteTargets(targets)
+	if err != nil {
+		setError(w, accessLogDetails, err.Error(), http.StatusBadRequest)
+		logAsError = true
+		return
+	}
 	accessLogDetails.Targets = targets
 
+	// parsed targets are returned instead of data, regardless of format
+	if r.FormValue("debug") == "tree" {
+		body, err := marshalExprTrees(targets)
+		if err != nil {
+			setError(w, accessLogDetails, err.Error(), http.StatusInternalServerError)
+			logAsError = true
+			return
+		}
+		accessLogDetails.CarbonapiResponseSizeBytes = int64(len(body))
+		writeResponse(w, http.StatusOK, body, jsonFormat, jsonp)
+		return
+	}
+
 	if !ok || !format.ValidRenderFormat() {
 		setError(w, accessLogDetails, "unsupported format specified: "+formatRaw, http.StatusBadRequest)
 		logAsError = true
@@ -199,6 +276,12 @@ func renderHandler(w http.ResponseWriter, r *http.Request) {
 		for i, r := range pv3Request.Metrics {
 			targets[i] = r.PathExpression
 		}
+		targets, err = rewriteTargets(targets)
+		if err != nil {
+			setError(w, accessLogDetails, err.Error(), http.StatusBadRequest)
+			logAsError = true
+			return
+		}
 	}
 
 	if useCache {
@@ -239,13 +322,62 @@ func renderHandler(w http.ResponseWriter, r *http.Request) {
 			} else {
 				answer = fmt.Sprint(r)
 			}
+			if format == jsonFormat {
+				env := &errorEnvelope{Error: answer, Code: errorCodeInternal, Offset: -1}
+				setErrorEnvelope(w, accessLogDetails, env, http.StatusInternalServerError, jsonp)
+				return
+			}
 			setError(w, accessLogDetails, answer, http.StatusInternalServerError)
 		}
 	}()
 
+	var plan *expr.QueryPlan
+	if debugPlan || config.Config.SlowQueryThreshold > 0 {
+		plan = &expr.QueryPlan{}
+		ctx = expr.WithQueryPlan(ctx, plan)
+		defer func() {
+			runtime := time.Since(t0)
+			plan.Runtime = runtime.Seconds()
+			if config.Config.SlowQueryThreshold > 0 && runtime >= config.Config.SlowQueryThreshold {
+				logger.Warn("slow request",
+					zap.Strings("targets", targets),
+					zap.Any("plan", plan),
+				)
+			}
+		}()
+	}
+
+	// series are written as soon as they are evaluated, backend cache can't be used as results are not kept
+	// in strict mode errors of all targets must be known before the response is written
+	streaming := config.Config.StreamJSON && format == jsonFormat && jsonp == "" && maxDataPoints == 0 && !strict && !debugPlan
+	var jsonWriter *types.JSONWriter
+	streamSize := 0
 	errors := make(map[string]merry.Error)
-	backendCacheKey := backendCacheComputeKey(from, until, targets)
-	results, err := backendCacheFetchResults(logger, useCache, backendCacheKey, accessLogDetails)
+	// errors of targets are reported as errorEnvelope to json clients and as text to the rest
+	setTargetsError := func(msg string, status int) {
+		if format == jsonFormat {
+			setErrorEnvelope(w, accessLogDetails, newErrorEnvelope(targets, errors), status, jsonp)
+		} else {
+			setError(w, accessLogDetails, msg, status)
+		}
+		logAsError = true
+	}
+	emit := func(r *types.MetricData) error {
+		if jsonWriter.Count() == 0 {
+			// only errors of targets evaluated before the first series can be reported
+			setWarningsHeader(w, targets, errors)
+			w.Header().Set("Content-Type", contentTypeJSON)
+			w.WriteHeader(http.StatusOK)
+		}
+		streamSize += r.Size()
+		return jsonWriter.Write(r)
+	}
+	if streaming {
+		jsonWriter = types.NewJSONWriter(w, timestampMultiplier, noNullPoints)
+	}
+
+	backendCacheKey := backendCacheComputeKey(from, until, targets, strictStep)
+	results, err := backendCacheFetchResults(logger, useCache && !streaming, backendCacheKey, accessLogDetails)
 
 	if err != nil {
 		ApiMetrics.BackendCacheMisses.Add(1)
@@ -253,21 +385,62 @@ func renderHandler(w http.ResponseWriter, r *http.Request) {
 		results = make([]*types.MetricData, 0)
 		values := make(map[parser.MetricRequest][]*types.MetricData)
 
-		for _, target := range targets {
+		// targets that can't be parsed are reported as errors, the rest are evaluated as usual
+		exps := make([]parser.Expr, len(targets))
+		parsed := make([]parser.Expr, 0, len(targets))
+		for i, target := range targets {
 			exp, e, err := parser.ParseExpr(target)
 			if err != nil || e != "" {
-				msg := buildParseErrorString(target, e, err)
-				setError(w, accessLogDetails, msg, http.StatusBadRequest)
-				logAsError = true
-				return
+				errors[target] = newParseError(target, e, err)
+				plan.AddTarget(target, 0, 0, errors[target])
+				continue
+			}
+			exps[i] = exp
+			parsed = append(parsed, exp)
+		}
+
+		if strict && len(errors) > 0 {
+			setTargetsError(strings.Join(formatTargetErrors(targets, errors), "\n"), errorStatus(targets, errors))
+			return
+		}
+
+		// the limit of series is shared by all targets of the request
+		ctx = expr.WithSeriesLimit(ctx, config.Config.MaxSeries)
+		// strings repeated in backend responses are shared by all targets of the request as well
+		ctx = types.WithStringPool(ctx, types.NewStringPool())
+
+		// fetch unique metrics of all targets at once, targets are evaluated against the shared values
+		expr.Prefetch(ctx, parsed, from32, until32, values)
+
+		for i, target := range targets {
+			if exps[i] == nil {
+				continue
+			}
+
+			// client has gone away or request deadline is exceeded, don't evaluate the rest of targets
+			if err := ctx.Err(); err != nil {
+				errors[target] = merry.Wrap(err)
+				break
 			}
 
 			ApiMetrics.RenderRequests.Add(1)
 
-			result, err := expr.FetchAndEvalExp(ctx, exp, from32, until32, values)
+			targetCtx := expr.WithFunctionCallsLimit(ctx, config.Config.MaxFunctionCalls)
+			te := time.Now()
+			if streaming {
+				emitted := jsonWriter.Count()
+				err := expr.FetchAndEvalStream(targetCtx, exps[i], from32, until32, values, emit)
+				if err != nil {
+					errors[target] = merry.Wrap(err)
+				}
+				plan.AddTarget(target, jsonWriter.Count()-emitted, time.Since(te), err)
+				continue
+			}
+			result, err := expr.FetchAndEvalExp(targetCtx, exps[i], from32, until32, values)
 			if err != nil {
 				errors[target] = merry.Wrap(err)
 			}
+			plan.AddTarget(target, len(result), time.Since(te), err)
 
 			results = append(results, result...)
 		}
@@ -276,11 +449,41 @@ func renderHandler(w http.ResponseWriter, r *http.Request) {
 			expr.SortMetrics(values[mFetch], mFetch)
 		}
 
-		if len(errors) == 0 {
+		if len(errors) == 0 && !streaming {
 			backendCacheStoreResults(logger, backendCacheKey, results, backendCacheTimeout)
 		}
 	}
 
+	if debugPlan {
+		plan.Runtime = time.Since(t0).Seconds()
+		body, err := json.Marshal(plan)
+		if err != nil {
+			setError(w, accessLogDetails, err.Error(), http.StatusInternalServerError)
+			logAsError = true
+			return
+		}
+		accessLogDetails.CarbonapiResponseSizeBytes = int64(len(body))
+		writeResponse(w, http.StatusOK, body, jsonFormat, jsonp)
+		return
+	}
+
+	// if nothing was written, response is built as usual, e.x. to report errors
+	if streaming && jsonWriter.Count() > 0 {
+		if err := jsonWriter.Close(); err != nil {
+			logger.Debug("failed to write response", zap.Error(err))
+		}
+		accessLogDetails.Metrics = targets
+		accessLogDetails.CarbonzipperResponseSizeBytes = int64(streamSize)
+		accessLogDetails.CarbonapiResponseSizeBytes = jsonWriter.Size()
+		accessLogDetails.HaveNonFatalErrors = len(errors) > 0
+		return
+	}
+
+	if strict && hasTargetErrors(errors) {
+		setTargetsError(strings.Join(formatTargetErrors(targets, errors), "\n"), errorStatus(targets, errors))
+		return
+	}
+
 	size := 0
 	for _, result := range results {
 		size += result.Size()
@@ -296,17 +499,18 @@ func renderHandler(w http.ResponseWriter, r *http.Request) {
 		returnCode = http.StatusNotFound
 		errMsgs := make([]string, 0)
 		for _, err := range errors {
-			if merry.HTTPCode(err) == 404 || merry.Is(err, parser.ErrSeriesDoesNotExist) {
+			if !isTargetError(err) {
 				continue
 			}
 			errMsgs = append(errMsgs, err.Error())
-			if merry.HTTPCode(err) == 400 {
+			_, status := classifyError(err)
+			if status == 400 {
 				// The 400 is returned on wrong requests, e.g. non-existent functions
-				returnCode = merry.HTTPCode(err)
+				returnCode = status
 				continue
 			}
 			if returnCode < 500 {
-				returnCode = merry.HTTPCode(err)
+				returnCode = status
 			}
 		}
 		logger.Debug("error response or no response", zap.Strings("error", errMsgs))
@@ -315,20 +519,18 @@ func renderHandler(w http.ResponseWriter, r *http.Request) {
 			returnCode = config.Config.NotFoundStatusCode
 		}
 		if returnCode == 400 {
-			setError(w, accessLogDetails, strings.Join(errMsgs, ","), returnCode)
-			logAsError = true
+			setTargetsError(strings.Join(errMsgs, ","), returnCode)
 			return
 		}
 		if returnCode >= 500 {
-			setError(w, accessLogDetails, "error or no response: "+strings.Join(errMsgs, ","), returnCode)
-			logAsError = true
+			setTargetsError("error or no response: "+strings.Join(errMsgs, ","), returnCode)
 			return
 		}
 	}
 
 	switch format {
 	case jsonFormat:
-		if maxDataPoints != 0 {
+		if maxDataPoints > 0 {
 			types.ConsolidateJSON(maxDataPoints, results)
 			accessLogDetails.MaxDataPoints = maxDataPoints
 		}
@@ -353,7 +555,12 @@ func renderHandler(w http.ResponseWriter, r *http.Request) {
 	case csvFormat:
 		body = types.MarshalCSV(results)
 	case pickleFormat:
-		body = types.MarshalPickle(results)
+		body, err = types.MarshalPickle(results)
+		if err != nil {
+			setError(w, accessLogDetails, err.Error(), http.StatusInternalServerError)
+			logAsError = true
+			return
+		}
 	case pngFormat:
 		body = png.MarshalPNGRequest(r, results, template)
 	case svgFormat:
@@ -364,9 +571,11 @@ func renderHandler(w http.ResponseWriter, r *http.Request) {
 	accessLogDetails.CarbonzipperResponseSizeBytes = int64(size)
 	accessLogDetails.CarbonapiResponseSizeBytes = int64(len(body))
 
+	setWarningsHeader(w, targets, errors)
 	writeResponse(w, returnCode, body, format, jsonp)
 
-	if len(results) != 0 {
+	// partial responses are not cached, as warnings are not kept in the cache
+	if len(results) != 0 && !hasTargetErrors(errors) {
 		tc := time.Now()
 		config.Config.ResponseCache.Set(responseCacheKey, body, responseCacheTimeout)
 		td := time.Since(tc).Nanoseconds()
@@ -377,7 +586,7 @@ func renderHandler(w http.ResponseWriter, r *http.Request) {
 	accessLogDetails.HaveNonFatalErrors = gotErrors
 }
 
-func backendCacheComputeKey(from, until string, targets []string) string {
+func backendCacheComputeKey(from, until string, targets []string, strictStep bool) string {
 	var backendCacheKey bytes.Buffer
 	backendCacheKey.WriteString("from:")
 	backendCacheKey.WriteString(from)
@@ -385,6 +594,10 @@ func backendCacheComputeKey(from, until string, targets []string) string {
 	backendCacheKey.WriteString(until)
 	backendCacheKey.WriteString(" targets:")
 	backendCacheKey.WriteString(strings.Join(targets, ","))
+	// results depend on it, as series are evaluated without resampling
+	if strictStep {
+		backendCacheKey.WriteString(" strictStep")
+	}
 	return backendCacheKey.String()
 }
 
@@ -0,0 +1,43 @@
+package http
+
+import "sync"
+
+// TargetRewriter rewrites a raw target of a render request before it's parsed, e.x. to add a prefix or to redirect
+// deprecated metric names without changing dashboards. Error fails the request with 400 and the message of the error.
+type TargetRewriter func(target string) (string, error)
+
+var targetRewriters struct {
+	sync.RWMutex
+	chain []TargetRewriter
+}
+
+// RegisterTargetRewriter appends rewriter to the chain of target rewriters. Rewriters are called in the order they are
+// registered, every rewriter gets the target returned by the previous one. It's expected to be called on startup,
+// before handlers are served.
+func RegisterTargetRewriter(rewriter TargetRewriter) {
+	targetRewriters.Lock()
+	targetRewriters.chain = append(targetRewriters.chain, rewriter)
+	targetRewriters.Unlock()
+}
+
+// rewriteTargets passes every target through the chain of rewriters. The first error stops the chain and is returned.
+// targets are not modified, as they are the values of the request form.
+func rewriteTargets(targets []string) ([]string, error) {
+	targetRewriters.RLock()
+	defer targetRewriters.RUnlock()
+	if len(targetRewriters.chain) == 0 {
+		return targets, nil
+	}
+
+	rewritten := make([]string, len(targets))
+	for i, target := range targets {
+		var err error
+		for _, rewriter := range targetRewriters.chain {
+			if target, err = rewriter(target); err != nil {
+				return nil, err
+			}
+		}
+		rewritten[i] = target
+	}
+	return rewritten, nil
+}
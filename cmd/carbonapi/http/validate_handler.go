@@ -0,0 +1,86 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-graphite/carbonapi/carbonapipb"
+	"github.com/go-graphite/carbonapi/expr"
+	utilctx "github.com/go-graphite/carbonapi/util/ctx"
+	"github.com/lomik/zapwriter"
+)
+
+type validateResponse struct {
+	Target string                  `json:"target"`
+	Valid  bool                    `json:"valid"`
+	Errors []*expr.ValidationError `json:"errors,omitempty"`
+}
+
+// validateHandler checks targets without fetching any data, so editors can highlight errors while query is typed
+func validateHandler(w http.ResponseWriter, r *http.Request) {
+	t0 := time.Now()
+	username, _, _ := r.BasicAuth()
+
+	srcIP, srcPort := splitRemoteAddr(r.RemoteAddr)
+
+	accessLogger := zapwriter.Logger("access")
+	var accessLogDetails = carbonapipb.AccessLogDetails{
+		Handler:        "validate",
+		Username:       username,
+		URL:            r.URL.RequestURI(),
+		PeerIP:         srcIP,
+		PeerPort:       srcPort,
+		Host:           r.Host,
+		Referer:        r.Referer(),
+		URI:            r.RequestURI,
+		RequestHeaders: utilctx.GetLogHeaders(r.Context()),
+	}
+
+	logAsError := false
+	defer func() {
+		deferredAccessLogging(accessLogger, &accessLogDetails, t0, logAsError)
+	}()
+
+	ApiMetrics.Requests.Add(1)
+
+	err := r.ParseForm()
+	if err != nil {
+		setError(w, &accessLogDetails, err.Error(), http.StatusBadRequest)
+		logAsError = true
+		return
+	}
+
+	targets := r.Form["target"]
+	accessLogDetails.Targets = targets
+	if len(targets) == 0 {
+		setError(w, &accessLogDetails, "no target specified", http.StatusBadRequest)
+		logAsError = true
+		return
+	}
+
+	response := make([]validateResponse, 0, len(targets))
+	for _, target := range targets {
+		res := validateResponse{Target: target, Valid: true}
+		if err := expr.ParseAndValidate(target); err != nil {
+			if verrs, ok := err.(expr.ValidationErrors); ok {
+				res.Valid = false
+				res.Errors = verrs
+			} else {
+				setError(w, &accessLogDetails, err.Error(), http.StatusBadRequest)
+				logAsError = true
+				return
+			}
+		}
+		response = append(response, res)
+	}
+
+	b, err := json.Marshal(response)
+	if err != nil {
+		setError(w, &accessLogDetails, err.Error(), http.StatusInternalServerError)
+		logAsError = true
+		return
+	}
+
+	writeResponse(w, http.StatusOK, b, jsonFormat, r.FormValue("jsonp"))
+}
@@ -6,7 +6,6 @@ import (
 
 	"github.com/ansel1/merry"
 	"github.com/go-graphite/carbonapi/expr/helper"
-	tags2 "github.com/go-graphite/carbonapi/expr/tags"
 	"github.com/go-graphite/carbonapi/expr/types"
 	util "github.com/go-graphite/carbonapi/util/ctx"
 	realZipper "github.com/go-graphite/carbonapi/zipper"
@@ -91,13 +90,7 @@ func (z zipper) Render(ctx context.Context, request pb.MultiFetchRequest) ([]*ty
 	z.statsSender(stats)
 
 	if pbresp != nil {
-		for i := range pbresp.Metrics {
-			tags := tags2.ExtractTags(pbresp.Metrics[i].Name)
-			result = append(result, &types.MetricData{
-				FetchResponse: pbresp.Metrics[i],
-				Tags:          tags,
-			})
-		}
+		result = types.FromFetchResponses(pbresp.Metrics, types.GetStringPool(ctx))
 	}
 
 	sort.Sort(helper.ByNameNatural(result))
@@ -47,3 +47,34 @@ func TestDateParamToEpoch(t *testing.T) {
 		}
 	}
 }
+
+func TestDateParamToEpochTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone database is not available: %v", err)
+	}
+
+	timeNow = func() time.Time {
+		// 16 Aug 1994 02:00 UTC, still 15 Aug in New York
+		return time.Date(1994, time.August, 16, 2, 0, 0, 0, time.UTC)
+	}
+
+	var tests = []struct {
+		input string
+		qtz   string
+		want  time.Time
+	}{
+		{"midnight 20060812", "America/New_York", time.Date(2006, time.August, 12, 0, 0, 0, 0, loc)},
+		{"midnight", "America/New_York", time.Date(1994, time.August, 15, 0, 0, 0, 0, loc)},
+		{"midnight", "", time.Date(1994, time.August, 16, 0, 0, 0, 0, time.UTC)},
+		// unknown timezone falls back to the default one
+		{"midnight 20060812", "No/Such_Zone", time.Date(2006, time.August, 12, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got := DateParamToEpoch(tt.input, tt.qtz, 0, time.UTC)
+		if got != tt.want.Unix() {
+			t.Errorf("dateParamToEpoch(%q, %q)=%v, want %v", tt.input, tt.qtz, got, tt.want.Unix())
+		}
+	}
+}
@@ -25,7 +25,7 @@ var ConsolidationToFunc = map[string]func([]float64) float64{
 	"multiply": summarizeToAggregate("multiply"),
 	"range":    summarizeToAggregate("range"),
 	"sum":      AggSum,
-	"stddev":   summarizeToAggregate("stddev"),
+	"stddev":   AggStddev,
 	"first":    AggFirst,
 	"last":     AggLast,
 }
@@ -66,28 +66,68 @@ func VarianceValue(f64s []float64) float64 {
 	return squareSum / float64(elts)
 }
 
-// Percentile returns percent-th percentile. Can interpolate if needed
+// Stddev returns population standard deviation of non-NaN values and number of them. Deviation of less than 2 values
+// is NaN. It's the core of both stddevSeries, that aggregates values of all series at the same timestamp, and stdev,
+// that computes deviation over a moving window of a single series with types.Windowed.
+func Stddev(values []float64) (float64, int) {
+	mean := AggMean(values)
+	var squareSum float64
+	var n int
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		n++
+		squareSum += (v - mean) * (v - mean)
+	}
+	if n < 2 {
+		return math.NaN(), n
+	}
+	return math.Sqrt(squareSum / float64(n)), n
+}
+
+// Percentile returns percent-th percentile of non-NaN values of data, or NaN if there are none.
+// It uses the same nearest-rank method as graphite-web: rank of the percentile is percent/100*(n+1), rounded up
+// to an actual value unless interpolate is set, in which case the result is interpolated between the values
+// at the ranks around it.
 func Percentile(data []float64, percent float64, interpolate bool) float64 {
-	dataFiltered := make([]float64, 0)
+	// quickselect reorders values, so they are copied to a scratch buffer
+	dataFiltered := GetBuffer(len(data))[:0]
+	defer PutBuffer(dataFiltered)
 	for _, v := range data {
 		if !math.IsNaN(v) {
 			dataFiltered = append(dataFiltered, v)
 		}
 	}
 
-	if len(dataFiltered) == 0 || percent < 0 || percent > 100 {
+	n := len(dataFiltered)
+	if n == 0 || percent < 0 || percent > 100 {
 		return math.NaN()
 	}
-	if len(dataFiltered) == 1 {
-		return dataFiltered[0]
+
+	fractionalRank := percent / 100 * float64(n+1)
+	rank := int(fractionalRank)
+	rankFraction := fractionalRank - float64(rank)
+	if !interpolate {
+		rank += int(math.Ceil(rankFraction))
 	}
 
-	k := (float64(len(dataFiltered)-1) * percent) / 100
-	length := int(math.Ceil(k)) + 1
+	// ranks are 1-based, ones outside of data are clamped to the lowest and highest values
+	idx := rank - 1
+	if idx < 0 {
+		idx = 0
+	} else if idx >= n {
+		idx = n - 1
+	}
+	// the value at the next rank is only needed for interpolation
+	next := idx
+	if interpolate && rank > 0 && rank < n {
+		next = rank
+	}
 
-	_ = quickselect.Float64QuickSelect(dataFiltered, length)
+	_ = quickselect.Float64QuickSelect(dataFiltered, next+1)
 	top, secondTop := math.Inf(-1), math.Inf(-1)
-	for _, val := range dataFiltered[0:length] {
+	for _, val := range dataFiltered[0 : next+1] {
 		if val > top {
 			secondTop = top
 			top = val
@@ -95,11 +135,10 @@ func Percentile(data []float64, percent float64, interpolate bool) float64 {
 			secondTop = val
 		}
 	}
-	remainder := k - float64(int(k))
-	if remainder == 0 || !interpolate {
+	if next == idx {
 		return top
 	}
-	return (top * remainder) + (secondTop * (1 - remainder))
+	return secondTop + rankFraction*(top-secondTop)
 }
 
 func summarizeToAggregate(f string) func([]float64) float64 {
@@ -214,15 +253,14 @@ func SummarizeValues(f string, values []float64, XFilesFactor float32) float64 {
 		rv = float64(len(values))
 		total = notNans(values)
 	case "stddev":
-		rv = math.Sqrt(VarianceValue(values))
-		total = notNans(values)
+		rv, total = Stddev(values)
 	default:
-		f = strings.Split(f, "p")[1]
-		percent, err := strconv.ParseFloat(f, 64)
-		if err == nil {
-			total = notNans(values)
-			rv = Percentile(values, percent, true)
+		percent, ok := parsePercentile(f)
+		if !ok {
+			return math.NaN()
 		}
+		total = notNans(values)
+		rv = Percentile(values, percent, true)
 	}
 
 	if float32(total)/float32(len(values)) < XFilesFactor {
@@ -232,6 +270,52 @@ func SummarizeValues(f string, values []float64, XFilesFactor float32) float64 {
 	return rv
 }
 
+// XFilesFactor reports whether nonNull out of total points are enough to produce a value, as graphite-web xff does.
+// At least one non-null point is always required.
+func XFilesFactor(nonNull, total int, xFilesFactor float32) bool {
+	if nonNull == 0 || total == 0 {
+		return false
+	}
+	return float32(nonNull)/float32(total) >= xFilesFactor
+}
+
+// parsePercentile parses percentile aggregation in form of p50 or p99.9
+func parsePercentile(f string) (float64, bool) {
+	if !strings.HasPrefix(f, "p") {
+		return 0, false
+	}
+	percent, err := strconv.ParseFloat(f[1:], 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, false
+	}
+	return percent, true
+}
+
+// IsSummarizer returns true if SummarizeValues supports f
+func IsSummarizer(f string) bool {
+	for _, s := range AvailableSummarizers {
+		if s == f {
+			return true
+		}
+	}
+	_, ok := parsePercentile(f)
+	return ok
+}
+
+// GetAggregateFunc returns aggregation function from ConsolidationToFunc, or percentile one for names in form of p50
+func GetAggregateFunc(name string) (func([]float64) float64, bool) {
+	if f, ok := ConsolidationToFunc[name]; ok {
+		return f, true
+	}
+	percent, ok := parsePercentile(name)
+	if !ok {
+		return nil, false
+	}
+	return func(values []float64) float64 {
+		return Percentile(values, percent, false)
+	}, true
+}
+
 var consolidateFuncs []string
 
 // AvailableConsolidationFuncs lists all available consolidation functions
@@ -279,6 +363,12 @@ func AggMeanZero(v []float64) float64 {
 	return sum / float64(n)
 }
 
+// AggStddev computes population standard deviation of values, see Stddev
+func AggStddev(v []float64) float64 {
+	d, _ := Stddev(v)
+	return d
+}
+
 // AggMax computes max of values
 func AggMax(v []float64) float64 {
 	var m = math.Inf(-1)
@@ -126,15 +126,229 @@ func TestSummarizeValues(t *testing.T) {
 			xFilesFactor: 0,
 			expected:     3,
 		},
+		{
+			name:         "unknown function",
+			function:     "si",
+			values:       []float64{1, 2, 3},
+			xFilesFactor: 0,
+			expected:     math.NaN(),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			actual := SummarizeValues(tt.function, tt.values, tt.xFilesFactor)
-			if math.Abs(actual-tt.expected) > epsilon {
+			if math.IsNaN(actual) != math.IsNaN(tt.expected) || math.Abs(actual-tt.expected) > epsilon {
+				t.Errorf("actual %v, expected %v", actual, tt.expected)
+			}
+		})
+	}
+
+}
+
+func TestStddev(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name      string
+		values    []float64
+		expected  float64
+		validSize int
+	}{
+		{"empty", []float64{}, nan, 0},
+		{"single", []float64{nan, 5, nan}, nan, 1},
+		{"constant", []float64{3, 3, 3}, 0, 3},
+		{"population", []float64{1, 2, 3, 4}, 1.118033988749895, 4},
+		{"skips absent", []float64{2, nan, 4, nan, 4, 4, 5, 5, 7, 9}, 2, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n := Stddev(tt.values)
+			if n != tt.validSize {
+				t.Errorf("got %d valid values, want %d", n, tt.validSize)
+			}
+			if math.IsNaN(got) != math.IsNaN(tt.expected) || (!math.IsNaN(got) && math.Abs(got-tt.expected) > 1e-12) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBufferPool(t *testing.T) {
+	for _, n := range []int{1, 3, 4, 1000, 1024, 1025} {
+		b := GetBuffer(n)
+		if len(b) != n || cap(b)&(cap(b)-1) != 0 {
+			t.Errorf("GetBuffer(%d): got len %d, cap %d", n, len(b), cap(b))
+		}
+		PutBuffer(b)
+	}
+
+	if b := GetBuffer(0); b != nil {
+		t.Errorf("GetBuffer(0): expected nil, got %v", b)
+	}
+
+	// buffers that aren't from the pool are ignored
+	PutBuffer(make([]float64, 3))
+	PutBuffer(nil)
+}
+
+func TestPercentile(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name        string
+		values      []float64
+		percent     float64
+		interpolate bool
+		expected    float64
+	}{
+		{"p0", []float64{3, 1, 2}, 0, false, 1},
+		{"p0 interpolate", []float64{3, 1, 2}, 0, true, 1},
+		{"p100", []float64{3, 1, 2}, 100, false, 3},
+		{"p100 interpolate", []float64{3, 1, 2}, 100, true, 3},
+		{"single value", []float64{nan, 42, nan}, 95, false, 42},
+		{"single value interpolate", []float64{42}, 5, true, 42},
+		{"all NaN", []float64{nan, nan}, 50, false, nan},
+		{"empty", []float64{}, 50, true, nan},
+		{"out of range", []float64{1, 2}, 101, false, nan},
+		// rank is 0.5*(4+1) = 2.5, rounded up to the 3rd value
+		{"p50 nearest rank", []float64{4, 1, 3, 2}, 50, false, 3},
+		{"p50 interpolate", []float64{4, 1, 3, 2}, 50, true, 2.5},
+		// rank is 0.9*(6+1) = 6.3, which is above the last value
+		{"p90", []float64{2, 4, 6, 10, 14, 20}, 90, true, 20},
+		// rank is 0.25*(5+1) = 1.5
+		{"p25 nearest rank", []float64{5, 4, nan, 3, 2, 1}, 25, false, 2},
+		{"p25 interpolate", []float64{5, 4, nan, 3, 2, 1}, 25, true, 1.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := Percentile(tt.values, tt.percent, tt.interpolate)
+			if math.IsNaN(actual) != math.IsNaN(tt.expected) || (!math.IsNaN(actual) && actual != tt.expected) {
 				t.Errorf("actual %v, expected %v", actual, tt.expected)
 			}
 		})
 	}
+}
+
+func TestGetAggregateFunc(t *testing.T) {
+	values := []float64{1, 2, 3, 4, math.NaN()}
+	for name, expected := range map[string]float64{"max": 4, "p50": 3, "p0": 1, "p99.9": 4} {
+		f, ok := GetAggregateFunc(name)
+		if !ok {
+			t.Errorf("%s: function not found", name)
+			continue
+		}
+		if actual := f(values); actual != expected {
+			t.Errorf("%s: actual %v, expected %v", name, actual, expected)
+		}
+	}
+
+	for _, name := range []string{"p", "p101", "pmax", "unknown"} {
+		if _, ok := GetAggregateFunc(name); ok {
+			t.Errorf("%s: expected to be unsupported", name)
+		}
+	}
+}
+
+func TestIsSummarizer(t *testing.T) {
+	for _, f := range []string{"sum", "total", "median", "p50", "p99.9"} {
+		if !IsSummarizer(f) {
+			t.Errorf("%s: expected to be supported", f)
+		}
+	}
+	for _, f := range []string{"", "p", "p101", "maximum", "unknown"} {
+		if IsSummarizer(f) {
+			t.Errorf("%s: expected to be unsupported", f)
+		}
+	}
+}
+
+func TestPercentileKeepsData(t *testing.T) {
+	data := []float64{5, 1, math.NaN(), 4, 2, 3}
+	for i := 0; i < 3; i++ {
+		if got := Percentile(data, 50, false); got != 3 {
+			t.Errorf("Percentile: expected 3, got %v", got)
+		}
+	}
+	if data[0] != 5 || data[1] != 1 || data[5] != 3 {
+		t.Errorf("Percentile modified its argument: %v", data)
+	}
+}
+
+func TestXFilesFactor(t *testing.T) {
+	tests := []struct {
+		nonNull, total int
+		xFilesFactor   float32
+		want           bool
+	}{
+		{0, 5, 0, false},
+		{1, 5, 0, true},
+		{2, 5, 0.5, false},
+		{3, 5, 0.5, true},
+		{5, 5, 1, true},
+		{4, 5, 1, false},
+		{0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		if got := XFilesFactor(tt.nonNull, tt.total, tt.xFilesFactor); got != tt.want {
+			t.Errorf("XFilesFactor(%d, %d, %v): expected %v, got %v", tt.nonNull, tt.total, tt.xFilesFactor, tt.want, got)
+		}
+	}
+}
+
+func TestLTTBIndexes(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []float64
+		threshold int
+		want      []int
+	}{
+		{"peak and dip", []float64{0, 1, 0, 10, 0, 1, 0, 0, -5, 0}, 4, []int{0, 3, 8, 9}},
+		{"every bucket", []float64{0, 5, 1, 2, 7, 1, 0}, 5, []int{0, 1, 2, 4, 6}},
+		{"fits into threshold", []float64{1, 2, 3}, 3, nil},
+		{"threshold too small", []float64{1, 2, 3, 4}, 2, nil},
+		{"absent points", []float64{1, math.NaN(), 3, 4, 5}, 3, nil},
+		{"infinite points", []float64{1, math.Inf(1), 3, 4, 5}, 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LTTBIndexes(tt.values, tt.threshold)
+			if len(got) != len(tt.want) {
+				t.Fatalf("LTTBIndexes(%v, %d): expected %v, got %v", tt.values, tt.threshold, tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("LTTBIndexes(%v, %d): expected %v, got %v", tt.values, tt.threshold, tt.want, got)
+				}
+			}
+		})
+	}
+}
 
+func TestLTTBIndexesLarge(t *testing.T) {
+	values := make([]float64, 10000)
+	for i := range values {
+		values[i] = math.Sin(float64(i) / 100)
+	}
+	values[4321] = 100
+
+	for _, threshold := range []int{3, 10, 333, 9999} {
+		got := LTTBIndexes(values, threshold)
+		if len(got) != threshold {
+			t.Fatalf("threshold %d: expected %d points, got %d", threshold, threshold, len(got))
+		}
+		if got[0] != 0 || got[len(got)-1] != len(values)-1 {
+			t.Errorf("threshold %d: first and last points must be selected, got %d and %d", threshold, got[0], got[len(got)-1])
+		}
+		peak := false
+		for i, idx := range got {
+			if i > 0 && idx <= got[i-1] {
+				t.Fatalf("threshold %d: indexes are not ascending at %d: %v", threshold, i, got[i-1:i+1])
+			}
+			peak = peak || idx == 4321
+		}
+		if !peak && threshold > 3 {
+			t.Errorf("threshold %d: peak wasn't selected", threshold)
+		}
+	}
 }
@@ -0,0 +1,75 @@
+package consolidations
+
+import "math"
+
+// LTTB is the name of Largest-Triangle-Three-Buckets consolidation. It's not an aggregation of consecutive values, so
+// it's not in ConsolidationToFunc: series consolidated with it fall back to average where LTTB can't be used.
+const LTTB = "lttb"
+
+// LTTBIndexes selects threshold points of values with Largest-Triangle-Three-Buckets algorithm and returns their
+// indexes in ascending order. Unlike averaging it keeps peaks and dips of the series, as every selected point is the one
+// that forms the largest triangle with the point selected from the previous bucket and the average of the next bucket.
+// The first and the last points are always selected.
+//
+// Points are expected to be evenly spaced, so index is used as x. nil is returned if values already fit into
+// threshold or LTTB can't be applied: threshold is less than 3 or some of values are absent or infinite.
+func LTTBIndexes(values []float64, threshold int) []int {
+	n := len(values)
+	if threshold < 3 || n <= threshold {
+		return nil
+	}
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+	}
+
+	indexes := make([]int, 0, threshold)
+	indexes = append(indexes, 0)
+
+	// the first and the last points are buckets of their own, the rest is split into threshold-2 buckets
+	bucketSize := float64(n-2) / float64(threshold-2)
+	bucketStart := func(i int) int {
+		start := int(float64(i)*bucketSize) + 1
+		if start > n-1 {
+			start = n - 1
+		}
+		return start
+	}
+
+	a := 0
+	for i := 0; i < threshold-2; i++ {
+		start, end := bucketStart(i), bucketStart(i+1)
+
+		// the third vertex of the triangle is the average point of the next bucket
+		nextStart, nextEnd := end, bucketStart(i+2)
+		if i == threshold-3 {
+			// bucket boundaries are rounded, the last bucket must end right before the last point
+			end = n - 1
+			nextStart, nextEnd = n-1, n
+		}
+		var avgX, avgY float64
+		for j := nextStart; j < nextEnd; j++ {
+			avgX += float64(j)
+			avgY += values[j]
+		}
+		count := float64(nextEnd - nextStart)
+		avgX /= count
+		avgY /= count
+
+		ax, ay := float64(a), values[a]
+		maxArea, selected := -1.0, start
+		for j := start; j < end; j++ {
+			// doubled area of the triangle, only comparison matters
+			area := math.Abs((ax-avgX)*(values[j]-ay) - (ax-float64(j))*(avgY-ay))
+			if area > maxArea {
+				maxArea, selected = area, j
+			}
+		}
+
+		indexes = append(indexes, selected)
+		a = selected
+	}
+
+	return append(indexes, n-1)
+}
@@ -0,0 +1,42 @@
+package consolidations
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// bufferPools keeps scratch []float64 buffers, pool i holds buffers with capacity of 1<<i
+var bufferPools [32]sync.Pool
+
+// GetBuffer returns a scratch buffer of length n. Content of the buffer is undefined.
+//
+// Buffer must be returned with PutBuffer as soon as caller is done with it and must never be used as values of
+// a series or kept in any other way after that: buffer is reused by other requests.
+func GetBuffer(n int) []float64 {
+	if n == 0 {
+		return nil
+	}
+	i := bits.Len(uint(n - 1))
+	if i >= len(bufferPools) {
+		return make([]float64, n)
+	}
+	if b, ok := bufferPools[i].Get().(*[]float64); ok {
+		return (*b)[:n]
+	}
+	return make([]float64, n, 1<<i)
+}
+
+// PutBuffer returns buffer obtained with GetBuffer to the pool
+func PutBuffer(b []float64) {
+	c := cap(b)
+	if c == 0 || c&(c-1) != 0 {
+		// not from GetBuffer
+		return
+	}
+	i := bits.Len(uint(c - 1))
+	if i >= len(bufferPools) {
+		return
+	}
+	b = b[:0]
+	bufferPools[i].Put(&b)
+}
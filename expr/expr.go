@@ -2,6 +2,7 @@ package expr
 
 import (
 	"context"
+	"time"
 
 	utilctx "github.com/go-graphite/carbonapi/util/ctx"
 
@@ -22,32 +23,51 @@ func (eval evaluator) FetchAndEvalExp(ctx context.Context, exp parser.Expr, from
 	config.Config.Limiter.Enter()
 	defer config.Config.Limiter.Leave()
 
+	targetValues, err := eval.fetch(ctx, exp, from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return eval.Eval(ctx, exp, from, until, targetValues)
+}
+
+// Fetch fetches metrics of exp for [from, until] that aren't in values yet, see interfaces.Fetcher.
+// It's called during evaluation, so limiter isn't entered again.
+func (eval evaluator) Fetch(ctx context.Context, exp parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) error {
+	missing := false
+	for _, r := range parser.FetchRequests(exp, from, until) {
+		if _, ok := values[r]; !ok {
+			missing = true
+			break
+		}
+	}
+	// evaluator can be used without backend, e.x. in tests, then only values are used
+	if !missing || config.Config.ZipperInstance == nil {
+		return nil
+	}
+
+	_, err := eval.fetch(ctx, exp, from, until, values)
+	return err
+}
+
+// fetch fetches metrics of exp that aren't in values yet and returns values related to exp
+func (eval evaluator) fetch(ctx context.Context, exp parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) (map[parser.MetricRequest][]*types.MetricData, error) {
 	multiFetchRequest := pb.MultiFetchRequest{}
 	metricRequestCache := make(map[string]parser.MetricRequest)
 	maxDataPoints := utilctx.GetMaxDatapoints(ctx)
 	// values related to this particular `target=`
 	targetValues := make(map[parser.MetricRequest][]*types.MetricData)
+	var requests []parser.MetricRequest
 
-	for _, m := range exp.Metrics() {
+	// requests are unique, so divideSeries(a.b, a.b) fetches a.b once
+	for _, metricRequest := range parser.FetchRequests(exp, from, until) {
 		fetchRequest := pb.FetchRequest{
-			Name:           m.Metric,
-			PathExpression: m.Metric,
-			StartTime:      m.From + from,
-			StopTime:       m.Until + until,
+			Name:           metricRequest.Metric,
+			PathExpression: metricRequest.Metric,
+			StartTime:      metricRequest.From,
+			StopTime:       metricRequest.Until,
 			MaxDataPoints:  maxDataPoints,
 		}
-		metricRequest := parser.MetricRequest{
-			Metric: fetchRequest.PathExpression,
-			From:   fetchRequest.StartTime,
-			Until:  fetchRequest.StopTime,
-		}
-
-		// avoid multiple requests in a function, E.g divideSeries(a.b, a.b)
-		if cachedMetricRequest, ok := metricRequestCache[m.Metric]; ok &&
-			cachedMetricRequest.From == metricRequest.From &&
-			cachedMetricRequest.Until == metricRequest.Until {
-			continue
-		}
 
 		// avoid multiple requests in a http request, E.g render?target=a.b&target=a.b
 		if _, ok := values[metricRequest]; ok {
@@ -60,17 +80,31 @@ func (eval evaluator) FetchAndEvalExp(ctx context.Context, exp parser.Expr, from
 			continue
 		}
 
-		metricRequestCache[m.Metric] = metricRequest
+		metricRequestCache[metricRequest.Metric] = metricRequest
 		targetValues[metricRequest] = nil
+		requests = append(requests, metricRequest)
 		multiFetchRequest.Metrics = append(multiFetchRequest.Metrics, fetchRequest)
 	}
 
 	if len(multiFetchRequest.Metrics) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, merry.Wrap(err)
+		}
+		// the limit can already be exceeded by prefetch or by other targets of the request
+		if err := countSeries(ctx, 0); err != nil {
+			return nil, err
+		}
+		t0 := time.Now()
 		metrics, _, err := config.Config.ZipperInstance.Render(ctx, multiFetchRequest)
+		GetQueryPlan(ctx).addFetch(requests, metrics, time.Since(t0), false)
 		// If we had only partial result, we want to do our best to actually do our job
 		if err != nil && merry.HTTPCode(err) >= 400 && exp.Target() != "fallbackSeries" {
 			return nil, err
 		}
+		if err := countSeries(ctx, len(metrics)); err != nil {
+			return nil, err
+		}
+		setConsolidateBy(ctx, metrics)
 		for _, metric := range metrics {
 			metricRequest := metricRequestCache[metric.PathExpression]
 			if metric.RequestStartTime != 0 && metric.RequestStopTime != 0 {
@@ -89,11 +123,93 @@ func (eval evaluator) FetchAndEvalExp(ctx context.Context, exp parser.Expr, from
 		targetValues[m] = values[m]
 	}
 
-	if config.Config.ZipperInstance.ScaleToCommonStep() {
+	// with strict step functions that combine series must see their original steps
+	if config.Config.ZipperInstance.ScaleToCommonStep() && !utilctx.GetStrictStep(ctx) {
 		targetValues = helper.ScaleValuesToCommonStep(targetValues)
 	}
 
-	return eval.Eval(ctx, exp, from, until, targetValues)
+	return targetValues, nil
+}
+
+// Prefetch fetches metrics of all expressions with a single backend request, so following FetchAndEvalExp calls for
+// them could be served from values. Metrics that backend hasn't returned are stored as empty lists to avoid
+// fetching them again per target. On backend errors values are left untouched and every target fetches its own data.
+func (eval evaluator) Prefetch(ctx context.Context, exps []parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) {
+	multiFetchRequest := pb.MultiFetchRequest{}
+	metricRequestCache := make(map[string]parser.MetricRequest)
+	requested := make(map[parser.MetricRequest]struct{})
+	var requests []parser.MetricRequest
+	maxDataPoints := utilctx.GetMaxDatapoints(ctx)
+
+	for _, exp := range exps {
+		for _, metricRequest := range parser.FetchRequests(exp, from, until) {
+			if _, ok := values[metricRequest]; ok {
+				continue
+			}
+			if _, ok := requested[metricRequest]; ok {
+				continue
+			}
+			// responses are matched by path expression, so the same metric with different time ranges can't be batched
+			if _, ok := metricRequestCache[metricRequest.Metric]; ok {
+				continue
+			}
+
+			metricRequestCache[metricRequest.Metric] = metricRequest
+			requested[metricRequest] = struct{}{}
+			requests = append(requests, metricRequest)
+			multiFetchRequest.Metrics = append(multiFetchRequest.Metrics, pb.FetchRequest{
+				Name:           metricRequest.Metric,
+				PathExpression: metricRequest.Metric,
+				StartTime:      metricRequest.From,
+				StopTime:       metricRequest.Until,
+				MaxDataPoints:  maxDataPoints,
+			})
+		}
+	}
+
+	if len(multiFetchRequest.Metrics) == 0 || ctx.Err() != nil {
+		return
+	}
+
+	config.Config.Limiter.Enter()
+	defer config.Config.Limiter.Leave()
+
+	t0 := time.Now()
+	metrics, _, err := config.Config.ZipperInstance.Render(ctx, multiFetchRequest)
+	GetQueryPlan(ctx).addFetch(requests, metrics, time.Since(t0), true)
+	if err != nil {
+		return
+	}
+	// series are dropped, every target fails with ErrTooManySeries when it tries to fetch them
+	if countSeries(ctx, len(metrics)) != nil {
+		return
+	}
+	setConsolidateBy(ctx, metrics)
+
+	for _, metric := range metrics {
+		metricRequest := metricRequestCache[metric.PathExpression]
+		delete(requested, metricRequest)
+		if metric.RequestStartTime != 0 && metric.RequestStopTime != 0 {
+			metricRequest.From = metric.RequestStartTime
+			metricRequest.Until = metric.RequestStopTime
+		}
+		values[metricRequest] = append(values[metricRequest], metric)
+	}
+
+	for metricRequest := range requested {
+		values[metricRequest] = make([]*types.MetricData, 0)
+	}
+}
+
+// setConsolidateBy overrides consolidation function of fetched series with the default one of the request, if it's set
+func setConsolidateBy(ctx context.Context, metrics []*types.MetricData) {
+	name := utilctx.GetConsolidateBy(ctx)
+	if name == "" {
+		return
+	}
+	for _, metric := range metrics {
+		metric.ConsolidationFunc = name
+	}
 }
 
 // Eval evalualtes expressions
@@ -131,6 +247,11 @@ func FetchAndEvalExp(ctx context.Context, e parser.Expr, from, until int64, valu
 	return _evaluator.FetchAndEvalExp(ctx, e, from, until, values)
 }
 
+// Prefetch fetches metrics of all expressions with a single backend request
+func Prefetch(ctx context.Context, exps []parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) {
+	_evaluator.Prefetch(ctx, exps, from, until, values)
+}
+
 // Eval is the main expression evaluator
 func EvalExpr(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
 	if e.IsName() {
@@ -147,6 +268,14 @@ func EvalExpr(ctx context.Context, e parser.Expr, from, until int64, values map[
 	}
 	// evaluate the function
 
+	// stop evaluation as soon as request is cancelled or its deadline is exceeded
+	if err := ctx.Err(); err != nil {
+		return nil, merry.Wrap(err)
+	}
+	if err := countFunctionCall(ctx); err != nil {
+		return nil, err
+	}
+
 	// all functions have arguments -- check we do too
 	if len(e.Args()) == 0 {
 		err := merry.WithMessagef(parser.ErrMissingArgument, "target=%s: %s", e.Target(), parser.ErrMissingArgument)
@@ -159,21 +288,7 @@ func EvalExpr(ctx context.Context, e parser.Expr, from, until int64, values map[
 	if ok {
 		v, err := f.Do(ctx, e, from, until, values)
 		if err != nil {
-			err = merry.WithMessagef(err, "function=%s: %s", e.Target(), err.Error())
-			if merry.Is(
-				err,
-				parser.ErrMissingExpr,
-				parser.ErrMissingComma,
-				parser.ErrMissingQuote,
-				parser.ErrUnexpectedCharacter,
-				parser.ErrBadType,
-				parser.ErrMissingArgument,
-				parser.ErrMissingTimeseries,
-				parser.ErrSeriesDoesNotExist,
-				parser.ErrUnknownTimeUnits,
-			) {
-				err = merry.WithHTTPCode(err, 400)
-			}
+			err = functionError(e, err)
 		}
 		return v, err
 	}
@@ -181,6 +296,28 @@ func EvalExpr(ctx context.Context, e parser.Expr, from, until int64, values map[
 	return nil, merry.WithHTTPCode(helper.ErrUnknownFunction(e.Target()), 400)
 }
 
+// functionError adds function name to the error returned by it, errors caused by the target itself are reported as 400
+func functionError(e parser.Expr, err error) error {
+	err = merry.WithMessagef(err, "function=%s: %s", e.Target(), err.Error())
+	if merry.Is(
+		err,
+		parser.ErrMissingExpr,
+		parser.ErrMissingComma,
+		parser.ErrMissingQuote,
+		parser.ErrUnexpectedCharacter,
+		parser.ErrBadType,
+		parser.ErrMissingArgument,
+		parser.ErrMissingTimeseries,
+		parser.ErrSeriesDoesNotExist,
+		parser.ErrUnknownTimeUnits,
+		parser.ErrInvalidRegex,
+		types.ErrStepMismatch,
+	) {
+		err = merry.WithHTTPCode(err, 400)
+	}
+	return err
+}
+
 // RewriteExpr expands targets that use applyByNode into a new list of targets.
 // eg:
 // applyByNode(foo*, 1, "%") -> (true, ["foo1", "foo2"], nil)
@@ -4,16 +4,22 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 	"unicode"
 
+	"github.com/ansel1/merry"
+
 	"github.com/go-graphite/carbonapi/expr/functions"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/rewrite"
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/pkg/parser"
 	th "github.com/go-graphite/carbonapi/tests"
+	utilctx "github.com/go-graphite/carbonapi/util/ctx"
+	pbv2 "github.com/go-graphite/protocol/carbonapi_v2_pb"
 	pb "github.com/go-graphite/protocol/carbonapi_v3_pb"
 )
 
@@ -68,7 +74,7 @@ func TestAlignToBucketSize(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		start, stop := helper.AlignToBucketSize(test.inputStart, test.inputStop, test.bucketSize)
+		start, stop := helper.AlignToBucketSize(test.inputStart, test.inputStop, test.bucketSize, time.UTC)
 		if start != test.wantStart || stop != test.wantStop {
 			t.Errorf("TestAlignToBucketSize failed!\n%v\ngot start %d stop %d",
 				test,
@@ -105,7 +111,7 @@ func TestAlignToInterval(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		start := helper.AlignStartToInterval(test.inputStart, test.inputStop, test.bucketSize)
+		start := helper.AlignStartToInterval(test.inputStart, test.inputStop, test.bucketSize, time.UTC)
 		if start != test.wantStart {
 			t.Errorf("TestAlignToInterval failed!\n%v\ngot start %d",
 				test,
@@ -276,6 +282,107 @@ func TestEvalExpression(t *testing.T) {
 			},
 			[]*types.MetricData{types.MakeMetricData("sumSeries(pow(devops.service.*.filter.received.*.count, 0))", []float64{2, 2, 2}, 1, now32)},
 		},
+		{
+			"scale(group(metric1,metric2),2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+				{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{4, 5, 6}, 1, now32)},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("scale(metric1,2)", []float64{2, 4, 6}, 1, now32),
+				types.MakeMetricData("scale(metric2,2)", []float64{8, 10, 12}, 1, now32),
+			},
+		},
+		{
+			"sumSeries(metric1,-metric2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+				{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{4, 5, 6}, 1, now32)},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("sumSeries(metric1,scale(metric2,-1))", []float64{-3, -3, -3}, 1, now32),
+			},
+		},
+		{
+			"sumSeries(group(metric1,metric2),metric3)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+				{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{4, 5, 6}, 1, now32)},
+				{"metric3", 0, 1}: {types.MakeMetricData("metric3", []float64{7, 8, 9}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("sumSeries(group(metric1,metric2),metric3)", []float64{12, 15, 18}, 1, now32)},
+		},
+		{
+			"scale(metric1,nPercentile(metric2,50))",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+				{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{4, 5, 6}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("scale(metric1,nPercentile(metric2,50))", []float64{5, 10, 15}, 1, now32)},
+		},
+		{
+			"offset(metric1,nPercentile(metric2,50))",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+				{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{4, 5, 6}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("offset(metric1,nPercentile(metric2,50))", []float64{6, 7, 8}, 1, now32)},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+}
+
+// TestEvalNestedTransforms checks that transforms are applied in order of nesting and NaNs are kept at every level
+func TestEvalNestedTransforms(t *testing.T) {
+	now32 := time.Now().Unix()
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"foo", 0, 1}: {types.MakeMetricData("foo", []float64{1, math.NaN(), -3, 10}, 1, now32)},
+		{"foo.*", 0, 1}: {
+			types.MakeMetricData("foo.a", []float64{math.NaN(), 2, -7}, 1, now32),
+			types.MakeMetricData("foo.b", []float64{4, math.NaN(), math.NaN()}, 1, now32),
+		},
+	}
+
+	tests := []th.EvalTestItem{
+		{
+			"absolute(offset(foo,-5))",
+			m,
+			[]*types.MetricData{types.MakeMetricData("absolute(offset(foo,-5))", []float64{4, math.NaN(), 8, 5}, 1, now32)},
+		},
+		{
+			"offset(absolute(foo),-5)",
+			m,
+			[]*types.MetricData{types.MakeMetricData("offset(absolute(foo),-5)", []float64{-4, math.NaN(), -2, 5}, 1, now32)},
+		},
+		{
+			"scale(absolute(offset(foo,-5)),0.5)",
+			m,
+			[]*types.MetricData{types.MakeMetricData("scale(absolute(offset(foo,-5)),0.5)", []float64{2, math.NaN(), 4, 2.5}, 1, now32)},
+		},
+		{
+			"absolute(scale(offset(foo,1),-2))",
+			m,
+			[]*types.MetricData{types.MakeMetricData("absolute(scale(offset(foo,1),-2))", []float64{4, math.NaN(), 4, 22}, 1, now32)},
+		},
+		{
+			"offset(scale(foo,0),1)",
+			m,
+			[]*types.MetricData{types.MakeMetricData("offset(scale(foo,0),1)", []float64{1, math.NaN(), 1, 1}, 1, now32)},
+		},
+		{
+			"absolute(offset(foo.*,-5))",
+			m,
+			[]*types.MetricData{
+				types.MakeMetricData("absolute(offset(foo.a,-5))", []float64{math.NaN(), 3, 12}, 1, now32),
+				types.MakeMetricData("absolute(offset(foo.b,-5))", []float64{1, math.NaN(), math.NaN()}, 1, now32),
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -286,6 +393,72 @@ func TestEvalExpression(t *testing.T) {
 	}
 }
 
+func TestEvalSeriesNames(t *testing.T) {
+	// 2014-01-01 00:00:00 UTC, a week of data before it is fetched for holtWinters*
+	from := int64(1388534400)
+	until := from + 3600
+
+	tests := []struct {
+		target string
+		names  []string
+	}{
+		{"aggregateLine(metric1,'max')", []string{"aggregateLine(metric1,1)"}},
+		{"aggregateLine(transformNull(metric1,-1),'max',true)", []string{"aggregateLine(transformNull(metric1,-1),1)"}},
+		{"timeSlice(metric1,'1388534460','1388537940')", []string{"timeSlice(metric1,1388534460,1388537940)"}},
+		{"timeShift(metric1,'1h')", []string{"timeShift(metric1,'-3600',false)"}},
+		{"timeShift(metric1,'1h',true)", []string{"timeShift(metric1,'-3600',true)"}},
+		{"hitcount(metric1,'10min')", []string{"hitcount(metric1,'10min')"}},
+		{"hitcount(metric1,'10min',true,xFilesFactor=0.5)", []string{"hitcount(metric1,'10min',true,xFilesFactor=0.5)"}},
+		{"smartSummarize(metric1,'10min')", []string{"smartSummarize(metric1,'10min','sum')"}},
+		{"smartSummarize(metric1,'10min','max','1h',0.5)", []string{"smartSummarize(metric1,'10min','max','1h',xFilesFactor=0.5)"}},
+		{"summarize(metric1,'10min')", []string{"summarize(metric1,'10min')"}},
+		{"summarize(metric1,'10min','max',true,0.5)", []string{"summarize(metric1,'10min','max',true,xFilesFactor=0.5)"}},
+		{"removeBelowValue(metric1,0)", []string{"removeBelowValue(metric1,0)"}},
+		{"removeAboveValue(metric1,2,true)", []string{"removeAboveValue(metric1,2,inclusive=True)"}},
+		{"removeBelowPercentile(metric1,50)", []string{"removeBelowPercentile(metric1,50)"}},
+		{"asPercent(metric1)", []string{"asPercent(metric1)"}},
+		{"asPercent(metric1,2)", []string{"asPercent(metric1,2)"}},
+		{"nonNegativeDerivative(metric1,minValue=0)", []string{"nonNegativeDerivative(metric1,minValue=0)"}},
+		{"holtWintersForecast(metric1)", []string{"holtWintersForecast(metric1)"}},
+		{"holtWintersConfidenceBands(metric1)", []string{"holtWintersConfidenceLower(metric1)", "holtWintersConfidenceUpper(metric1)"}},
+		{"holtWintersAberration(metric1)", []string{"holtWintersAberration(metric1)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			exp, _, err := parser.ParseExpr(tt.target)
+			if err != nil {
+				t.Fatalf("failed to parse %s: %v", tt.target, err)
+			}
+
+			m := make(map[parser.MetricRequest][]*types.MetricData)
+			for _, r := range parser.FetchRequests(exp, from, until) {
+				values := make([]float64, (r.Until-r.From)/60)
+				for i := range values {
+					values[i] = 1
+				}
+				m[r] = []*types.MetricData{types.MakeMetricData(r.Metric, values, 60, r.From)}
+			}
+
+			res, err := EvalExpr(context.Background(), exp, from, until, m)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(res) != len(tt.names) {
+				t.Fatalf("unexpected number of results: got %d, want %d", len(res), len(tt.names))
+			}
+			for i, r := range res {
+				if r.Name != tt.names[i] {
+					t.Errorf("name: got %q, want %q", r.Name, tt.names[i])
+				}
+				if strings.HasPrefix(tt.target, "holtWinters") && r.PathExpression != tt.names[i] {
+					t.Errorf("path expression: got %q, want %q", r.PathExpression, tt.names[i])
+				}
+			}
+		})
+	}
+}
+
 func TestRewriteExpr(t *testing.T) {
 	now32 := time.Now().Unix()
 
@@ -503,3 +676,613 @@ func TestEvalCustomFromUntil(t *testing.T) {
 		})
 	}
 }
+
+func TestEvalSharedMetric(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), 3}, 1, now32)},
+	}
+	originalMetrics := th.DeepClone(m)
+
+	targets := []string{
+		"alias(metric1,'a')",
+		"aliasSub(metric1,'metric','b')",
+		"aliasByMetric(metric1)",
+		"transformNull(metric1,0)",
+		"alias(metric1,'c')",
+	}
+	wantNames := []string{"a", "b1", "metric1", "transformNull(metric1,0)", "c"}
+	wantNameTags := []string{"a", "b1", "metric1", "metric1", "c"}
+
+	var results [][]*types.MetricData
+	for _, target := range targets {
+		exp, _, err := parser.ParseExpr(target)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", target, err)
+		}
+		g, err := EvalExpr(context.Background(), exp, 0, 1, m)
+		if err != nil {
+			t.Fatalf("failed to eval %s: %v", target, err)
+		}
+		results = append(results, g)
+	}
+
+	th.DeepEqual(t, "shared metric", originalMetrics, m, true)
+
+	for i, g := range results {
+		if len(g) != 1 {
+			t.Fatalf("%s: unexpected number of results %d", targets[i], len(g))
+		}
+		if g[0].Name != wantNames[i] || g[0].Tags["name"] != wantNameTags[i] {
+			t.Errorf("%s: got name %q and name tag %q, want %q and %q", targets[i], g[0].Name, g[0].Tags["name"], wantNames[i], wantNameTags[i])
+		}
+	}
+	if !th.NearlyEqual(results[3][0].Values, []float64{1, 0, 3}) {
+		t.Errorf("transformNull: got %v, want [1 0 3]", results[3][0].Values)
+	}
+	if !th.NearlyEqual(results[0][0].Values, []float64{1, math.NaN(), 3}) {
+		t.Errorf("alias: got %v, want [1 NaN 3]", results[0][0].Values)
+	}
+}
+
+func TestEvalBareMetric(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), 3}, 1, now32)},
+		{"metric.*", 0, 1}: {
+			types.MakeMetricData("metric.a", []float64{1, 2, 3}, 1, now32),
+			types.MakeMetricData("metric.b", []float64{4, math.NaN(), 6}, 1, now32),
+		},
+	}
+
+	for _, target := range []string{"metric1", "metric.*"} {
+		t.Run(target, func(t *testing.T) {
+			exp, _, err := parser.ParseExpr(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !exp.IsName() {
+				t.Fatalf("expected %s to be parsed as a series name, got type %v", target, exp.Type())
+			}
+			g, err := EvalExpr(context.Background(), exp, 0, 1, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// fetched series are returned as is
+			want := m[parser.MetricRequest{Metric: target, From: 0, Until: 1}]
+			if len(g) != len(want) {
+				t.Fatalf("unexpected number of results: got %d, want %d", len(g), len(want))
+			}
+			for i := range g {
+				if g[i] != want[i] {
+					t.Errorf("result %d: got %s, want the fetched series %s", i, g[i].Name, want[i].Name)
+				}
+			}
+		})
+	}
+}
+
+func TestEvalStream(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric.*", 0, 1}: {
+			types.MakeMetricData("metric.a", []float64{1, 2, 3, 4, math.NaN(), 6}, 1, now32),
+			types.MakeMetricData("metric.b", []float64{4, math.NaN(), 6, 7, 8, 9}, 1, now32),
+		},
+		{"metric.*", -2, 1}: {
+			types.MakeMetricData("metric.a", []float64{1, 2, 3, 4, math.NaN(), 6}, 1, now32),
+			types.MakeMetricData("metric.b", []float64{4, math.NaN(), 6, 7, 8, 9}, 1, now32),
+		},
+		{"factor", 0, 1}: {types.MakeMetricData("factor", []float64{2}, 1, now32)},
+	}
+
+	targets := []string{
+		"metric.*",
+		"scale(metric.*,2)",
+		"scale(movingAverage(metric.*,2),0.5)",
+		"movingSum(scale(metric.*,-1),'2s')",
+		// not streamed functions in between are evaluated in full
+		"scale(sumSeries(movingMax(metric.*,2)),3)",
+		"sumSeries(scale(metric.*,2))",
+		// factor computed from a series is evaluated in full first
+		"scale(metric.*,factor)",
+	}
+
+	for _, target := range targets {
+		t.Run(target, func(t *testing.T) {
+			exp, _, err := parser.ParseExpr(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := EvalExpr(context.Background(), exp, 0, 1, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []*types.MetricData
+			err = EvalStream(context.Background(), exp, 0, 1, m, func(r *types.MetricData) error {
+				got = append(got, r)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("unexpected number of results: got %d, want %d", len(got), len(want))
+			}
+			for i := range got {
+				if got[i].Name != want[i].Name || got[i].StartTime != want[i].StartTime || !th.NearlyEqual(got[i].Values, want[i].Values) {
+					t.Errorf("result %d: got %s %d %v, want %s %d %v", i, got[i].Name, got[i].StartTime, got[i].Values, want[i].Name, want[i].StartTime, want[i].Values)
+				}
+			}
+		})
+	}
+}
+
+func TestEvalStreamErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric.*", 0, 1}: {
+			types.MakeMetricData("metric.a", []float64{1, 2, 3}, 1, now32),
+			types.MakeMetricData("metric.b", []float64{4, 5, 6}, 1, now32),
+		},
+	}
+
+	exp, _, err := parser.ParseExpr("scale(metric.*,'a')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = EvalStream(context.Background(), exp, 0, 1, m, func(r *types.MetricData) error { return nil })
+	if !merry.Is(err, parser.ErrBadType) || merry.HTTPCode(err) != 400 {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// evaluation stops at the first error of emit
+	errStop := merry.New("stop")
+	exp, _, err = parser.ParseExpr("scale(metric.*,2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	emitted := 0
+	err = EvalStream(context.Background(), exp, 0, 1, m, func(r *types.MetricData) error {
+		emitted++
+		return errStop
+	})
+	if !merry.Is(err, errStop) || emitted != 1 {
+		t.Errorf("unexpected error %v after %d series", err, emitted)
+	}
+}
+
+// cancelAfterContext reports cancellation after Err was called n times, emulating a request cancelled mid-evaluation
+type cancelAfterContext struct {
+	context.Context
+	n int
+}
+
+func (c *cancelAfterContext) Err() error {
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestEvalCancelled(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric1", 0, 1}: {
+			types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32),
+			types.MakeMetricData("metric1", []float64{4, 5, 6}, 1, now32),
+		},
+	}
+
+	target := "metric1"
+	for i := 0; i < 50; i++ {
+		target = "absolute(scale(" + target + ",1))"
+	}
+	exp, _, err := parser.ParseExpr(target)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", target, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = EvalExpr(ctx, exp, 0, 1, m); !merry.Is(err, context.Canceled) {
+		t.Errorf("cancelled context: got error %v, want %v", err, context.Canceled)
+	}
+
+	ctx = &cancelAfterContext{Context: context.Background(), n: 10}
+	if _, err = EvalExpr(ctx, exp, 0, 1, m); !merry.Is(err, context.Canceled) {
+		t.Errorf("context cancelled during evaluation: got error %v, want %v", err, context.Canceled)
+	}
+
+	if _, err = EvalExpr(context.Background(), exp, 0, 1, m); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEvalCancelledInFunctions(t *testing.T) {
+	// many long series, so functions are cancelled while they compute the result, not only between calls
+	from := int64(1388534400)
+	until := from + 2*86400
+
+	tests := []string{
+		"movingAverage(metric.*,10)",
+		"movingMedian(metric.*,'10min')",
+		"summarize(metric.*,'1h')",
+		"smartSummarize(metric.*,'1h','max')",
+		"holtWintersForecast(metric.*)",
+		"holtWintersConfidenceBands(metric.*)",
+		"holtWintersAberration(metric.*)",
+		"sumSeries(metric.*)",
+		"stddevSeries(metric.*)",
+	}
+
+	for _, target := range tests {
+		t.Run(target, func(t *testing.T) {
+			exp, _, err := parser.ParseExpr(target)
+			if err != nil {
+				t.Fatalf("failed to parse %s: %v", target, err)
+			}
+
+			m := make(map[parser.MetricRequest][]*types.MetricData)
+			for _, r := range parser.FetchRequests(exp, from, until) {
+				for i := 0; i < 10; i++ {
+					values := make([]float64, (r.Until-r.From)/60)
+					for j := range values {
+						values[j] = float64(i + j%7)
+					}
+					m[r] = append(m[r], types.MakeMetricData(fmt.Sprintf("metric.%d", i), values, 60, r.From))
+				}
+			}
+
+			ctx := &cancelAfterContext{Context: context.Background(), n: 3}
+			if _, err = EvalExpr(ctx, exp, from, until, m); !merry.Is(err, context.Canceled) {
+				t.Errorf("context cancelled during evaluation: got error %v, want %v", err, context.Canceled)
+			}
+
+			if _, err = EvalExpr(context.Background(), exp, from, until, m); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestEvalFunctionCallsLimit(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+		{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{4, 5, 6}, 1, now32)},
+	}
+
+	// 4 function calls
+	exp, _, err := parser.ParseExpr("sumSeries(absolute(scale(metric1,2)),absolute(metric2))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, limit := range []int{0, 4, 10} {
+		ctx := WithFunctionCallsLimit(context.Background(), limit)
+		if _, err = EvalExpr(ctx, exp, 0, 1, m); err != nil {
+			t.Errorf("limit %d: unexpected error: %v", limit, err)
+		}
+	}
+
+	ctx := WithFunctionCallsLimit(context.Background(), 3)
+	_, err = EvalExpr(ctx, exp, 0, 1, m)
+	if !merry.Is(err, ErrTooManyFunctionCalls) {
+		t.Errorf("limit 3: got error %v, want %v", err, ErrTooManyFunctionCalls)
+	}
+	if code := merry.HTTPCode(err); code != 400 {
+		t.Errorf("limit 3: got http code %d, want 400", code)
+	}
+}
+
+func TestSeriesLimit(t *testing.T) {
+	if err := countSeries(context.Background(), 1000); err != nil {
+		t.Errorf("no limit: unexpected error: %v", err)
+	}
+	if err := countSeries(WithSeriesLimit(context.Background(), 0), 1000); err != nil {
+		t.Errorf("limit 0: unexpected error: %v", err)
+	}
+
+	// the counter is shared by all fetches with the context
+	ctx := WithSeriesLimit(context.Background(), 5)
+	for _, n := range []int{0, 3, 2, 0} {
+		if err := countSeries(ctx, n); err != nil {
+			t.Fatalf("limit 5: unexpected error after %d series: %v", n, err)
+		}
+	}
+	err := countSeries(ctx, 1)
+	if !merry.Is(err, ErrTooManySeries) {
+		t.Errorf("limit 5: got error %v, want %v", err, ErrTooManySeries)
+	}
+	if code := merry.HTTPCode(err); code != 400 {
+		t.Errorf("limit 5: got http code %d, want 400", code)
+	}
+	want := "too many series: 6 series matched, maximum is 5"
+	if err != nil && err.Error() != want {
+		t.Errorf("limit 5: got message %q, want %q", err.Error(), want)
+	}
+	// once exceeded, the limit fails following fetches before they are made
+	if err := countSeries(ctx, 0); !merry.Is(err, ErrTooManySeries) {
+		t.Errorf("limit 5: got error %v for exceeded limit, want %v", err, ErrTooManySeries)
+	}
+}
+
+func TestQueryPlan(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+	requests := []parser.MetricRequest{{"metric.*", 0, 1}, {"other", 0, 1}}
+	metrics := []*types.MetricData{
+		types.MakeMetricData("metric.a", []float64{1, 2, 3}, 1, now32),
+		types.MakeMetricData("metric.b", []float64{1, 2, 3}, 1, now32),
+	}
+	for _, m := range metrics {
+		m.PathExpression = "metric.*"
+	}
+
+	var noPlan *QueryPlan
+	noPlan.addFetch(requests, metrics, time.Second, false)
+	noPlan.AddTarget("metric.*", 2, time.Second, nil)
+	if GetQueryPlan(context.Background()) != nil {
+		t.Error("plan should be nil if it's not set in context")
+	}
+
+	plan := &QueryPlan{}
+	ctx := WithQueryPlan(context.Background(), plan)
+	GetQueryPlan(ctx).addFetch(requests, metrics, time.Second, true)
+	GetQueryPlan(ctx).AddTarget("sumSeries(metric.*)", 1, 2*time.Second, nil)
+	GetQueryPlan(ctx).AddTarget("other", 0, 0, parser.ErrSeriesDoesNotExist)
+
+	wantFetches := []*PlanFetch{
+		{Metric: "metric.*", From: 0, Until: 1, Series: 2, Runtime: 1, Prefetched: true},
+		{Metric: "other", From: 0, Until: 1, Series: 0, Runtime: 1, Prefetched: true},
+	}
+	if !reflect.DeepEqual(plan.Fetches, wantFetches) {
+		t.Errorf("fetches: got %+v, want %+v", plan.Fetches, wantFetches)
+	}
+	wantTargets := []*PlanTarget{
+		{Target: "sumSeries(metric.*)", Series: 1, Runtime: 2},
+		{Target: "other", Error: parser.ErrSeriesDoesNotExist.Error()},
+	}
+	if !reflect.DeepEqual(plan.Targets, wantTargets) {
+		t.Errorf("targets: got %+v, want %+v", plan.Targets, wantTargets)
+	}
+}
+
+func TestSetConsolidateBy(t *testing.T) {
+	metrics := []*types.MetricData{types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, 0)}
+	metrics[0].ConsolidationFunc = "sum"
+
+	setConsolidateBy(context.Background(), metrics)
+	if metrics[0].ConsolidationFunc != "sum" {
+		t.Errorf("consolidation function without default: got %q, want %q", metrics[0].ConsolidationFunc, "sum")
+	}
+
+	setConsolidateBy(utilctx.SetConsolidateBy(context.Background(), "lttb"), metrics)
+	if metrics[0].ConsolidationFunc != "lttb" {
+		t.Errorf("consolidation function with default: got %q, want %q", metrics[0].ConsolidationFunc, "lttb")
+	}
+}
+
+func TestEvalGroupByCallback(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric.*.*", 0, 1}: {
+			types.MakeMetricData("metric.a.x", []float64{1, 5, 3}, 1, now32),
+			types.MakeMetricData("metric.a.y", []float64{4, 2, 1}, 1, now32),
+			types.MakeMetricData("metric.b.x", []float64{7, 8, 9}, 1, now32),
+		},
+		{"seriesByTag('name=cpu')", 0, 1}: {
+			types.MakeMetricData("cpu;dc=dc1;host=a", []float64{1, 5, 3}, 1, now32),
+			types.MakeMetricData("cpu;dc=dc1;host=b", []float64{4, 2, 1}, 1, now32),
+		},
+	}
+
+	tests := []th.EvalTestItem{
+		{
+			`groupByNode(metric.*.*,1,"highestMax")`,
+			m,
+			[]*types.MetricData{
+				types.MakeMetricData("a", []float64{1, 5, 3}, 1, now32),
+				types.MakeMetricData("b", []float64{7, 8, 9}, 1, now32),
+			},
+		},
+		{
+			`groupByNodes(metric.*.*,"sumSeries",1)`,
+			m,
+			[]*types.MetricData{
+				types.MakeMetricData("a", []float64{5, 7, 4}, 1, now32),
+				types.MakeMetricData("b", []float64{7, 8, 9}, 1, now32),
+			},
+		},
+		{
+			`groupByTags(seriesByTag('name=cpu'),"lowestCurrent","dc")`,
+			m,
+			[]*types.MetricData{types.MakeMetricData("cpu;dc=dc1", []float64{4, 2, 1}, 1, now32)},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+
+	errorTests := []th.EvalTestItemWithError{
+		{
+			Target: `groupByNode(metric.*.*,1,"noSuchFunction")`,
+			M:      m,
+			Error:  helper.ErrUnknownFunction("noSuchFunction"),
+		},
+		{
+			Target: `groupByTags(seriesByTag('name=cpu'),"noSuchFunction","dc")`,
+			M:      m,
+			Error:  helper.ErrUnknownFunction("noSuchFunction"),
+		},
+		{
+			Target: `groupByNode(metric.*.*,1,"absolute")`,
+			M:      m,
+			Error:  parser.ErrBadType,
+		},
+	}
+
+	for _, tt := range errorTests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
+
+func TestEvalInvalidRegex(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric.*", 0, 1}: {types.MakeMetricData("metric.a", []float64{1, 2, 3}, 1, now32)},
+	}
+
+	for _, target := range []string{
+		`exclude(metric.*,"a(")`,
+		`grep(metric.*,"*a")`,
+		`aliasSub(metric.*,"[a","b")`,
+	} {
+		exp, _, err := parser.ParseExpr(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = EvalExpr(context.Background(), exp, 0, 1, m)
+		if !merry.Is(err, parser.ErrInvalidRegex) || merry.HTTPCode(err) != 400 {
+			t.Errorf("%s: unexpected error: %v", target, err)
+		}
+	}
+}
+
+func TestEvalStrictStep(t *testing.T) {
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 4}, 10, 0)},
+		{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{2, 4}, 20, 0)},
+	}
+
+	for _, target := range []string{
+		"diffSeries(metric1,metric2)",
+		"sumSeries(metric1,metric2)",
+		"divideSeries(metric1,metric2)",
+	} {
+		exp, _, err := parser.ParseExpr(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// lenient by default, series are resampled to the common step
+		res, err := EvalExpr(context.Background(), exp, 0, 1, m)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", target, err)
+		} else if len(res) != 1 || res[0].StepTime != 20 {
+			t.Errorf("%s: series are not resampled to the common step: %v", target, res)
+		}
+
+		_, err = EvalExpr(utilctx.SetStrictStep(context.Background(), true), exp, 0, 1, m)
+		if !merry.Is(err, types.ErrStepMismatch) || merry.HTTPCode(err) != 400 {
+			t.Errorf("%s: unexpected error with strict step: %v", target, err)
+		}
+	}
+}
+
+func TestEvalAsPercentNodesStrictStep(t *testing.T) {
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"m.*.x", 0, 1}: {
+			types.MakeMetricData("m.a.x", []float64{1, 2, 3, 4}, 10, 0),
+			types.MakeMetricData("m.b.x", []float64{2, 4}, 20, 0),
+		},
+	}
+
+	exp, _, err := parser.ParseExpr("asPercent(m.*.x,None,0)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = EvalExpr(utilctx.SetStrictStep(context.Background(), true), exp, 0, 1, m)
+	if !merry.Is(err, types.ErrStepMismatch) || merry.HTTPCode(err) != 400 {
+		t.Errorf("unexpected error with strict step: %v", err)
+	}
+}
+
+// Absent points are NaN in MetricData, there is no separate mask: serializers mark NaN as absent (null in json,
+// IsAbsent in protobuf v2). Per-point transforms must keep absent points absent and must not turn present ones absent.
+func TestEvalTransformsKeepAbsentPoints(t *testing.T) {
+	isAbsent := []bool{false, true, false, true, false}
+	values := []float64{1, math.NaN(), 2, math.NaN(), 4}
+
+	for _, target := range []string{
+		"scale(metric1,2)",
+		"scale(metric1,0)",
+		"offset(metric1,-1)",
+		"absolute(offset(metric1,-3))",
+		"pow(metric1,0)",
+		"invert(metric1)",
+		"round(scale(metric1,0.3))",
+		"clamp(metric1,0,1)",
+		"delay(metric1,0)",
+		"timeShift(metric1,'0s')",
+	} {
+		t.Run(target, func(t *testing.T) {
+			exp, _, err := parser.ParseExpr(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := map[parser.MetricRequest][]*types.MetricData{
+				{Metric: "metric1", From: 0, Until: 1}: {types.MakeMetricData("metric1", values, 1, 0)},
+			}
+			res, err := EvalExpr(context.Background(), exp, 0, 1, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res) != 1 || len(res[0].Values) != len(isAbsent) {
+				t.Fatalf("unexpected result: %v", res)
+			}
+
+			b, err := types.MarshalProtobufV2(res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got pbv2.MultiFetchResponse
+			if err := got.Unmarshal(b); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Metrics[0].IsAbsent, isAbsent) {
+				t.Errorf("absent points: got %v, want %v (values %v)", got.Metrics[0].IsAbsent, isAbsent, res[0].Values)
+			}
+		})
+	}
+}
+
+func BenchmarkEvalNested(b *testing.B) {
+	const seriesCount, pointsCount = 100, 1000
+
+	series := make([]*types.MetricData, 0, seriesCount)
+	for i := 0; i < seriesCount; i++ {
+		values := make([]float64, pointsCount)
+		for j := range values {
+			values[j] = float64((i * j) % 97)
+		}
+		series = append(series, types.MakeMetricData(fmt.Sprintf("metric.%d", i), values, 1, 1))
+	}
+	values := map[parser.MetricRequest][]*types.MetricData{
+		{Metric: "metric.*", From: 0, Until: 1}: series,
+	}
+
+	exp, _, err := parser.ParseExpr("alias(scale(offset(percentileOfSeries(absolute(metric.*),90),1),2),'p90')")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := EvalExpr(context.Background(), exp, 0, 1, values); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
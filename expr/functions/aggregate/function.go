@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/ansel1/merry"
+
 	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
@@ -39,6 +41,10 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // aggregate(*seriesLists)
 func (f *aggregate) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	if (e.Target() == "diff" || e.Target() == "diffSeries") && len(e.Args()) == 2 {
+		return diffSeries(ctx, e, from, until, values)
+	}
+
 	var args []*types.MetricData
 	isAggregateFunc := true
 
@@ -47,7 +53,7 @@ func (f *aggregate) Do(ctx context.Context, e parser.Expr, from, until int64, va
 		if e.Target() == "aggregate" {
 			return nil, err
 		} else {
-			args, err = helper.GetSeriesArgsAndRemoveNonExisting(e, from, until, values)
+			args, err = helper.GetSeriesArgsAndRemoveNonExisting(ctx, e, from, until, values)
 			if err != nil {
 				return nil, err
 			}
@@ -55,7 +61,7 @@ func (f *aggregate) Do(ctx context.Context, e parser.Expr, from, until int64, va
 			isAggregateFunc = false
 		}
 	} else {
-		args, err = helper.GetSeriesArg(e.Args()[0], from, until, values)
+		args, err = helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 		if err != nil {
 			return nil, err
 		}
@@ -78,7 +84,51 @@ func (f *aggregate) Do(ctx context.Context, e parser.Expr, from, until int64, va
 	if isAggregateFunc {
 		e.SetRawArgs(e.Args()[0].Target())
 	}
-	return helper.AggregateSeries(e, args, aggFunc)
+
+	switch callback {
+	case "sum":
+		return helper.SumSeries(ctx, e, args)
+	case "avg", "average":
+		return helper.AverageSeries(ctx, e, args)
+	}
+	return helper.AggregateSeries(ctx, e, args, aggFunc)
+}
+
+// diffSeries(seriesList, series) subtracts the series from every series of the list and returns as many series as
+// there are in the list. Otherwise series 2 through n are subtracted from series 1, as with more than two arguments.
+func diffSeries(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	minuends, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil && !merry.Is(err, parser.ErrSeriesDoesNotExist) {
+		return nil, err
+	}
+	subtrahends, err := helper.GetSeriesArg(ctx, e.Args()[1], from, until, values)
+	if err != nil && !merry.Is(err, parser.ErrSeriesDoesNotExist) {
+		return nil, err
+	}
+	e.SetTarget("diffSeries")
+
+	if len(minuends) > 1 && len(subtrahends) == 1 {
+		results := make([]*types.MetricData, 0, len(minuends))
+		for _, minuend := range minuends {
+			r, err := helper.AggregateSeries(ctx, e, []*types.MetricData{minuend, subtrahends[0]}, consolidations.AggDiff)
+			if err != nil {
+				return nil, err
+			}
+			r[0].Name = helper.FuncName("diffSeries", minuend.Name, subtrahends[0].Name)
+			results = append(results, r[0])
+		}
+		return results, nil
+	}
+
+	args := make([]*types.MetricData, 0, len(minuends)+len(subtrahends))
+	args = append(append(args, minuends...), subtrahends...)
+	if len(args) == 0 {
+		return nil, parser.ErrSeriesDoesNotExist
+	}
+	if len(args) < len(e.Args()) {
+		e.SetRawArgs(helper.RemoveEmptySeriesFromName(args))
+	}
+	return helper.AggregateSeries(ctx, e, args, consolidations.AggDiff)
 }
 
 // Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
@@ -293,7 +343,7 @@ func (f *aggregate) Description() map[string]types.FunctionDescription {
 			},
 		},
 		"diff": {
-			Description: "Subtracts series 2 through n from series 1.\n\nExample:\n\n.. code-block:: none\n\n  &target=diff(service.connections.total,service.connections.failed)\n\nTo diff a series and a constant, one should use offset instead of (or in\naddition to) diffSeries\n\nExample:\n\n.. code-block:: none\n\n  &target=offset(service.connections.total,-5)\n\n  &target=offset(diffSeries(service.connections.total,service.connections.failed),-4)\n\nThis is an alias for :py:func:`aggregate <aggregate>` with aggregation ``diff``.",
+			Description: "Subtracts series 2 through n from series 1.\n\nExample:\n\n.. code-block:: none\n\n  &target=diff(service.connections.total,service.connections.failed)\n\nTo diff a series and a constant, one should use offset instead of (or in\naddition to) diffSeries\n\nExample:\n\n.. code-block:: none\n\n  &target=offset(service.connections.total,-5)\n\n  &target=offset(diffSeries(service.connections.total,service.connections.failed),-4)\n\nThis is an alias for :py:func:`aggregate <aggregate>` with aggregation ``diff``.\n\nWhen called with a seriesList and a single series, the series is subtracted from every series of the list\nand a result is returned for each of them:\n\n.. code-block:: none\n\n  &target=diffSeries(host.*.requests,baseline.requests)",
 			Function: "diff(*seriesLists)",
 			Group: "Combine",
 			Module: "graphite.render.functions",
@@ -308,7 +358,7 @@ func (f *aggregate) Description() map[string]types.FunctionDescription {
 			},
 		},
 		"diffSeries": {
-			Description: "Subtracts series 2 through n from series 1.\n\nExample:\n\n.. code-block:: none\n\n  &target=diffSeries(service.connections.total,service.connections.failed)\n\nTo diff a series and a constant, one should use offset instead of (or in\naddition to) diffSeries\n\nExample:\n\n.. code-block:: none\n\n  &target=offset(service.connections.total,-5)\n\n  &target=offset(diffSeries(service.connections.total,service.connections.failed),-4)\n\nThis is an alias for :py:func:`aggregate <aggregate>` with aggregation ``diff``.",
+			Description: "Subtracts series 2 through n from series 1.\n\nExample:\n\n.. code-block:: none\n\n  &target=diffSeries(service.connections.total,service.connections.failed)\n\nTo diff a series and a constant, one should use offset instead of (or in\naddition to) diffSeries\n\nExample:\n\n.. code-block:: none\n\n  &target=offset(service.connections.total,-5)\n\n  &target=offset(diffSeries(service.connections.total,service.connections.failed),-4)\n\nThis is an alias for :py:func:`aggregate <aggregate>` with aggregation ``diff``.\n\nWhen called with a seriesList and a single series, the series is subtracted from every series of the list\nand a result is returned for each of them:\n\n.. code-block:: none\n\n  &target=diffSeries(host.*.requests,baseline.requests)",
 			Function: "diffSeries(*seriesLists)",
 			Group: "Combine",
 			Module: "graphite.render.functions",
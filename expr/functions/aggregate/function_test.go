@@ -1,10 +1,13 @@
 package aggregate
 
 import (
+	"context"
+	"fmt"
 	"math"
 	"testing"
 	"time"
 
+	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/metadata"
 	"github.com/go-graphite/carbonapi/expr/types"
@@ -180,6 +183,28 @@ func TestAverageSeries(t *testing.T) {
 			[]*types.MetricData{types.MakeMetricData("stddevSeries(metric1,metric2,metric3)",
 				[]float64{0.4714045207910317, 0.9428090415820634, 1.4142135623730951, 1.8856180831641267, 2.357022603955158}, 1, now32)},
 		},
+		{
+			// single valid value at a timestamp has no deviation
+			`stddevSeries(metric[12])`,
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric[12]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, math.NaN(), 2}, 1, now32),
+					types.MakeMetricData("metric2", []float64{3, math.NaN(), math.NaN()}, 1, now32),
+				},
+			},
+			[]*types.MetricData{types.MakeMetricData("stddevSeries(metric[12])",
+				[]float64{1, math.NaN(), math.NaN()}, 1, now32)},
+		},
+		{
+			// stddev is a short form of stddevSeries, not of the moving stdev
+			`stddev(metric1,metric2)`,
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+				{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{3, 2}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("stddevSeries(metric1,metric2)",
+				[]float64{1, 0}, 1, now32)},
+		},
 		{
 			`aggregate(metric[123], "stddev")`,
 			map[parser.MetricRequest][]*types.MetricData{
@@ -256,6 +281,57 @@ func TestAverageSeries(t *testing.T) {
 				[]float64{1, math.NaN(), 2, 3, 4, 5}, 1, now32)},
 		},
 
+		// diff: 1:1 and lists reduce to one series, a list and a single series give a series per list item
+		{
+			"diffSeries(metric1,metric2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), 2, 3, 4, 5}, 1, now32)},
+				{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{2, math.NaN(), 3, math.NaN(), 5, 6}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("diffSeries(metric1,metric2)",
+				[]float64{-1, math.NaN(), -1, 3, -1, -1}, 1, now32)},
+		},
+		{
+			"diffSeries(metric[12],metric[34])",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric[12]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{10, 20, 30}, 1, now32),
+					types.MakeMetricData("metric2", []float64{1, 2, 3}, 1, now32),
+				},
+				{"metric[34]", 0, 1}: {
+					types.MakeMetricData("metric3", []float64{1, 1, 1}, 1, now32),
+					types.MakeMetricData("metric4", []float64{2, 2, math.NaN()}, 1, now32),
+				},
+			},
+			[]*types.MetricData{types.MakeMetricData("diffSeries(metric[12],metric[34])",
+				[]float64{6, 15, 26}, 1, now32)},
+		},
+		{
+			"diffSeries(metric[12],baseline)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric[12]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{10, 20, 30, math.NaN()}, 1, now32),
+					types.MakeMetricData("metric2", []float64{1, 2, 3, 4}, 1, now32),
+				},
+				{"baseline", 0, 1}: {types.MakeMetricData("baseline", []float64{1, math.NaN(), 3, 1}, 1, now32)},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("diffSeries(metric1,baseline)", []float64{9, 20, 27, math.NaN()}, 1, now32),
+				types.MakeMetricData("diffSeries(metric2,baseline)", []float64{0, 2, 0, 3}, 1, now32),
+			},
+		},
+		{
+			"diffSeries(metric[12],missing)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric[12]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{10, 20, 30}, 1, now32),
+					types.MakeMetricData("metric2", []float64{1, 2, 3}, 1, now32),
+				},
+			},
+			[]*types.MetricData{types.MakeMetricData("diffSeries(metric[12],missing)",
+				[]float64{9, 18, 27}, 1, now32)},
+		},
+
 		// avg
 		{
 			"averageSeries(metric1,metric2,metric3)",
@@ -267,6 +343,17 @@ func TestAverageSeries(t *testing.T) {
 			[]*types.MetricData{types.MakeMetricData("averageSeries(metric1,metric2,metric3)",
 				[]float64{2, math.NaN(), 3, 4, 5, 5.5}, 1, now32)},
 		},
+
+		// different steps, longest series goes first
+		{
+			"sumSeries(metric1,metric2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5, 6}, 3, 3)},
+				{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{1, 2, 3, 4, 5}, 2, 4)},
+			},
+			[]*types.MetricData{types.MakeMetricData("sumSeries(metric1,metric2)",
+				[]float64{2, 5.5, 9.5, 6}, 6, 0)},
+		},
 	}
 
 	for _, tt := range tests {
@@ -277,3 +364,49 @@ func TestAverageSeries(t *testing.T) {
 	}
 
 }
+
+func BenchmarkSumSeries(b *testing.B) {
+	const seriesCount, pointsCount = 5000, 1000
+
+	args := make([]*types.MetricData, 0, seriesCount)
+	for i := 0; i < seriesCount; i++ {
+		values := make([]float64, pointsCount)
+		for j := range values {
+			values[j] = float64(i + j)
+			if (i+j)%10 == 0 {
+				values[j] = math.NaN()
+			}
+		}
+		args = append(args, types.MakeMetricData(fmt.Sprintf("metric.%d", i), values, 1, 1))
+	}
+
+	e := parser.NewExpr("sumSeries", "metric.*")
+	ctx := context.Background()
+
+	b.Run("AggregateSeries", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := helper.AggregateSeries(ctx, e, args, consolidations.AggSum); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("SumSeries", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := helper.SumSeries(ctx, e, args); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("AverageSeries", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := helper.AverageSeries(ctx, e, args); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -2,9 +2,10 @@ package aggregateLine
 
 import (
 	"context"
-	"fmt"
 	"math"
 
+	"github.com/ansel1/merry"
+
 	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
@@ -30,36 +31,26 @@ func New(configFile string) []interfaces.FunctionMetadata {
 	return res
 }
 
-// aggregateLine(*seriesLists)
+// aggregateLine(seriesList, func='average', keepStep=False)
 func (f *aggregateLine) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
 
-	callback := "avg"
-	keepStep := false
-	switch len(e.Args()) {
-	case 2:
-		callback, err = e.GetStringArg(1)
-		if err != nil {
-			return nil, err
-		}
-	case 3:
-		callback, err = e.GetStringArg(1)
-		if err != nil {
-			return nil, err
-		}
+	callback, err := e.GetStringNamedOrPosArgDefault("func", 1, "average")
+	if err != nil {
+		return nil, err
+	}
 
-		keepStep, err = e.GetBoolArgDefault(2, false)
-		if err != nil {
-			return nil, err
-		}
+	keepStep, err := e.GetBoolNamedOrPosArgDefault("keepStep", 2, false)
+	if err != nil {
+		return nil, err
 	}
 
-	aggFunc, ok := consolidations.ConsolidationToFunc[callback]
+	aggFunc, ok := consolidations.GetAggregateFunc(callback)
 	if !ok {
-		return nil, fmt.Errorf("unsupported consolidation function %s", callback)
+		return nil, merry.WithMessagef(parser.ErrBadType, "%s: unsupported consolidation function %q", parser.ErrBadType, callback)
 	}
 
 	var results []*types.MetricData
@@ -67,9 +58,9 @@ func (f *aggregateLine) Do(ctx context.Context, e parser.Expr, from, until int64
 		val := aggFunc(a.Values)
 		var name string
 		if !math.IsNaN(val) {
-			name = fmt.Sprintf("aggregateLine(%s, %g)", a.Name, val)
+			name = helper.FuncName("aggregateLine", a.Name, val)
 		} else {
-			name = fmt.Sprintf("aggregateLine(%s, None)", a.Name)
+			name = helper.FuncName("aggregateLine", a.Name, "None")
 		}
 
 		r := types.MetricData{
@@ -83,7 +74,10 @@ func (f *aggregateLine) Do(ctx context.Context, e parser.Expr, from, until int64
 				RequestStopTime:   a.FetchResponse.RequestStopTime,
 				XFilesFactor:      a.FetchResponse.XFilesFactor,
 			},
-			Tags: a.Tags,
+			Tags: make(map[string]string, len(a.Tags)),
+		}
+		for k, v := range a.Tags {
+			r.Tags[k] = v
 		}
 		if keepStep {
 			r.FetchResponse.Values = make([]float64, len(a.Values))
@@ -36,9 +36,9 @@ func TestConstantLine(t *testing.T) {
 				},
 			},
 			[]*types.MetricData{
-				types.MakeMetricData("aggregateLine(metric1, 3)", []float64{3, 3}, 6, now32),
-				types.MakeMetricData("aggregateLine(metric2, 4)", []float64{4, 4}, 6, now32),
-				types.MakeMetricData("aggregateLine(metric3, 4.5)", []float64{4.5, 4.5}, 6, now32),
+				types.MakeMetricData("aggregateLine(metric1,3)", []float64{3, 3}, 6, now32),
+				types.MakeMetricData("aggregateLine(metric2,4)", []float64{4, 4}, 6, now32),
+				types.MakeMetricData("aggregateLine(metric3,4.5)", []float64{4.5, 4.5}, 6, now32),
 			},
 		},
 		{
@@ -50,10 +50,24 @@ func TestConstantLine(t *testing.T) {
 				},
 			},
 			[]*types.MetricData{
-				types.MakeMetricData("aggregateLine(metric1, None)", []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()}, 1, now32),
-				types.MakeMetricData("aggregateLine(metric2, 4)", []float64{4, 4, 4, 4, 4, 4}, 1, now32),
+				types.MakeMetricData("aggregateLine(metric1,None)", []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()}, 1, now32),
+				types.MakeMetricData("aggregateLine(metric2,4)", []float64{4, 4, 4, 4, 4, 4}, 1, now32),
 			},
 		},
+		{
+			"aggregateLine(metric1,func='max',keepStep=true)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{2.0, math.NaN(), 3.0}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("aggregateLine(metric1,3)", []float64{3, 3, 3}, 1, now32)},
+		},
+		{
+			"aggregateLine(metric1,'p50')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1.0, 7.0, 2.0, math.NaN()}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("aggregateLine(metric1,2)", []float64{2, 2}, 4, now32)},
+		},
 	}
 
 	for _, tt := range tests {
@@ -64,3 +78,16 @@ func TestConstantLine(t *testing.T) {
 	}
 
 }
+
+func TestConstantLineErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tt := th.EvalTestItemWithError{
+		Target: "aggregateLine(metric1,'foo')",
+		M: map[parser.MetricRequest][]*types.MetricData{
+			{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+		},
+		Error: parser.ErrBadType,
+	}
+	th.TestEvalExprWithError(t, &tt)
+}
@@ -0,0 +1,135 @@
+package aggregateWithWildcards
+
+import (
+	"context"
+	"math"
+
+	"github.com/ansel1/merry"
+
+	"github.com/go-graphite/carbonapi/expr/consolidations"
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type aggregateWithWildcards struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &aggregateWithWildcards{}
+	functions := []string{"aggregateWithWildcards"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// aggregateWithWildcards(seriesList, func, *positions)
+func (f *aggregateWithWildcards) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	callback, err := e.GetStringArg(1)
+	if err != nil {
+		return nil, err
+	}
+	aggFunc, ok := consolidations.ConsolidationToFunc[callback]
+	if !ok {
+		return nil, merry.WithMessagef(parser.ErrBadType, "%s: unsupported aggregation function %s", parser.ErrBadType, callback)
+	}
+
+	var fields []int
+	if len(e.Args()) > 2 {
+		fields, err = e.GetIntArgs(2)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// results are named the same way as the ones of sumSeriesWithWildcards, averageSeriesWithWildcards, etc.
+	name := callback
+	if callback == "avg" {
+		name = "average"
+	}
+	name += "SeriesWithWildcards"
+
+	groups, keys := helper.GroupByWildcards(args, fields)
+	results := make([]*types.MetricData, 0, len(keys))
+	for _, key := range keys {
+		var r []*types.MetricData
+		switch callback {
+		case "sum":
+			r, err = helper.SumSeries(ctx, e, groups[key])
+		case "avg", "average":
+			r, err = helper.AverageSeries(ctx, e, groups[key])
+		case "multiply":
+			r, err = helper.AggregateSeries(ctx, e, groups[key], multiplyPresent)
+		default:
+			r, err = helper.AggregateSeries(ctx, e, groups[key], aggFunc)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		r[0].Name = helper.FuncName(name, key)
+		r[0].Tags["name"] = key
+		results = append(results, r[0])
+	}
+	return results, nil
+}
+
+// multiplyPresent multiplies present values, skipping absent ones, the same way as multiplySeriesWithWildcards does.
+// consolidations' multiply (used by multiplySeries and aggregate) returns NaN if the first value is absent, as graphite-web does.
+func multiplyPresent(values []float64) float64 {
+	rv, found := 1.0, false
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			rv *= v
+			found = true
+		}
+	}
+	if !found {
+		return math.NaN()
+	}
+	return rv
+}
+
+// Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
+func (f *aggregateWithWildcards) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"aggregateWithWildcards": {
+			Description: "Call aggregator after inserting wildcards at the given position(s).\n\nExample:\n\n.. code-block:: none\n\n  &target=aggregateWithWildcards(host.cpu-[0-7].cpu-{user,system}.value, \"sum\", 1)\n\nThis would be the equivalent of\n\n.. code-block:: none\n\n  &target=sumSeries(host.cpu-[0-7].cpu-user.value)&target=sumSeries(host.cpu-[0-7].cpu-system.value)\n  # or\n  &target=aggregate(host.cpu-[0-7].cpu-user.value,\"sum\")&target=aggregate(host.cpu-[0-7].cpu-system.value,\"sum\")\n\nThis function can be used with all aggregation functions supported by\n:py:func:`aggregate <aggregate>`: ``average``, ``median``, ``sum``, ``min``, ``max``, ``diff``,\n``stddev``, ``range`` & ``multiply``.\n\nThis complements :py:func:`groupByNodes <groupByNodes>` which takes a list of nodes that must match in each group.",
+			Function:    "aggregateWithWildcards(seriesList, func, *positions)",
+			Group:       "Combine",
+			Module:      "graphite.render.functions",
+			Name:        "aggregateWithWildcards",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+				{
+					Name:     "func",
+					Required: true,
+					Type:     types.AggFunc,
+					Options:  types.StringsToSuggestionList(consolidations.AvailableConsolidationFuncs()),
+				},
+				{
+					Multiple: true,
+					Name:     "positions",
+					Type:     types.Node,
+				},
+			},
+		},
+	}
+}
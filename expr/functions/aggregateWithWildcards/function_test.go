@@ -0,0 +1,132 @@
+package aggregateWithWildcards
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/functions/averageSeriesWithWildcards"
+	"github.com/go-graphite/carbonapi/expr/functions/multiplySeriesWithWildcards"
+	"github.com/go-graphite/carbonapi/expr/functions/sumSeriesWithWildcards"
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	for _, md := range [][]interfaces.FunctionMetadata{
+		New(""),
+		sumSeriesWithWildcards.New(""),
+		averageSeriesWithWildcards.New(""),
+		multiplySeriesWithWildcards.New(""),
+	} {
+		for _, m := range md {
+			metadata.RegisterFunction(m.Name, m.F)
+		}
+	}
+
+	evaluator := th.EvaluatorFromFuncWithMetadata(metadata.FunctionMD.Functions)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+}
+
+func TestAggregateWithWildcards(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.MultiReturnEvalTestItem{
+		{
+			"aggregateWithWildcards(metric1.foo.*.*,\"max\",1,2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1.foo.*.*", 0, 1}: {
+					types.MakeMetricData("metric1.foo.bar1.baz", []float64{1, 2, 3, 4, 5}, 1, now32),
+					types.MakeMetricData("metric1.foo.bar1.qux", []float64{6, 7, 8, 9, 10}, 1, now32),
+					types.MakeMetricData("metric1.foo.bar2.baz", []float64{11, 12, math.NaN(), 14, 15}, 1, now32),
+					types.MakeMetricData("metric1.foo.bar2.qux", []float64{7, 8, 9, 10, 11}, 1, now32),
+				},
+			},
+			"maxSeriesWithWildcards",
+			map[string][]*types.MetricData{
+				"maxSeriesWithWildcards(metric1.baz)": {types.MakeMetricData("maxSeriesWithWildcards(metric1.baz)", []float64{11, 12, 3, 14, 15}, 1, now32)},
+				"maxSeriesWithWildcards(metric1.qux)": {types.MakeMetricData("maxSeriesWithWildcards(metric1.qux)", []float64{7, 8, 9, 10, 11}, 1, now32)},
+			},
+		},
+		{
+			"aggregateWithWildcards(metric1.foo.*.*,\"median\",3)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1.foo.*.*", 0, 1}: {
+					types.MakeMetricData("metric1.foo.bar1.baz", []float64{1, 2, 3}, 1, now32),
+					types.MakeMetricData("metric1.foo.bar1.qux", []float64{3, 8, 5}, 1, now32),
+					types.MakeMetricData("metric1.foo.bar1.quux", []float64{2, 5, math.NaN()}, 1, now32),
+				},
+			},
+			"medianSeriesWithWildcards",
+			map[string][]*types.MetricData{
+				"medianSeriesWithWildcards(metric1.foo.bar1)": {types.MakeMetricData("medianSeriesWithWildcards(metric1.foo.bar1)", []float64{2, 5, 4}, 1, now32)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestMultiReturnEvalExpr(t, &tt)
+		})
+	}
+}
+
+// aggregateWithWildcards should produce the same series as the specific variants
+func TestAggregateWithWildcardsSameAsVariants(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric1.foo.*.*", 0, 1}: {
+			types.MakeMetricData("metric1.foo.bar1.baz", []float64{1, 2, math.NaN(), 4, 5}, 1, now32),
+			types.MakeMetricData("metric1.foo.bar1.qux", []float64{6, 7, 8, 9, math.NaN()}, 1, now32),
+			types.MakeMetricData("metric1.foo.bar2.baz", []float64{11, 12, math.NaN(), 14, 15}, 1, now32),
+			types.MakeMetricData("metric1.foo.bar2.qux", []float64{7, 8, 9, 10, math.NaN()}, 1, now32),
+		},
+	}
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"aggregateWithWildcards(metric1.foo.*.*,'sum',2)", "sumSeriesWithWildcards(metric1.foo.*.*,2)"},
+		{"aggregateWithWildcards(metric1.foo.*.*,'average',1,2)", "averageSeriesWithWildcards(metric1.foo.*.*,1,2)"},
+		{"aggregateWithWildcards(metric1.foo.*.*,'avg',3)", "averageSeriesWithWildcards(metric1.foo.*.*,3)"},
+		{"aggregateWithWildcards(metric1.foo.*.*,'multiply',3)", "multiplySeriesWithWildcards(metric1.foo.*.*,3)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got := eval(t, tt.target, m)
+			want := eval(t, tt.want, m)
+			if len(got) != len(want) {
+				t.Fatalf("got %d series, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i].Name != want[i].Name || got[i].Tags["name"] != want[i].Tags["name"] {
+					t.Errorf("series %d: got name %s (%s), want %s (%s)", i, got[i].Name, got[i].Tags["name"], want[i].Name, want[i].Tags["name"])
+				}
+				if !th.NearlyEqual(got[i].Values, want[i].Values) {
+					t.Errorf("series %s: got values %v, want %v", want[i].Name, got[i].Values, want[i].Values)
+				}
+			}
+		})
+	}
+}
+
+func eval(t *testing.T, target string, m map[parser.MetricRequest][]*types.MetricData) []*types.MetricData {
+	exp, _, err := parser.ParseExpr(target)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", target, err)
+	}
+	r, err := metadata.GetEvaluator().Eval(context.Background(), exp, 0, 1, m)
+	if err != nil {
+		t.Fatalf("failed to eval %s: %v", target, err)
+	}
+	return r
+}
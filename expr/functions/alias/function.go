@@ -28,7 +28,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 }
 
 func (f *alias) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -43,20 +43,12 @@ func (f *alias) Do(ctx context.Context, e parser.Expr, from, until int64, values
 		return nil, err
 	}
 
-	results := make([]*types.MetricData, 0, len(args))
-	for _, arg := range args {
-		r := *arg
-
-		r.Name = alias
+	return helper.RenameSeries(args, func(name string) string {
 		if allowFormatStr {
-			r.Name = strings.ReplaceAll(r.Name, "${expr}", arg.Name)
+			return strings.ReplaceAll(alias, "${expr}", name)
 		}
-		r.Tags["name"] = r.Name
-
-		results = append(results, &r)
-	}
-
-	return results, nil
+		return alias
+	}), nil
 }
 
 func (f *alias) Description() map[string]types.FunctionDescription {
@@ -29,7 +29,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 }
 
 func (f *aliasByBase64) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -38,34 +38,27 @@ func (f *aliasByBase64) Do(ctx context.Context, e parser.Expr, from, until int64
 	field--
 	withoutFieldArg := err != nil
 
-	results := make([]*types.MetricData, 0, len(args))
-
-	for _, a := range args {
-		r := *a
+	return helper.RenameSeries(args, func(name string) string {
 		if withoutFieldArg {
-			decoded, err := base64.StdEncoding.DecodeString(r.Name)
-			if err == nil {
-				r.Name = string(decoded)
+			if decoded, err := base64.StdEncoding.DecodeString(name); err == nil {
+				return string(decoded)
 			}
-		} else {
-			metric := helper.ExtractMetric(r.Name)
-			var name []string
-			for i, n := range strings.Split(metric, ".") {
-				if i == field {
-					decoded, err := base64.StdEncoding.DecodeString(n)
-					if err == nil {
-						n = string(decoded)
-					}
+			return name
+		}
+
+		metric := helper.ExtractMetric(name)
+		var nodes []string
+		for i, n := range strings.Split(metric, ".") {
+			if i == field {
+				decoded, err := base64.StdEncoding.DecodeString(n)
+				if err == nil {
+					n = string(decoded)
 				}
-				name = append(name, n)
 			}
-			r.Name = strings.Join(name, ".")
+			nodes = append(nodes, n)
 		}
-
-		results = append(results, &r)
-	}
-
-	return results, nil
+		return strings.Join(nodes, ".")
+	}), nil
 }
 
 func (f *aliasByBase64) Description() map[string]types.FunctionDescription {
@@ -29,13 +29,13 @@ func New(configFile string) []interfaces.FunctionMetadata {
 }
 
 func (f *aliasByMetric) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	return helper.ForEachSeriesDo(e, from, until, values, func(a *types.MetricData, r *types.MetricData) *types.MetricData {
+	return helper.ForEachSeriesDo(ctx, e, from, until, values, func(a *types.MetricData, r *types.MetricData) *types.MetricData {
 		metric := helper.ExtractMetric(a.Name)
 		part := strings.Split(metric, ".")
 		r.Name = part[len(part)-1]
 		r.Tags["name"] = r.Name
 		r.PathExpression = r.Name
-		r.Values = a.Values
+		copy(r.Values, a.Values)
 		return r
 	})
 }
@@ -27,7 +27,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 }
 
 func (f *aliasByNode) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -41,12 +41,12 @@ func (f *aliasByNode) Do(ctx context.Context, e parser.Expr, from, until int64,
 
 	for _, a := range args {
 		name := helper.AggKey(a, nodesOrTags)
-		r := *a
+		r := a.Copy(true)
 		if len(name) > 0 {
 			r.Name = name
 			r.Tags["name"] = r.Name
 		}
-		results = append(results, &r)
+		results = append(results, r)
 	}
 
 	return results, nil
@@ -56,6 +56,40 @@ func TestAliasByNode(t *testing.T) {
 			[]*types.MetricData{types.MakeMetricData("foo.bar",
 				[]float64{1, 2, 3, 4, 5}, 1, now32)},
 		},
+		{
+			`aliasByNode(seriesByTag('name=cpu.load'), 'host')`,
+			map[parser.MetricRequest][]*types.MetricData{
+				{"seriesByTag('name=cpu.load')", 0, 1}: {
+					types.MakeMetricData("cpu.load;dc=dc1;host=web1", []float64{1, 2, 3}, 1, now32),
+					types.MakeMetricData("cpu.load;dc=dc2;host=web2", []float64{4, 5, 6}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("web1", []float64{1, 2, 3}, 1, now32),
+				types.MakeMetricData("web2", []float64{4, 5, 6}, 1, now32),
+			},
+		},
+		{
+			`aliasByNode(seriesByTag('name=cpu.load'), 'dc', 'host', 1)`,
+			map[parser.MetricRequest][]*types.MetricData{
+				{"seriesByTag('name=cpu.load')", 0, 1}: {
+					types.MakeMetricData("cpu.load;dc=dc1;host=web1", []float64{1, 2, 3}, 1, now32),
+					types.MakeMetricData("cpu.load;dc=dc2;host=web2", []float64{4, 5, 6}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("dc1.web1.load", []float64{1, 2, 3}, 1, now32),
+				types.MakeMetricData("dc2.web2.load", []float64{4, 5, 6}, 1, now32),
+			},
+		},
+		{
+			// missing tag is an empty node, as in graphite-web
+			`seriesByTag('name=cpu.load')|aliasByNode(0, "rack", -1)`,
+			map[parser.MetricRequest][]*types.MetricData{
+				{"seriesByTag('name=cpu.load')", 0, 1}: {types.MakeMetricData("cpu.load;dc=dc1;host=web1", []float64{1, 2, 3}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("cpu..load", []float64{1, 2, 3}, 1, now32)},
+		},
 		{
 			`aliasByTags(*, "foo")`,
 			map[parser.MetricRequest][]*types.MetricData{
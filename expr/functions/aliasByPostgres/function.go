@@ -151,7 +151,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 func (f *aliasByPostgres) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
 	logger := zapwriter.Logger("functionInit").With(zap.String("function", "aliasByPostgres"))
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -213,22 +213,22 @@ func (f *aliasByPostgres) Do(ctx context.Context, e parser.Expr, from, until int
 		}
 		if len(res) > 0 {
 			if matchString.MatchString(res) {
-				r := *a
+				r := a.Copy(true)
 				r.Name = strings.Join(name, ".")
 				if len(name) > 0 {
 					r.Name = res + "." + r.Name
-					results = append(results, &r)
+					results = append(results, r)
 				} else {
 					r.Name = res
-					results = append(results, &r)
+					results = append(results, r)
 				}
 				r.Tags["name"] = r.Name
 			}
 		} else {
-			r := *a
+			r := a.Copy(true)
 			r.Name = tempName
 			r.Tags["name"] = r.Name
-			results = append(results, &r)
+			results = append(results, r)
 		}
 	}
 	return results, nil
@@ -169,7 +169,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 }
 
 func (f *aliasByRedis) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -188,14 +188,14 @@ func (f *aliasByRedis) Do(ctx context.Context, e parser.Expr, from, until int64,
 	results := make([]*types.MetricData, 0, len(args))
 
 	for _, a := range args {
-		r := *a
+		r := a.Copy(true)
 		r.Name = prepareMetric(r.Name)
 		redisName, err := redisGetHash(r.Name, redisHashName, redisConnection, f.queryTimeout)
 		if err == nil {
 			r.Name = redisName
 			r.Tags["name"] = redisName
 		}
-		results = append(results, &r)
+		results = append(results, r)
 	}
 
 	return results, nil
@@ -3,6 +3,8 @@ package aliasSub
 import (
 	"context"
 
+	"github.com/ansel1/merry"
+
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/types"
@@ -29,7 +31,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 }
 
 func (f *aliasSub) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -46,21 +48,14 @@ func (f *aliasSub) Do(ctx context.Context, e parser.Expr, from, until int64, val
 
 	re, err := regexp.Compile(search)
 	if err != nil {
-		return nil, err
+		return nil, merry.WithMessagef(parser.ErrInvalidRegex, "%s: %q: %v", parser.ErrInvalidRegex, search, err)
 	}
 
 	replace = helper.Backref.ReplaceAllString(replace, "$${$1}")
 
-	var results []*types.MetricData
-
-	for _, a := range args {
-		r := *a
-		r.Name = re.ReplaceAllString(r.Name, replace)
-		r.Tags["name"] = r.Name
-		results = append(results, &r)
-	}
-
-	return results, nil
+	return helper.RenameSeries(args, func(name string) string {
+		return re.ReplaceAllString(name, replace)
+	}), nil
 }
 
 // Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
@@ -0,0 +1,82 @@
+package alpha
+
+import (
+	"context"
+
+	"github.com/ansel1/merry"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type alpha struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &alpha{}
+	functions := []string{"alpha"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// alpha(seriesList, alpha)
+func (f *alpha) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	alpha, err := e.GetFloatArg(1)
+	if err != nil {
+		return nil, err
+	}
+	// NaN fails both comparisons
+	if !(alpha >= 0 && alpha <= 1) {
+		return nil, merry.WithMessagef(parser.ErrBadType, "alpha should be between 0 and 1, got %g", alpha)
+	}
+
+	results := make([]*types.MetricData, 0, len(args))
+	for _, a := range args {
+		r := a.Copy(true)
+		r.Alpha = alpha
+		r.HasAlpha = true
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
+func (f *alpha) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"alpha": {
+			Name: "alpha",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+				{
+					Name:     "alpha",
+					Required: true,
+					Type:     types.Float,
+				},
+			},
+			Module:      "graphite.render.functions",
+			Description: "Assigns the given alpha transparency setting to the series. Takes a float value between 0 and 1.",
+			Function:    "alpha(seriesList, alpha)",
+			Group:       "Graph",
+		},
+	}
+}
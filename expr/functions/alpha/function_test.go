@@ -0,0 +1,86 @@
+package alpha
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestFunction(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItem{
+		{
+			"alpha(metric1,0.5)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), 3}, 1, now32)},
+			},
+			[]*types.MetricData{th.WithGraphOptions(
+				types.MakeMetricData("metric1", []float64{1, math.NaN(), 3}, 1, now32),
+				types.GraphOptions{Alpha: 0.5, HasAlpha: true},
+			)},
+		},
+		{
+			"alpha(metric1,0)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+			},
+			[]*types.MetricData{th.WithGraphOptions(
+				types.MakeMetricData("metric1", []float64{1, 2}, 1, now32),
+				types.GraphOptions{Alpha: 0, HasAlpha: true},
+			)},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+
+}
+
+func TestErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "alpha(metric1,-0.1)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+		{
+			Target: "alpha(metric1,1.5)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
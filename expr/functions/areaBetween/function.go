@@ -0,0 +1,87 @@
+package areaBetween
+
+import (
+	"context"
+
+	"github.com/ansel1/merry"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type areaBetween struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &areaBetween{}
+	functions := []string{"areaBetween"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// areaBetween(seriesList)
+func (f *areaBetween) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) != 2 {
+		return nil, merry.WithMessagef(parser.ErrBadType, "areaBetween needs exactly two series (%d given)", len(args))
+	}
+
+	name := helper.FuncName(e.Target(), e.RawArgs())
+
+	// Normalize returns copies, so they can be modified
+	args, _, _, err = helper.Normalize(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	lower, upper := args[0], args[1]
+
+	// lower series is invisible and upper is stacked on top of it, so only the area between them is drawn
+	lower.Stacked = true
+	lower.StackName = types.DefaultStackName
+	lower.Invisible = true
+	lower.Name = name
+
+	upper.Stacked = true
+	upper.StackName = types.DefaultStackName
+	upper.Name = name
+
+	for i, v := range upper.Values {
+		upper.Values[i] = v - lower.Values[i]
+	}
+
+	return []*types.MetricData{lower, upper}, nil
+}
+
+// Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
+func (f *areaBetween) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"areaBetween": {
+			Name: "areaBetween",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+			},
+			Module:      "graphite.render.functions",
+			Description: "Draws the vertical area in between the two series in seriesList. Useful for\nvisualizing a range such as the minimum and maximum latency for a service.\n\nareaBetween expects **exactly one argument** that results in exactly two series\n(see example below). The order of the lower and higher values series does not\nmatter. The visualization only works when used in conjunction with\n``areaMode=stacked``.\n\nMost likely use case is to provide a band within which another metric should\nmove. In such case applying an ``alpha()``, as in the second example, gives\nbest visual results.\n\nExample:\n\n.. code-block:: none\n\n  &target=areaBetween(service.latency.{min,max})&areaMode=stacked\n\n  &target=alpha(areaBetween(service.latency.{min,max}),0.3)&areaMode=stacked\n\nIf for instance, you need to build a seriesList, you should use the ``group``\nfunction, like so:\n\n.. code-block:: none\n\n  &target=areaBetween(group(minSeries(a.*.min),maxSeries(a.*.max)))",
+			Function:    "areaBetween(seriesList)",
+			Group:       "Graph",
+		},
+	}
+}
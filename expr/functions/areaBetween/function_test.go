@@ -0,0 +1,89 @@
+package areaBetween
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestFunction(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItem{
+		{
+			"areaBetween(metric.{min,max})",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric.{min,max}", 0, 1}: {
+					types.MakeMetricData("metric.min", []float64{1, 2, math.NaN(), 1}, 1, now32),
+					types.MakeMetricData("metric.max", []float64{3, 5, 4, math.NaN()}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				th.WithGraphOptions(
+					types.MakeMetricData("areaBetween(metric.{min,max})", []float64{1, 2, math.NaN(), 1}, 1, now32),
+					types.GraphOptions{Stacked: true, StackName: types.DefaultStackName, Invisible: true},
+				),
+				th.WithGraphOptions(
+					types.MakeMetricData("areaBetween(metric.{min,max})", []float64{2, 3, math.NaN(), math.NaN()}, 1, now32),
+					types.GraphOptions{Stacked: true, StackName: types.DefaultStackName},
+				),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprOrdered(t, &tt)
+		})
+	}
+
+}
+
+func TestErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "areaBetween(metric1)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+		{
+			Target: "areaBetween(metric*)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2}, 1, now32),
+					types.MakeMetricData("metric2", []float64{1, 2}, 1, now32),
+					types.MakeMetricData("metric3", []float64{1, 2}, 1, now32),
+				},
+			},
+			Error: parser.ErrBadType,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"math"
 	"sort"
-	"strings"
 
+	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/types"
@@ -25,15 +25,22 @@ func GetOrder() interfaces.Order {
 func New(configFile string) []interfaces.FunctionMetadata {
 	res := make([]interfaces.FunctionMetadata, 0)
 	f := &asPercent{}
-	for _, n := range []string{"asPercent"} {
+	for _, n := range []string{"asPercent", "pct"} {
 		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
 	}
 	return res
 }
 
-// asPercent(seriesList, total=None, *nodes)
+// asPercent(seriesList, total=None, *nodes, strictTotal=False)
+// Alias: pct
 func (f *asPercent) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	// strictTotal can only be passed by name, nodes take all positional arguments after total
+	strictTotal, err := e.GetBoolNamedOrPosArgDefault("strictTotal", len(e.Args()), false)
 	if err != nil {
 		return nil, err
 	}
@@ -54,6 +61,9 @@ func (f *asPercent) Do(ctx context.Context, e parser.Expr, from, until int64, va
 			var atLeastOne bool
 			for _, a := range arg {
 				if math.IsNaN(a.Values[i]) {
+					if strictTotal {
+						return math.NaN()
+					}
 					continue
 				}
 				atLeastOne = true
@@ -66,7 +76,7 @@ func (f *asPercent) Do(ctx context.Context, e parser.Expr, from, until int64, va
 			return t
 		}
 		formatName = func(a, b string) string {
-			return fmt.Sprintf("asPercent(%s)", a)
+			return helper.FuncName(e.Target(), a)
 		}
 	} else if len(e.Args()) == 2 && e.Args()[1].IsConst() {
 		total, err := e.GetFloatArg(1)
@@ -77,10 +87,10 @@ func (f *asPercent) Do(ctx context.Context, e parser.Expr, from, until int64, va
 		getTotal = func(i int) float64 { return total }
 		totalString = fmt.Sprintf("%g", total)
 		formatName = func(a, b string) string {
-			return fmt.Sprintf("asPercent(%s,%s)", a, b)
+			return helper.FuncName(e.Target(), a, b)
 		}
 	} else if len(e.Args()) == 2 && (e.Args()[1].IsName() || e.Args()[1].IsFunc()) {
-		total, err := helper.GetSeriesArg(e.Args()[1], from, until, values)
+		total, err := helper.GetSeriesArg(ctx, e.Args()[1], from, until, values)
 		if err != nil {
 			return nil, err
 		}
@@ -111,72 +121,41 @@ func (f *asPercent) Do(ctx context.Context, e parser.Expr, from, until int64, va
 			sort.Sort(helper.ByName(denominators))
 		}
 		formatName = func(a, b string) string {
-			return fmt.Sprintf("asPercent(%s,%s)", a, b)
+			return helper.FuncName(e.Target(), a, b)
 		}
 	} else if len(e.Args()) >= 3 {
-		total, err := helper.GetSeriesArg(e.Args()[1], from, until, values)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(total) != 1 && len(total) != len(arg) {
-			return nil, types.ErrWildcardNotAllowed
+		// total may be None, then every series is a percentage of the sum of its own group
+		var total []*types.MetricData
+		if !e.Args()[1].IsName() || e.Args()[1].Target() != "None" {
+			total, err = helper.GetSeriesArg(ctx, e.Args()[1], from, until, values)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		alignedSeries := helper.AlignSeries(types.CopyMetricDataSlice(append(arg, total...)))
 		arg = alignedSeries[0:len(arg)]
 		total = alignedSeries[len(arg):]
 
-		nodeIndexes, err := e.GetIntArgs(2)
+		nodesOrTags, err := e.GetNodeOrTagArgs(2)
 		if err != nil {
 			return nil, err
 		}
 
 		sumSeries := func(seriesList []*types.MetricData) (*types.MetricData, error) {
-			seriesNames := make([]string, len(seriesList))
-			for i, series := range seriesList {
-				seriesNames[i] = series.Name
-			}
-
 			seriesNameExprs := make([]parser.Expr, len(seriesList))
-			for i, seriesName := range seriesNames {
-				seriesNameExprs[i] = parser.NewTargetExpr(seriesName)
+			for i, series := range seriesList {
+				seriesNameExprs[i] = parser.NewTargetExpr(series.Name)
 			}
 
-			result, err := f.Evaluator.Eval(ctx, parser.NewExprTyped("sumSeries", seriesNameExprs), from, until, values)
-
+			// aggregateSeries returns only one series
+			result, err := helper.AggregateSeries(ctx, parser.NewExprTyped("sumSeries", seriesNameExprs), seriesList, consolidations.AggSum)
 			if err != nil {
 				return nil, err
 			}
-
-			// sumSeries uses aggregateSeries function which returns only one series
 			return result[0], nil
 		}
 
-		groupByNodes := func(seriesList []*types.MetricData, nodeIndexes []int) (map[string][]*types.MetricData, []string) {
-			var nodeKeys []string
-			groups := make(map[string][]*types.MetricData)
-
-			for _, series := range seriesList {
-				metric := helper.ExtractMetric(series.Name)
-				nodes := strings.Split(metric, ".")
-				nodeKey := make([]string, 0, len(nodeIndexes))
-				for _, index := range nodeIndexes {
-					nodeKey = append(nodeKey, nodes[index])
-				}
-				node := strings.Join(nodeKey, ".")
-				_, exist := groups[node]
-
-				if !exist {
-					nodeKeys = append(nodeKeys, node)
-				}
-
-				groups[node] = append(groups[node], series)
-			}
-
-			return groups, nodeKeys
-		}
-
 		distinct := func(slice []string) []string {
 			keys := make(map[string]bool)
 			var list []string
@@ -189,7 +168,7 @@ func (f *asPercent) Do(ctx context.Context, e parser.Expr, from, until int64, va
 			return list
 		}
 
-		metaSeriesGroup, metaKeys := groupByNodes(arg, nodeIndexes)
+		metaSeriesGroup, metaKeys := helper.GroupByNodes(arg, nodesOrTags)
 
 		totalSeriesGroup := make(map[string]*types.MetricData)
 		var groups map[string][]*types.MetricData
@@ -198,17 +177,35 @@ func (f *asPercent) Do(ctx context.Context, e parser.Expr, from, until int64, va
 		if len(total) == 0 {
 			groups, groupKeys = metaSeriesGroup, metaKeys
 		} else {
-			groups, groupKeys = groupByNodes(total, nodeIndexes)
+			groups, groupKeys = helper.GroupByNodes(total, nodesOrTags)
 		}
 
 		for _, nodeKey := range groupKeys {
 			if len(groups[nodeKey]) == 1 {
 				totalSeriesGroup[nodeKey] = groups[nodeKey][0]
 			} else {
-				totalSeriesGroup[nodeKey], err = sumSeries(groups[nodeKey])
+				group := groups[nodeKey]
+				if strictTotal && len(total) == 0 {
+					// series of the group may have different steps, mask the sum by the resampled ones
+					group, _, _, err = helper.Normalize(ctx, group)
+					if err != nil {
+						return nil, err
+					}
+				}
+				totalSeriesGroup[nodeKey], err = sumSeries(group)
 				if err != nil {
 					return nil, err
 				}
+				if strictTotal && len(total) == 0 {
+					// sum of the group is absent where any of its series is
+					for _, series := range group {
+						for i, v := range series.Values {
+							if math.IsNaN(v) {
+								totalSeriesGroup[nodeKey].Values[i] = math.NaN()
+							}
+						}
+					}
+				}
 			}
 		}
 
@@ -219,7 +216,7 @@ func (f *asPercent) Do(ctx context.Context, e parser.Expr, from, until int64, va
 			if !existInMeta {
 				totalSeries := totalSeriesGroup[nodeKey]
 				result := *totalSeries
-				result.Name = fmt.Sprintf("asPercent(MISSING,%s)", totalSeries.Name)
+				result.Name = helper.FuncName(e.Target(), "MISSING", totalSeries.Name)
 				result.Values = make([]float64, len(totalSeries.Values))
 				for i := range result.Values {
 					result.Values[i] = math.NaN()
@@ -233,20 +230,28 @@ func (f *asPercent) Do(ctx context.Context, e parser.Expr, from, until int64, va
 				result := *metaSeries
 				totalSeries, existInTotal := totalSeriesGroup[nodeKey]
 				if !existInTotal {
-					result.Name = fmt.Sprintf("asPercent(%s,MISSING)", metaSeries.Name)
+					result.Name = helper.FuncName(e.Target(), metaSeries.Name, "MISSING")
 					result.Values = make([]float64, len(metaSeries.Values))
 					for i := range result.Values {
 						result.Values[i] = math.NaN()
 					}
 				} else {
-					result.Name = fmt.Sprintf("asPercent(%s,%s)", metaSeries.Name, totalSeries.Name)
-					result.Values = make([]float64, len(metaSeries.Values))
-					for i := range metaSeries.Values {
-						if math.IsNaN(metaSeries.Values[i]) || math.IsNaN(totalSeries.Values[i]) {
+					// series and its total may have different steps
+					normalized, _, _, err := helper.Normalize(ctx, []*types.MetricData{metaSeries, totalSeries})
+					if err != nil {
+						return nil, err
+					}
+					numerator, denominator := normalized[0], normalized[1]
+
+					result = *numerator
+					result.Name = helper.FuncName(e.Target(), metaSeries.Name, totalSeries.Name)
+					result.Values = make([]float64, len(numerator.Values))
+					for i := range numerator.Values {
+						if math.IsNaN(numerator.Values[i]) || math.IsNaN(denominator.Values[i]) {
 							result.Values[i] = math.NaN()
 							continue
 						}
-						result.Values[i] = (metaSeries.Values[i] / totalSeries.Values[i]) * 100
+						result.Values[i] = (numerator.Values[i] / denominator.Values[i]) * 100
 					}
 				}
 
@@ -315,11 +320,44 @@ func (f *asPercent) Do(ctx context.Context, e parser.Expr, from, until int64, va
 func (f *asPercent) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"asPercent": {
-			Description: "Calculates a percentage of the total of a wildcard series. If `total` is specified,\neach series will be calculated as a percentage of that total. If `total` is not specified,\nthe sum of all points in the wildcard series will be used instead.\n\nA list of nodes can optionally be provided, if so they will be used to match series with their\ncorresponding totals following the same logic as :py:func:`groupByNodes <groupByNodes>`.\n\nWhen passing `nodes` the `total` parameter may be a series list or `None`.  If it is `None` then\nfor each series in `seriesList` the percentage of the sum of series in that group will be returned.\n\nWhen not passing `nodes`, the `total` parameter may be a single series, reference the same number\nof series as `seriesList` or be a numeric value.\n\nExample:\n\n.. code-block:: none\n\n  # Server01 connections failed and succeeded as a percentage of Server01 connections attempted\n  &target=asPercent(Server01.connections.{failed,succeeded}, Server01.connections.attempted)\n\n  # For each server, its connections failed as a percentage of its connections attempted\n  &target=asPercent(Server*.connections.failed, Server*.connections.attempted)\n\n  # For each server, its connections failed and succeeded as a percentage of its connections attemped\n  &target=asPercent(Server*.connections.{failed,succeeded}, Server*.connections.attempted, 0)\n\n  # apache01.threads.busy as a percentage of 1500\n  &target=asPercent(apache01.threads.busy,1500)\n\n  # Server01 cpu stats as a percentage of its total\n  &target=asPercent(Server01.cpu.*.jiffies)\n\n  # cpu stats for each server as a percentage of its total\n  &target=asPercent(Server*.cpu.*.jiffies, None, 0)\n\nWhen using `nodes`, any series or totals that can't be matched will create output series with\nnames like ``asPercent(someSeries,MISSING)`` or ``asPercent(MISSING,someTotalSeries)`` and all\nvalues set to None. If desired these series can be filtered out by piping the result through\n``|exclude(\"MISSING\")`` as shown below:\n\n.. code-block:: none\n\n  &target=asPercent(Server{1,2}.memory.used,Server{1,3}.memory.total,0)\n\n  # will produce 3 output series:\n  # asPercent(Server1.memory.used,Server1.memory.total) [values will be as expected}\n  # asPercent(Server2.memory.used,MISSING) [all values will be None}\n  # asPercent(MISSING,Server3.memory.total) [all values will be None}\n\n  &target=asPercent(Server{1,2}.memory.used,Server{1,3}.memory.total,0)|exclude(\"MISSING\")\n\n  # will produce 1 output series:\n  # asPercent(Server1.memory.used,Server1.memory.total) [values will be as expected}\n\nEach node may be an integer referencing a node in the series name or a string identifying a tag.\n\n.. note::\n\n  When `total` is a seriesList, specifying `nodes` to match series with the corresponding total\n  series will increase reliability.",
-			Function:    "asPercent(seriesList, total=None, *nodes)",
-			Group:       "Combine",
-			Module:      "graphite.render.functions",
-			Name:        "asPercent",
+			Description: "Calculates a percentage of the total of a wildcard series. If `total` is specified,\neach series will be calculated as a percentage of that total. If `total` is not specified,\nthe sum of all points in the wildcard series will be used instead.\n\nA list of nodes can optionally be provided, if so they will be used to match series with their\ncorresponding totals following the same logic as :py:func:`groupByNodes <groupByNodes>`.\n\nWhen passing `nodes` the `total` parameter may be a series list or `None`.  If it is `None` then\nfor each series in `seriesList` the percentage of the sum of series in that group will be returned.\n\nWhen not passing `nodes`, the `total` parameter may be a single series, reference the same number\nof series as `seriesList` or be a numeric value.\n\nExample:\n\n.. code-block:: none\n\n  # Server01 connections failed and succeeded as a percentage of Server01 connections attempted\n  &target=asPercent(Server01.connections.{failed,succeeded}, Server01.connections.attempted)\n\n  # For each server, its connections failed as a percentage of its connections attempted\n  &target=asPercent(Server*.connections.failed, Server*.connections.attempted)\n\n  # For each server, its connections failed and succeeded as a percentage of its connections attemped\n  &target=asPercent(Server*.connections.{failed,succeeded}, Server*.connections.attempted, 0)\n\n  # apache01.threads.busy as a percentage of 1500\n  &target=asPercent(apache01.threads.busy,1500)\n\n  # Server01 cpu stats as a percentage of its total\n  &target=asPercent(Server01.cpu.*.jiffies)\n\n  # cpu stats for each server as a percentage of its total\n  &target=asPercent(Server*.cpu.*.jiffies, None, 0)\n\nWhen using `nodes`, any series or totals that can't be matched will create output series with\nnames like ``asPercent(someSeries,MISSING)`` or ``asPercent(MISSING,someTotalSeries)`` and all\nvalues set to None. If desired these series can be filtered out by piping the result through\n``|exclude(\"MISSING\")`` as shown below:\n\n.. code-block:: none\n\n  &target=asPercent(Server{1,2}.memory.used,Server{1,3}.memory.total,0)\n\n  # will produce 3 output series:\n  # asPercent(Server1.memory.used,Server1.memory.total) [values will be as expected}\n  # asPercent(Server2.memory.used,MISSING) [all values will be None}\n  # asPercent(MISSING,Server3.memory.total) [all values will be None}\n\n  &target=asPercent(Server{1,2}.memory.used,Server{1,3}.memory.total,0)|exclude(\"MISSING\")\n\n  # will produce 1 output series:\n  # asPercent(Server1.memory.used,Server1.memory.total) [values will be as expected}\n\nEach node may be an integer referencing a node in the series name or a string identifying a tag.\n\n.. note::\n\n  When `total` is a seriesList, specifying `nodes` to match series with the corresponding total\n  series will increase reliability.\n\n" +
+				"carbonapi extends this function by optional strictTotal parameter, that can only be passed by name. When total is\n" +
+				"the sum of the series (total is not specified or is `None`), some of the series being absent makes the sum smaller\n" +
+				"and percentages of the rest can add up to more than 100. With strictTotal=true the total is absent at such points,\n" +
+				"so all percentages there are absent too:\n\n.. code-block:: none\n\n  &target=asPercent(Server01.cpu.*.jiffies,strictTotal=true)",
+			Function: "asPercent(seriesList, total=None, *nodes, strictTotal=False)",
+			Group:    "Combine",
+			Module:   "graphite.render.functions",
+			Name:     "asPercent",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+				{
+					Name: "total",
+					Type: types.Any,
+				},
+				{
+					Multiple: true,
+					Name:     "nodes",
+					Type:     types.NodeOrTag,
+				},
+				{
+					Default: types.NewSuggestion(false),
+					Name:    "strictTotal",
+					Type:    types.Boolean,
+				},
+			},
+		},
+		"pct": {
+			Description: "pct is an alias of :py:func:`asPercent <asPercent>`, see its description for details.\n\nExample:\n\n.. code-block:: none\n\n  # Server01 connections failed and succeeded as a percentage of Server01 connections attempted\n  &target=pct(Server01.connections.{failed,succeeded}, Server01.connections.attempted)\n\n  # apache01.threads.busy as a percentage of 1500\n  &target=pct(apache01.threads.busy,1500)\n\n  # cpu stats for each server as a percentage of its total\n  &target=pct(Server*.cpu.*.jiffies, None, 0)\n\n" +
+				"carbonapi extends this function by optional strictTotal parameter, that can only be passed by name:\n\n.. code-block:: none\n\n  &target=pct(Server01.cpu.*.jiffies,strictTotal=true)",
+			Function: "pct(seriesList, total=None, *nodes, strictTotal=False)",
+			Group:    "Combine",
+			Module:   "graphite.render.functions",
+			Name:     "pct",
 			Params: []types.FunctionParam{
 				{
 					Name:     "seriesList",
@@ -328,13 +366,18 @@ func (f *asPercent) Description() map[string]types.FunctionDescription {
 				},
 				{
 					Name: "total",
-					Type: types.SeriesList,
+					Type: types.Any,
 				},
 				{
 					Multiple: true,
 					Name:     "nodes",
 					Type:     types.NodeOrTag,
 				},
+				{
+					Default: types.NewSuggestion(false),
+					Name:    "strictTotal",
+					Type:    types.Boolean,
+				},
 			},
 		},
 	}
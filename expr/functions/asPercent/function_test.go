@@ -88,6 +88,125 @@ func TestAliasByNode(t *testing.T) {
 				types.MakeMetricData("asPercent(MISSING,Server3.memory.total)", []float64{NaN, NaN, NaN}, 1, now32),
 			},
 		},
+		{
+			"asPercent(Server{1,2}.cpu.*,Server*.cpu.limit.*,0)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"Server{1,2}.cpu.*", 0, 1}: {
+					types.MakeMetricData("Server1.cpu.user", []float64{1, 2, NaN}, 1, now32),
+					types.MakeMetricData("Server1.cpu.system", []float64{2, 4, 6}, 1, now32),
+					types.MakeMetricData("Server2.cpu.user", []float64{5, 10, 20}, 1, now32),
+				},
+				{"Server*.cpu.limit.*", 0, 1}: {
+					types.MakeMetricData("Server1.cpu.limit.soft", []float64{2, 4, 6}, 1, now32),
+					types.MakeMetricData("Server1.cpu.limit.hard", []float64{8, NaN, 6}, 1, now32),
+					types.MakeMetricData("Server2.cpu.limit.soft", []float64{10, 20, 40}, 1, now32),
+					types.MakeMetricData("Server3.cpu.limit.soft", []float64{1, 1, 1}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("asPercent(Server1.cpu.user,sumSeries(Server1.cpu.limit.soft,Server1.cpu.limit.hard))", []float64{10, 50, NaN}, 1, now32),
+				types.MakeMetricData("asPercent(Server1.cpu.system,sumSeries(Server1.cpu.limit.soft,Server1.cpu.limit.hard))", []float64{20, 100, 50}, 1, now32),
+				types.MakeMetricData("asPercent(Server2.cpu.user,Server2.cpu.limit.soft)", []float64{50, 50, 50}, 1, now32),
+				types.MakeMetricData("asPercent(MISSING,Server3.cpu.limit.soft)", []float64{NaN, NaN, NaN}, 1, now32),
+			},
+		},
+		{
+			"asPercent(Server*.cpu.*,None,0)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"Server*.cpu.*", 0, 1}: {
+					types.MakeMetricData("Server1.cpu.user", []float64{1, 3, NaN}, 1, now32),
+					types.MakeMetricData("Server1.cpu.system", []float64{3, 1, 2}, 1, now32),
+					types.MakeMetricData("Server2.cpu.user", []float64{5, 10, 20}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("asPercent(Server1.cpu.user,sumSeries(Server1.cpu.user,Server1.cpu.system))", []float64{25, 75, NaN}, 1, now32),
+				types.MakeMetricData("asPercent(Server1.cpu.system,sumSeries(Server1.cpu.user,Server1.cpu.system))", []float64{75, 25, 100}, 1, now32),
+				types.MakeMetricData("asPercent(Server2.cpu.user,Server2.cpu.user)", []float64{100, 100, 100}, 1, now32),
+			},
+		},
+		{
+			"pct(metric*)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 3, 0, NaN}, 1, now32),
+					types.MakeMetricData("metric2", []float64{3, 1, 0, 2}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("pct(metric1)", []float64{25, 75, NaN, NaN}, 1, now32),
+				types.MakeMetricData("pct(metric2)", []float64{75, 25, NaN, 100}, 1, now32),
+			},
+		},
+		{
+			// with strictTotal a point where any of the series is absent has no total
+			"asPercent(metric*,strictTotal=true)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 3, 0, NaN}, 1, now32),
+					types.MakeMetricData("metric2", []float64{3, 1, 0, 2}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("asPercent(metric1)", []float64{25, 75, NaN, NaN}, 1, now32),
+				types.MakeMetricData("asPercent(metric2)", []float64{75, 25, NaN, NaN}, 1, now32),
+			},
+		},
+		{
+			"asPercent(metric*,strictTotal=false)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, NaN}, 1, now32),
+					types.MakeMetricData("metric2", []float64{3, 2}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("asPercent(metric1)", []float64{25, NaN}, 1, now32),
+				types.MakeMetricData("asPercent(metric2)", []float64{75, 100}, 1, now32),
+			},
+		},
+		{
+			"asPercent(Server*.cpu.*,None,0,strictTotal=true)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"Server*.cpu.*", 0, 1}: {
+					types.MakeMetricData("Server1.cpu.user", []float64{1, 3, NaN}, 1, now32),
+					types.MakeMetricData("Server1.cpu.system", []float64{3, 1, 2}, 1, now32),
+					types.MakeMetricData("Server2.cpu.user", []float64{5, 10, 20}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("asPercent(Server1.cpu.user,sumSeries(Server1.cpu.user,Server1.cpu.system))", []float64{25, 75, NaN}, 1, now32),
+				types.MakeMetricData("asPercent(Server1.cpu.system,sumSeries(Server1.cpu.user,Server1.cpu.system))", []float64{75, 25, NaN}, 1, now32),
+				types.MakeMetricData("asPercent(Server2.cpu.user,Server2.cpu.user)", []float64{100, 100, 100}, 1, now32),
+			},
+		},
+		{
+			// series with different steps are resampled to the step of their total
+			"asPercent(m.*.x,None,0)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"m.*.x", 0, 1}: {
+					types.MakeMetricData("m.a.x", []float64{1, 3, 5, 7}, 10, 0),
+					types.MakeMetricData("m.b.x", []float64{6, 4}, 20, 0),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("asPercent(m.a.x,sumSeries(m.a.x,m.b.x))", []float64{25, 60}, 20, 0),
+				types.MakeMetricData("asPercent(m.b.x,sumSeries(m.a.x,m.b.x))", []float64{75, 40}, 20, 0),
+			},
+		},
+		{
+			"asPercent(m.*.x,None,0,strictTotal=true)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"m.*.x", 0, 1}: {
+					types.MakeMetricData("m.a.x", []float64{1, 3, NaN, NaN}, 10, 0),
+					types.MakeMetricData("m.b.x", []float64{6, 4}, 20, 0),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("asPercent(m.a.x,sumSeries(m.a.x,m.b.x))", []float64{25, NaN}, 20, 0),
+				types.MakeMetricData("asPercent(m.b.x,sumSeries(m.a.x,m.b.x))", []float64{75, NaN}, 20, 0),
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -2,9 +2,7 @@ package averageSeriesWithWildcards
 
 import (
 	"context"
-	"fmt"
 	"math"
-	"strings"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
@@ -33,7 +31,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 func (f *averageSeriesWithWildcards) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
 	/* TODO(dgryski): make sure the arrays are all the same 'size'
 	   (duplicated from sumSeriesWithWildcards because of similar logic but aggregation) */
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -45,35 +43,12 @@ func (f *averageSeriesWithWildcards) Do(ctx context.Context, e parser.Expr, from
 
 	var results []*types.MetricData
 
-	nodeList := []string{}
-	groups := make(map[string][]*types.MetricData)
-
-	for _, a := range args {
-		metric := helper.ExtractMetric(a.Name)
-		nodes := strings.Split(metric, ".")
-		var s []string
-		// Yes, this is O(n^2), but len(nodes) < 10 and len(fields) < 3
-		// Iterating an int slice is faster than a map for n ~ 30
-		// http://www.antoine.im/posts/someone_is_wrong_on_the_internet
-		for i, n := range nodes {
-			if !helper.Contains(fields, i) {
-				s = append(s, n)
-			}
-		}
-
-		node := strings.Join(s, ".")
-
-		if len(groups[node]) == 0 {
-			nodeList = append(nodeList, node)
-		}
-
-		groups[node] = append(groups[node], a)
-	}
+	groups, nodeList := helper.GroupByWildcards(args, fields)
 
 	for _, series := range nodeList {
 		args := groups[series]
 		r := *args[0]
-		r.Name = fmt.Sprintf("averageSeriesWithWildcards(%s)", series)
+		r.Name = helper.FuncName("averageSeriesWithWildcards", series)
 		r.Tags = make(map[string]string)
 		for k, v := range args[0].Tags {
 			r.Tags[k] = v
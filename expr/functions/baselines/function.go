@@ -2,7 +2,6 @@ package baselines
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/go-graphite/carbonapi/expr/consolidations"
@@ -58,7 +57,7 @@ func (f *baselines) Do(ctx context.Context, e parser.Expr, from, until int64, va
 	}
 
 	current := make(map[string]*types.MetricData)
-	arg, _ := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, _ := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	for _, a := range arg {
 		current[a.Name] = a
 	}
@@ -69,7 +68,7 @@ func (f *baselines) Do(ctx context.Context, e parser.Expr, from, until int64, va
 			continue
 		}
 		offs := int64(i * unit)
-		arg, _ := helper.GetSeriesArg(e.Args()[0], from+offs, until+offs, values)
+		arg, _ := helper.GetSeriesArg(ctx, e.Args()[0], from+offs, until+offs, values)
 		for _, a := range arg {
 			r := *a
 			if _, ok := current[r.Name]; ok || !isAberration {
@@ -84,9 +83,9 @@ func (f *baselines) Do(ctx context.Context, e parser.Expr, from, until int64, va
 	for name, args := range groups {
 		r := *args[0]
 		if isAberration {
-			r.Name = fmt.Sprintf("baselineAberration(%s)", name)
+			r.Name = helper.FuncName("baselineAberration", name)
 		} else {
-			r.Name = fmt.Sprintf("baseline(%s)", name)
+			r.Name = helper.FuncName("baseline", name)
 		}
 		r.Values = make([]float64, len(args[0].Values))
 
@@ -6,7 +6,6 @@ import (
 	"math"
 	"strings"
 
-	"github.com/dustin/go-humanize"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/types"
@@ -34,7 +33,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 // cactiStyle(seriesList, system=None, units=None)
 func (f *cactiStyle) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
 	// Get the series data
-	original, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	original, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -65,26 +64,27 @@ func (f *cactiStyle) Do(ctx context.Context, e parser.Expr, from, until int64, v
 		minVal := math.Inf(1)
 		currentVal := math.Inf(-1)
 		maxVal := math.Inf(-1)
+		hasValues := false
 		for _, av := range a.Values {
 			if !math.IsNaN(av) {
+				hasValues = true
 				minVal = math.Min(minVal, av)
 				maxVal = math.Max(maxVal, av)
 				currentVal = av
 			}
 		}
+		if !hasValues {
+			minVal, maxVal, currentVal = math.NaN(), math.NaN(), math.NaN()
+		}
 
 		// Format the output correctly
 		min := ""
 		max := ""
 		current := ""
-		if system == "si" {
-			mv, mf := humanize.ComputeSI(minVal)
-			xv, xf := humanize.ComputeSI(maxVal)
-			cv, cf := humanize.ComputeSI(currentVal)
-
-			min = fmt.Sprintf("%.2f%s", mv, mf)
-			max = fmt.Sprintf("%.2f%s", xv, xf)
-			current = fmt.Sprintf("%.2f%s", cv, cf)
+		if helper.ValidUnitSystem(system) {
+			min = helper.FormatUnits(minVal, system)
+			max = helper.FormatUnits(maxVal, system)
+			current = helper.FormatUnits(currentVal, system)
 
 		} else if system == "" {
 			min = fmt.Sprintf("%.0f", minVal)
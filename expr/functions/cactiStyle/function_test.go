@@ -35,7 +35,7 @@ func TestCactiStyle(t *testing.T) {
 				},
 			},
 			[]*types.MetricData{
-				types.MakeMetricData("metric1 Current:58.04k    Max:58.04k    Min:17.93k",
+				types.MakeMetricData("metric1 Current:58.04K    Max:58.04K    Min:17.93K",
 					[]float64{math.NaN(), 20531.733333333334, 20196.4, 17925.333333333332, 20950.4, 35168.13333333333, 19965.866666666665, 24556.4, 22266.4, 58039.86666666667}, 1, now32),
 			},
 		},
@@ -87,7 +87,7 @@ func TestCactiStyle(t *testing.T) {
 				},
 			},
 			[]*types.MetricData{
-				types.MakeMetricData("metric1 Current:1.00k    Max:1.00k    Min:1.00k",
+				types.MakeMetricData("metric1 Current:1.00K    Max:1.00K    Min:1.00K",
 					[]float64{1000}, 1, now32),
 			},
 		},
@@ -156,6 +156,58 @@ func TestCactiStyle(t *testing.T) {
 					[]float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()}, 1, now32),
 			},
 		},
+		{
+			"cactiStyle(metric1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {
+					types.MakeMetricData("metric1",
+						[]float64{math.NaN(), math.NaN()}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metric1 Current:NaN    Max:NaN    Min:NaN",
+					[]float64{math.NaN(), math.NaN()}, 1, now32),
+			},
+		},
+		{
+			"cactiStyle(metric1,\"binary\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {
+					types.MakeMetricData("metric1",
+						[]float64{512, 1536, math.NaN(), 1048576}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metric1 Current:1.00Mi    Max:1.00Mi    Min:512.00",
+					[]float64{512, 1536, math.NaN(), 1048576}, 1, now32),
+			},
+		},
+		{
+			"cactiStyle(metric1,\"binary\",\"B\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {
+					types.MakeMetricData("metric1",
+						[]float64{1536, math.NaN()}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metric1 Current:1.50Ki B    Max:1.50Ki B    Min:1.50Ki B",
+					[]float64{1536, math.NaN()}, 1, now32),
+			},
+		},
+		{
+			"cactiStyle(metric1,\"binary\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {
+					types.MakeMetricData("metric1",
+						[]float64{math.NaN(), math.NaN()}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metric1 Current:NaN    Max:NaN    Min:NaN",
+					[]float64{math.NaN(), math.NaN()}, 1, now32),
+			},
+		},
 	}
 
 	for _, tt := range tests {
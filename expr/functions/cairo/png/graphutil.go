@@ -220,6 +220,20 @@ var colors = map[string]color.RGBA{
 	"graphiteblue":         {0x64, 0x64, 0xff, 0xff},
 }
 
+// ValidColor checks if clr is a known color name or a hex color (rgb, rrggbb or rrggbbaa with optional leading #)
+func ValidColor(clr string) bool {
+	if _, ok := colors[clr]; ok {
+		return true
+	}
+
+	h := strings.TrimPrefix(clr, "#")
+	if len(h) != 3 && len(h) != 6 && len(h) != 8 {
+		return false
+	}
+	_, err := strconv.ParseUint(h, 16, 32)
+	return err == nil
+}
+
 func SetColor(name, rgba string) error {
 	color, err := hexToRGBA(rgba)
 	if err != nil {
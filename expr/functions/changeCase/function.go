@@ -0,0 +1,77 @@
+package changeCase
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type changeCase struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &changeCase{}
+	functions := []string{"lowercase", "uppercase"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// lowercase(seriesList), uppercase(seriesList)
+func (f *changeCase) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	rename := strings.ToLower
+	if e.Target() == "uppercase" {
+		rename = strings.ToUpper
+	}
+
+	return helper.RenameSeries(args, rename), nil
+}
+
+func (f *changeCase) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"lowercase": {
+			Description: "Takes one metric or a wildcard seriesList and changes the names of the series to lower case.\nValues are not changed.\n\nExample:\n\n.. code-block:: none\n\n  &target=lowercase(Server*.CPU.Load)",
+			Function:    "lowercase(seriesList)",
+			Group:       "Alias",
+			Module:      "graphite.render.functions.custom",
+			Name:        "lowercase",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+			},
+		},
+		"uppercase": {
+			Description: "Takes one metric or a wildcard seriesList and changes the names of the series to upper case.\nValues are not changed.\n\nExample:\n\n.. code-block:: none\n\n  &target=uppercase(server*.cpu.load)",
+			Function:    "uppercase(seriesList)",
+			Group:       "Alias",
+			Module:      "graphite.render.functions.custom",
+			Name:        "uppercase",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package changeCase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestChangeCase(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	input := map[parser.MetricRequest][]*types.MetricData{
+		{"Server*.CPU", 0, 1}: {
+			types.MakeMetricData("Server1.CPU", []float64{1, 2, 3}, 1, now32),
+			types.MakeMetricData("server2.Cpu", []float64{4, 5, 6}, 1, now32),
+		},
+	}
+
+	tests := []th.EvalTestItem{
+		{
+			"lowercase(Server*.CPU)",
+			input,
+			[]*types.MetricData{
+				types.MakeMetricData("server1.cpu", []float64{1, 2, 3}, 1, now32),
+				types.MakeMetricData("server2.cpu", []float64{4, 5, 6}, 1, now32),
+			},
+		},
+		{
+			"uppercase(Server*.CPU)",
+			input,
+			[]*types.MetricData{
+				types.MakeMetricData("SERVER1.CPU", []float64{1, 2, 3}, 1, now32),
+				types.MakeMetricData("SERVER2.CPU", []float64{4, 5, 6}, 1, now32),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprOrdered(t, &tt)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package changed
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -31,7 +30,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // changed(SeriesList)
 func (f *changed) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +38,7 @@ func (f *changed) Do(ctx context.Context, e parser.Expr, from, until int64, valu
 	var result []*types.MetricData
 	for _, a := range args {
 		r := *a
-		r.Name = fmt.Sprintf("%s(%s)", e.Target(), a.Name)
+		r.Name = helper.FuncName(e.Target(), a.Name)
 		r.Values = make([]float64, len(a.Values))
 
 		prev := math.NaN()
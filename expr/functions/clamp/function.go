@@ -0,0 +1,93 @@
+package clamp
+
+import (
+	"context"
+	"math"
+
+	"github.com/ansel1/merry"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type clamp struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &clamp{}
+	functions := []string{"clamp"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// clamp(seriesList, min, max)
+func (f *clamp) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	minValue, err := e.GetFloatArg(1)
+	if err != nil {
+		return nil, err
+	}
+	maxValue, err := e.GetFloatArg(2)
+	if err != nil {
+		return nil, err
+	}
+	if !(minValue <= maxValue) {
+		return nil, merry.WithMessagef(parser.ErrBadType, "%s: min must not be greater than max, got min=%g, max=%g", parser.ErrBadType, minValue, maxValue)
+	}
+
+	results := make([]*types.MetricData, 0, len(args))
+	for _, a := range args {
+		r := *a
+		r.Name = helper.FuncName(e.Target(), a.Name, minValue, maxValue)
+		r.Values = make([]float64, len(a.Values))
+		for i, v := range a.Values {
+			// NaN stays NaN, as math.Min and math.Max return NaN if any argument is NaN
+			r.Values[i] = math.Max(minValue, math.Min(maxValue, v))
+		}
+		results = append(results, &r)
+	}
+	return results, nil
+}
+
+func (f *clamp) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"clamp": {
+			Description: "Takes one metric or a wildcard seriesList and bounds every value to the [min, max] range:\nvalues below min are replaced with min, values above max are replaced with max. Absent values\nstay absent. Unlike minMax, values within the range are not changed.\n\nmin must not be greater than max.\n\nExample:\n\n.. code-block:: none\n\n  &target=clamp(server.cpu.usage,0,100)",
+			Function:    "clamp(seriesList, min, max)",
+			Group:       "Transform",
+			Module:      "graphite.render.functions.custom",
+			Name:        "clamp",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+				{
+					Name:     "min",
+					Required: true,
+					Type:     types.Float,
+				},
+				{
+					Name:     "max",
+					Required: true,
+					Type:     types.Float,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package clamp
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestClamp(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItem{
+		{
+			"clamp(metric1,0,100)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{-5, 0, 50, math.NaN(), 100, 150}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("clamp(metric1,0,100)",
+				[]float64{0, 0, 50, math.NaN(), 100, 100}, 1, now32)},
+		},
+		{
+			"clamp(metric1,-1.5,2.5)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{-3, -1, 2, 3, math.Inf(1), math.Inf(-1)}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("clamp(metric1,-1.5,2.5)",
+				[]float64{-1.5, -1, 2, 2.5, 2.5, -1.5}, 1, now32)},
+		},
+		{
+			// min equal to max turns every present value into a constant
+			"clamp(metric[12],1,1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric[12]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{0, 1, 2}, 1, now32),
+					types.MakeMetricData("metric2", []float64{math.NaN(), 5, -5}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("clamp(metric1,1,1)", []float64{1, 1, 1}, 1, now32),
+				types.MakeMetricData("clamp(metric2,1,1)", []float64{math.NaN(), 1, 1}, 1, now32),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+}
+
+func TestClampErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "clamp(metric1,10,1)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+		{
+			Target: "clamp(metric1,1)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+			},
+			Error: parser.ErrMissingArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
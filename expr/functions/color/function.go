@@ -0,0 +1,81 @@
+package color
+
+import (
+	"context"
+
+	"github.com/ansel1/merry"
+
+	"github.com/go-graphite/carbonapi/expr/functions/cairo/png"
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type color struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &color{}
+	functions := []string{"color"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// color(seriesList, theColor)
+func (f *color) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	theColor, err := e.GetStringArg(1)
+	if err != nil {
+		return nil, err
+	}
+	if !png.ValidColor(theColor) {
+		return nil, merry.WithMessagef(parser.ErrBadType, "invalid color %q, should be a color name or hex value", theColor)
+	}
+
+	results := make([]*types.MetricData, 0, len(args))
+	for _, a := range args {
+		r := a.Copy(true)
+		r.Color = theColor
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
+func (f *color) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"color": {
+			Name: "color",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+				{
+					Name:     "theColor",
+					Required: true,
+					Type:     types.String,
+				},
+			},
+			Module:      "graphite.render.functions",
+			Description: "Assigns the given color to the seriesList\n\nExample:\n\n.. code-block:: none\n\n  &target=color(collectd.hostname.cpu.0.user, 'green')\n  &target=color(collectd.hostname.cpu.0.system, 'ff0000')\n  &target=color(collectd.hostname.cpu.0.idle, 'gray')\n  &target=color(collectd.hostname.cpu.0.idle, '6464ffaa')",
+			Function:    "color(seriesList, theColor)",
+			Group:       "Graph",
+		},
+	}
+}
@@ -0,0 +1,106 @@
+package color
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestFunction(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItem{
+		{
+			"color(metric1,\"blue\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), 3}, 1, now32)},
+			},
+			[]*types.MetricData{th.WithGraphOptions(
+				types.MakeMetricData("metric1", []float64{1, math.NaN(), 3}, 1, now32),
+				types.GraphOptions{Color: "blue"},
+			)},
+		},
+		{
+			"color(metric*,\"#6464ffaa\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2}, 1, now32),
+					types.MakeMetricData("metric2", []float64{3, 4}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				th.WithGraphOptions(types.MakeMetricData("metric1", []float64{1, 2}, 1, now32), types.GraphOptions{Color: "#6464ffaa"}),
+				th.WithGraphOptions(types.MakeMetricData("metric2", []float64{3, 4}, 1, now32), types.GraphOptions{Color: "#6464ffaa"}),
+			},
+		},
+		{
+			"color(metric1,\"f00\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+			},
+			[]*types.MetricData{th.WithGraphOptions(
+				types.MakeMetricData("metric1", []float64{1, 2}, 1, now32),
+				types.GraphOptions{Color: "f00"},
+			)},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+
+}
+
+func TestErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "color(metric1,\"notacolor\")",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+		{
+			Target: "color(metric1,\"#12345\")",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+		{
+			Target: "color(metric1,\"gggggg\")",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
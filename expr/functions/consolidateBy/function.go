@@ -30,7 +30,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // consolidateBy(seriesList, aggregationMethod)
 func (f *consolidateBy) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -44,6 +44,8 @@ func (f *consolidateBy) Do(ctx context.Context, e parser.Expr, from, until int64
 	for _, a := range arg {
 		r := *a
 
+		// lttb isn't an aggregation function, it's applied by name when series are consolidated to maxDataPoints
+		r.ConsolidationFunc = name
 		r.AggregateFunction = consolidations.ConsolidationToFunc[name]
 
 		results = append(results, &r)
@@ -56,7 +58,7 @@ func (f *consolidateBy) Do(ctx context.Context, e parser.Expr, from, until int64
 func (f *consolidateBy) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"consolidateBy": {
-			Description: "Takes one metric or a wildcard seriesList and a consolidation function name.\n\nValid function names are 'sum', 'average', 'min', 'max', 'first' & 'last'.\n\nWhen a graph is drawn where width of the graph size in pixels is smaller than\nthe number of datapoints to be graphed, Graphite consolidates the values to\nto prevent line overlap. The consolidateBy() function changes the consolidation\nfunction from the default of 'average' to one of 'sum', 'max', 'min', 'first', or 'last'.\nThis is especially useful in sales graphs, where fractional values make no sense and a 'sum'\nof consolidated values is appropriate.\n\n.. code-block:: none\n\n  &target=consolidateBy(Sales.widgets.largeBlue, 'sum')\n  &target=consolidateBy(Servers.web01.sda1.free_space, 'max')",
+			Description: "Takes one metric or a wildcard seriesList and a consolidation function name.\n\nValid function names are 'sum', 'average', 'min', 'max', 'first' & 'last'.\n\nWhen a graph is drawn where width of the graph size in pixels is smaller than\nthe number of datapoints to be graphed, Graphite consolidates the values to\nto prevent line overlap. The consolidateBy() function changes the consolidation\nfunction from the default of 'average' to one of 'sum', 'max', 'min', 'first', or 'last'.\nThis is especially useful in sales graphs, where fractional values make no sense and a 'sum'\nof consolidated values is appropriate.\n\nCarbonAPI also supports 'lttb' (Largest-Triangle-Three-Buckets) for json responses with maxDataPoints:\ninstead of aggregating values, it selects maxDataPoints of original points that keep peaks and dips of\nthe series. Series with absent points are averaged.\n\n.. code-block:: none\n\n  &target=consolidateBy(Sales.widgets.largeBlue, 'sum')\n  &target=consolidateBy(Servers.web01.sda1.free_space, 'max')",
 			Function:    "consolidateBy(seriesList, consolidationFunc)",
 			Group:       "Special",
 			Module:      "graphite.render.functions",
@@ -69,7 +71,7 @@ func (f *consolidateBy) Description() map[string]types.FunctionDescription {
 				},
 				{
 					Name:     "consolidationFunc",
-					Options:  types.StringsToSuggestionList(consolidations.AvailableConsolidationFuncs()),
+					Options:  types.StringsToSuggestionList(append([]string{consolidations.LTTB}, consolidations.AvailableConsolidationFuncs()...)),
 					Required: true,
 					Type:     types.String,
 				},
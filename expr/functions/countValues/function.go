@@ -0,0 +1,101 @@
+package countValues
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/ansel1/merry"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type countValues struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &countValues{}
+	functions := []string{"countValues"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// countValues(seriesList, tolerance=0)
+func (f *countValues) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	tolerance, err := e.GetFloatNamedOrPosArgDefault("tolerance", 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if tolerance < 0 || math.IsNaN(tolerance) {
+		return nil, merry.WithMessagef(parser.ErrBadType, "%s: tolerance must be non-negative, got %v", parser.ErrBadType, tolerance)
+	}
+
+	return helper.AggregateSeries(ctx, e, args, func(values []float64) float64 {
+		return countDistinct(values, tolerance)
+	})
+}
+
+// countDistinct returns the number of distinct non-NaN values. Values are compared in ascending order and a value
+// starts a new group if it differs from the first value of the current group by more than tolerance, so with
+// tolerance = 0 only exactly equal values are counted once. values are reordered.
+func countDistinct(values []float64, tolerance float64) float64 {
+	n := 0
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			values[n] = v
+			n++
+		}
+	}
+	values = values[:n]
+	sort.Float64s(values)
+
+	count := 0
+	var groupStart float64
+	for i, v := range values {
+		if i == 0 || v-groupStart > tolerance {
+			groupStart = v
+			count++
+		}
+	}
+	return float64(count)
+}
+
+func (f *countValues) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"countValues": {
+			Description: "Draws a single series with the number of distinct values that the series of the seriesList\nhave at each point, e.x. the number of different versions deployed across a fleet. Absent values\nare ignored, points where all values are absent have 0 distinct values.\n\nValues are compared exactly by default. With non-zero tolerance values are compared in ascending\norder and a value is counted as a new one if it exceeds the first value of the current group by more\nthan tolerance.\n\nExample:\n\n.. code-block:: none\n\n  &target=countValues(server*.app.version)\n  &target=countValues(server*.config.checksum,tolerance=0.001)",
+			Function:    "countValues(seriesList, tolerance=0)",
+			Group:       "Combine",
+			Module:      "graphite.render.functions.custom",
+			Name:        "countValues",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+				{
+					Name:    "tolerance",
+					Type:    types.Float,
+					Default: types.NewSuggestion(0),
+				},
+			},
+		},
+	}
+}
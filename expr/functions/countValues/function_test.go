@@ -0,0 +1,73 @@
+package countValues
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestCountValues(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	input := map[parser.MetricRequest][]*types.MetricData{
+		{"metric*", 0, 1}: {
+			types.MakeMetricData("metric1", []float64{1, 1, 2, math.NaN(), 1.0005}, 1, now32),
+			types.MakeMetricData("metric2", []float64{1, 2, 2, math.NaN(), 1}, 1, now32),
+			types.MakeMetricData("metric3", []float64{1, 3, math.NaN(), math.NaN(), 1.002}, 1, now32),
+		},
+	}
+
+	tests := []th.EvalTestItem{
+		{
+			"countValues(metric*)",
+			input,
+			[]*types.MetricData{types.MakeMetricData("countValues(metric*)", []float64{1, 3, 1, 0, 3}, 1, now32)},
+		},
+		{
+			"countValues(metric*,0.001)",
+			input,
+			[]*types.MetricData{types.MakeMetricData("countValues(metric*,0.001)", []float64{1, 3, 1, 0, 2}, 1, now32)},
+		},
+		{
+			"countValues(metric*,tolerance=1)",
+			input,
+			[]*types.MetricData{types.MakeMetricData("countValues(metric*,tolerance=1)", []float64{1, 2, 1, 0, 1}, 1, now32)},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+}
+
+func TestCountValuesErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tt := th.EvalTestItemWithError{
+		Target: "countValues(metric*,-1)",
+		M: map[parser.MetricRequest][]*types.MetricData{
+			{"metric*", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+		},
+		Error: parser.ErrBadType,
+	}
+	th.TestEvalExprWithError(t, &tt)
+}
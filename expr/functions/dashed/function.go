@@ -0,0 +1,81 @@
+package dashed
+
+import (
+	"context"
+
+	"github.com/ansel1/merry"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type dashed struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &dashed{}
+	functions := []string{"dashed"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// dashed(seriesList, dashLength=5)
+func (f *dashed) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	dashLength, err := e.GetFloatNamedOrPosArgDefault("dashLength", 1, 5)
+	if err != nil {
+		return nil, err
+	}
+	if !(dashLength >= 0) {
+		return nil, merry.WithMessagef(parser.ErrBadType, "dashLength can't be negative, got %g", dashLength)
+	}
+
+	results := make([]*types.MetricData, 0, len(args))
+	for _, a := range args {
+		r := a.Copy(true)
+		r.Name = helper.FuncName("dashed", a.Name, dashLength)
+		r.Dashed = dashLength
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
+func (f *dashed) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"dashed": {
+			Name: "dashed",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+				{
+					Default: types.NewSuggestion(5),
+					Name:    "dashLength",
+					Type:    types.Float,
+				},
+			},
+			Module:      "graphite.render.functions",
+			Description: "Takes one metric or a wildcard seriesList, followed by a float F.\n\nDraw the selected metrics with a dotted line with segments of length F\nIf omitted, the default length of the segments is 5.0\n\nExample:\n\n.. code-block:: none\n\n  &target=dashed(server01.instance01.memory.free,2.5)",
+			Function:    "dashed(seriesList, dashLength=5)",
+			Group:       "Graph",
+		},
+	}
+}
@@ -2,8 +2,10 @@ package delay
 
 import (
 	"context"
-	"fmt"
 	"math"
+	"strconv"
+
+	"github.com/ansel1/merry"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
@@ -31,39 +33,57 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // delay(seriesList, steps)
 func (f *delay) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	seriesList, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	if len(e.Args()) < 2 {
+		return nil, parser.ErrMissingArgument
+	}
+
+	seriesList, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
 
-	steps, err := e.GetIntArg(1)
+	// steps is either a number of points or an interval, e.x. "5min", converted to points with the step of each series
+	var steps int
+	var interval int32
+	var stepsStr string
+	switch e.Args()[1].Type() {
+	case parser.EtConst:
+		steps, err = e.GetIntArg(1)
+		stepsStr = strconv.Itoa(steps)
+	case parser.EtString:
+		interval, err = e.GetIntervalArg(1, 1)
+		stepsStr = helper.Quote(e.Args()[1].StringValue())
+	default:
+		err = parser.ErrBadType
+	}
 	if err != nil {
 		return nil, err
 	}
+	if steps < 0 || interval < 0 {
+		return nil, merry.WithMessagef(parser.ErrBadType, "%s: steps must not be negative, got %s", parser.ErrBadType, stepsStr)
+	}
 
 	var results []*types.MetricData
 
 	for _, series := range seriesList {
 		length := len(series.Values)
 
-		newValues := make([]float64, length)
-		var prevValues []float64
+		seriesSteps := steps
+		if interval > 0 && series.StepTime > 0 {
+			seriesSteps = int(int64(interval) / series.StepTime)
+		}
 
-		for i, value := range series.Values {
-			if len(prevValues) < steps {
+		newValues := make([]float64, length)
+		for i := range newValues {
+			if i < seriesSteps {
 				newValues[i] = math.NaN()
 			} else {
-				newValue := prevValues[0]
-				prevValues = prevValues[1:]
-
-				newValues[i] = newValue
+				newValues[i] = series.Values[i-seriesSteps]
 			}
-
-			prevValues = append(prevValues, value)
 		}
 
 		result := *series
-		result.Name = fmt.Sprintf("delay(%s,%d)", series.Name, steps)
+		result.Name = helper.FuncName(e.Target(), series.Name, stepsStr)
 		result.Values = newValues
 
 		results = append(results, &result)
@@ -76,7 +96,7 @@ func (f *delay) Do(ctx context.Context, e parser.Expr, from, until int64, values
 func (f *delay) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"delay": {
-			Description: "This shifts all samples later by an integer number of steps. This can be\nused for custom derivative calculations, among other things. Note: this\nwill pad the early end of the data with None for every step shifted.\n\nThis complements other time-displacement functions such as timeShift and\ntimeSlice, in that this function is indifferent about the step intervals\nbeing shifted.\n\nExample:\n\n.. code-block:: none\n\n  &target=divideSeries(server.FreeSpace,delay(server.FreeSpace,1))\n\nThis computes the change in server free space as a percentage of the previous\nfree space.",
+			Description: "This shifts all samples later by an integer number of steps or by an interval, e.x. \"5min\". This can be\nused for custom derivative calculations, among other things. Note: this\nwill pad the early end of the data with None for every step shifted.\n\nThis complements other time-displacement functions such as timeShift and\ntimeSlice, in that this function is indifferent about the step intervals\nbeing shifted.\n\nExample:\n\n.. code-block:: none\n\n  &target=divideSeries(server.FreeSpace,delay(server.FreeSpace,1))\n\nThis computes the change in server free space as a percentage of the previous\nfree space.\n\nAn interval is converted to a number of steps with the step of each series, intervals that are\nnot a multiple of the step are rounded down:\n\n.. code-block:: none\n\n  &target=delay(server.FreeSpace,\"1h\")",
 			Function:    "delay(seriesList, steps)",
 			Group:       "Transform",
 			Module:      "graphite.render.functions",
@@ -90,7 +110,7 @@ func (f *delay) Description() map[string]types.FunctionDescription {
 				{
 					Name:     "steps",
 					Required: true,
-					Type:     types.Integer,
+					Type:     types.IntOrInterval,
 				},
 			},
 		},
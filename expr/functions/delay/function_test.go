@@ -34,6 +34,54 @@ func TestDelay(t *testing.T) {
 			[]*types.MetricData{types.MakeMetricData("delay(metric1,3)",
 				[]float64{math.NaN(), math.NaN(), math.NaN(), 1, 2, 3}, 1, now32)},
 		},
+		{
+			// interval is converted to points with the step of each series
+			"delay(metric[12],'2min')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric[12]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5}, 60, now32),
+					types.MakeMetricData("metric2", []float64{1, 2, 3, 4, 5}, 30, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("delay(metric1,'2min')", []float64{math.NaN(), math.NaN(), 1, 2, 3}, 60, now32),
+				types.MakeMetricData("delay(metric2,'2min')", []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), 1}, 30, now32),
+			},
+		},
+		{
+			"delay(metric1,0)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), 3}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("delay(metric1,0)",
+				[]float64{1, math.NaN(), 3}, 1, now32)},
+		},
+		{
+			// interval shorter than the step doesn't shift the series
+			"delay(metric1,'30s')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 60, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("delay(metric1,'30s')",
+				[]float64{1, 2, 3}, 60, now32)},
+		},
+		{
+			"delay(metric1,5)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("delay(metric1,5)",
+				[]float64{math.NaN(), math.NaN(), math.NaN()}, 1, now32)},
+		},
+		{
+			// intervals that are not a multiple of the step are rounded down
+			"delay(metric1,'90s')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 60, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("delay(metric1,'90s')",
+				[]float64{math.NaN(), 1, 2}, 60, now32)},
+		},
 	}
 
 	for _, tt := range tests {
@@ -44,3 +92,31 @@ func TestDelay(t *testing.T) {
 	}
 
 }
+
+func TestDelayErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "delay(metric1,'-5min')",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 60, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+		{
+			Target: "delay(metric1,-1)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 60, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
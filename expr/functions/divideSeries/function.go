@@ -3,9 +3,10 @@ package divideSeries
 import (
 	"context"
 	"errors"
-	"fmt"
 	"math"
 
+	"github.com/ansel1/merry"
+
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/types"
@@ -36,7 +37,7 @@ func (f *divideSeries) Do(ctx context.Context, e parser.Expr, from, until int64,
 		return nil, parser.ErrMissingTimeseries
 	}
 
-	firstArg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	firstArg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -48,12 +49,18 @@ func (f *divideSeries) Do(ctx context.Context, e parser.Expr, from, until int64,
 	if len(e.Args()) == 2 {
 		useMetricNames = true
 		numerators = firstArg
-		denominators, err := helper.GetSeriesArg(e.Args()[1], from, until, values)
+		denominators, err := helper.GetSeriesArg(ctx, e.Args()[1], from, until, values)
 		if err != nil {
 			return nil, err
 		}
-		if len(denominators) != 1 {
-			return nil, types.ErrWildcardNotAllowed
+		// single divisor is applied to every dividend, as in graphite-web
+		switch len(denominators) {
+		case 0:
+			return missingDivisor(numerators), nil
+		case 1:
+		default:
+			err := merry.WithMessagef(types.ErrWildcardNotAllowed, "%s: divisor must be a single series, got %d", types.ErrWildcardNotAllowed, len(denominators))
+			return nil, merry.WithHTTPCode(err, 400)
 		}
 
 		denominator = denominators[0]
@@ -64,31 +71,35 @@ func (f *divideSeries) Do(ctx context.Context, e parser.Expr, from, until int64,
 		return nil, errors.New("must be called with 2 series or a wildcard that matches exactly 2 series")
 	}
 
+	var results []*types.MetricData
 	for _, numerator := range numerators {
-		if numerator.StepTime != denominator.StepTime || len(numerator.Values) != len(denominator.Values) {
-			return nil, fmt.Errorf("series %s must have the same length as %s", numerator.Name, denominator.Name)
+		// series are brought to the same step and range as in graphite-web, unless strict step is requested
+		divisor := denominator
+		if numerator.StepTime != divisor.StepTime || numerator.StartTime != divisor.StartTime || len(numerator.Values) != len(divisor.Values) {
+			pair, _, _, err := helper.Normalize(ctx, []*types.MetricData{numerator, divisor})
+			if err != nil {
+				return nil, err
+			}
+			numerator, divisor = pair[0], pair[1]
 		}
-	}
 
-	var results []*types.MetricData
-	for _, numerator := range numerators {
 		r := *numerator
 		if useMetricNames {
-			r.Name = fmt.Sprintf("divideSeries(%s,%s)", numerator.Name, denominator.Name)
+			r.Name = helper.FuncName("divideSeries", numerator.Name, divisor.Name)
 		} else {
-			r.Name = fmt.Sprintf("divideSeries(%s)", e.RawArgs())
+			r.Name = helper.FuncName("divideSeries", e.RawArgs())
 		}
 		r.Values = make([]float64, len(numerator.Values))
 
 		for i, v := range numerator.Values {
 
-			// math.IsNaN(v) || math.IsNaN(denominator.Values[i]) covered by nature of math.NaN
-			if denominator.Values[i] == 0 {
+			// math.IsNaN(v) || math.IsNaN(divisor.Values[i]) covered by nature of math.NaN
+			if divisor.Values[i] == 0 {
 				r.Values[i] = math.NaN()
 				continue
 			}
 
-			r.Values[i] = v / denominator.Values[i]
+			r.Values[i] = v / divisor.Values[i]
 		}
 		results = append(results, &r)
 	}
@@ -97,6 +108,21 @@ func (f *divideSeries) Do(ctx context.Context, e parser.Expr, from, until int64,
 
 }
 
+// missingDivisor returns dividends with all values absent, as graphite-web does when divisor doesn't exist
+func missingDivisor(numerators []*types.MetricData) []*types.MetricData {
+	results := make([]*types.MetricData, 0, len(numerators))
+	for _, numerator := range numerators {
+		r := *numerator
+		r.Name = helper.FuncName("divideSeries", numerator.Name, "MISSING")
+		r.Values = make([]float64, len(numerator.Values))
+		for i := range r.Values {
+			r.Values[i] = math.NaN()
+		}
+		results = append(results, &r)
+	}
+	return results
+}
+
 // Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
 func (f *divideSeries) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
@@ -113,9 +139,8 @@ func (f *divideSeries) Description() map[string]types.FunctionDescription {
 					Type:     types.SeriesList,
 				},
 				{
-					Name:     "divisorSeries",
-					Required: true,
-					Type:     types.SeriesList,
+					Name: "divisorSeries",
+					Type: types.SeriesList,
 				},
 			},
 		},
@@ -81,6 +81,15 @@ func TestDivideSeries(t *testing.T) {
 			[]*types.MetricData{types.MakeMetricData("divideSeries(metric[12])",
 				[]float64{0.5, math.NaN(), math.NaN(), math.NaN(), math.NaN(), 2}, 1, now32)},
 		},
+		{
+			"divideSeries(metric1,metric2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+				{"metric2", 0, 1}: {},
+			},
+			[]*types.MetricData{types.MakeMetricData("divideSeries(metric1,MISSING)",
+				[]float64{math.NaN(), math.NaN(), math.NaN()}, 1, now32)},
+		},
 	}
 
 	for _, tt := range tests {
@@ -91,3 +100,31 @@ func TestDivideSeries(t *testing.T) {
 	}
 
 }
+
+func TestDivideSeriesErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "divideSeries(metric[12],metric[34])",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric[12]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32),
+					types.MakeMetricData("metric2", []float64{2, 4, 6}, 1, now32),
+				},
+				{"metric[34]", 0, 1}: {
+					types.MakeMetricData("metric3", []float64{1, 2, 3}, 1, now32),
+					types.MakeMetricData("metric4", []float64{2, 4, 6}, 1, now32),
+				},
+			},
+			Error: types.ErrWildcardNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package ewma
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/dgryski/go-onlinestats"
@@ -32,7 +31,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // ewma(seriesList, alpha)
 func (f *ewma) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +46,7 @@ func (f *ewma) Do(ctx context.Context, e parser.Expr, from, until int64, values
 	// ugh, helper.ForEachSeriesDo does not handle arguments properly
 	var results []*types.MetricData
 	for _, a := range arg {
-		name := fmt.Sprintf("ewma(%s,%v)", a.Name, alpha)
+		name := helper.FuncName("ewma", a.Name, alpha)
 
 		r := *a
 		r.Name = name
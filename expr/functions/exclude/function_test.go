@@ -37,6 +37,41 @@ func TestExclude(t *testing.T) {
 			[]*types.MetricData{types.MakeMetricData("metricBar", // NOTE(dgryski): not sure if this matches graphite
 				[]float64{2, 2, 2, 2, 2}, 1, now32)},
 		},
+		{
+			// pattern matches any part of the name, like re.search in graphite-web
+			"exclude(metric1,\"Ba\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {
+					types.MakeMetricData("metricFoo", []float64{1, 1, 1, 1, 1}, 1, now32),
+					types.MakeMetricData("metricBar", []float64{2, 2, 2, 2, 2}, 1, now32),
+					types.MakeMetricData("metricBaz", []float64{3, 3, 3, 3, 3}, 1, now32),
+				},
+			},
+			[]*types.MetricData{types.MakeMetricData("metricFoo", []float64{1, 1, 1, 1, 1}, 1, now32)},
+		},
+		{
+			"exclude(metric1,\"^Ba\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {
+					types.MakeMetricData("metricFoo", []float64{1, 1, 1, 1, 1}, 1, now32),
+					types.MakeMetricData("metricBar", []float64{2, 2, 2, 2, 2}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metricFoo", []float64{1, 1, 1, 1, 1}, 1, now32),
+				types.MakeMetricData("metricBar", []float64{2, 2, 2, 2, 2}, 1, now32),
+			},
+		},
+		{
+			"exclude(metric1,\"^metricBar$\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {
+					types.MakeMetricData("metricBar", []float64{2, 2, 2, 2, 2}, 1, now32),
+					types.MakeMetricData("metricBarBaz", []float64{3, 3, 3, 3, 3}, 1, now32),
+				},
+			},
+			[]*types.MetricData{types.MakeMetricData("metricBarBaz", []float64{3, 3, 3, 3, 3}, 1, now32)},
+		},
 	}
 
 	for _, tt := range tests {
@@ -47,3 +82,16 @@ func TestExclude(t *testing.T) {
 	}
 
 }
+
+func TestExcludeInvalidRegex(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tt := th.EvalTestItemWithError{
+		Target: "exclude(metric1,\"(Foo\")",
+		M: map[parser.MetricRequest][]*types.MetricData{
+			{"metric1", 0, 1}: {types.MakeMetricData("metricFoo", []float64{1, 1, 1, 1, 1}, 1, now32)},
+		},
+		Error: parser.ErrInvalidRegex,
+	}
+	th.TestEvalExprWithError(t, &tt)
+}
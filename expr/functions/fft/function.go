@@ -2,7 +2,6 @@ package fft
 
 import (
 	"context"
-	"fmt"
 	"math/cmplx"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -33,7 +32,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 // fft(seriesList, mode)
 // mode: "", abs, phase. Empty string means "both"
 func (f *fft) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +42,7 @@ func (f *fft) Do(ctx context.Context, e parser.Expr, from, until int64, values m
 	var results []*types.MetricData
 
 	extractComponent := func(m *types.MetricData, values []complex128, t string, f func(x complex128) float64) *types.MetricData {
-		name := fmt.Sprintf("fft(%s,'%s')", m.Name, t)
+		name := helper.FuncName("fft", m.Name, helper.Quote(t))
 		r := *m
 		r.Name = name
 		r.Values = make([]float64, len(values))
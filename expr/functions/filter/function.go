@@ -2,7 +2,9 @@ package filter
 
 import (
 	"context"
-	"fmt"
+	"math"
+
+	"github.com/ansel1/merry"
 
 	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -39,7 +41,7 @@ var supportedOperators = map[string]struct{}{
 
 // filterSeries(*seriesLists)
 func (f *filterSeries) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -55,7 +57,7 @@ func (f *filterSeries) Do(ctx context.Context, e parser.Expr, from, until int64,
 	}
 
 	if _, ok := supportedOperators[operator]; !ok {
-		return nil, fmt.Errorf("unsupported operator %v, supported operators: %v", operator, supportedOperators)
+		return nil, merry.WithMessagef(parser.ErrBadType, "unsupported operator %q, supported operators: =, !=, >, >=, <, <=", operator)
 	}
 
 	threshold, err := e.GetFloatArg(3)
@@ -63,14 +65,18 @@ func (f *filterSeries) Do(ctx context.Context, e parser.Expr, from, until int64,
 		return nil, err
 	}
 
-	aggFunc, ok := consolidations.ConsolidationToFunc[callback]
+	aggFunc, ok := consolidations.GetAggregateFunc(callback)
 	if !ok {
-		return nil, fmt.Errorf("unsupported consolidation function %s", callback)
+		return nil, merry.WithMessagef(parser.ErrBadType, "unsupported consolidation function %q", callback)
 	}
 
 	var results []*types.MetricData
 	for _, a := range args {
 		val := aggFunc(a.Values)
+		if math.IsNaN(val) {
+			// series without any valid points can't match any threshold
+			continue
+		}
 		keepSeries := false
 		switch operator {
 		case "=":
@@ -114,7 +120,7 @@ func (f *filterSeries) Description() map[string]types.FunctionDescription {
 		"filterSeries": {
 			Name:        "filterSeries",
 			Function:    "filterSeries(seriesList, func, operator, threshold)",
-			Description: "Takes one metric or a wildcard seriesList followed by a consolidation function, an operator and a threshold.\nDraws only the metrics which match the filter expression.\n\nExample:\n\n.. code-block:: none\n\n  &target=filterSeries(system.interface.eth*.packetsSent, 'max', '>', 1000)\n\nThis would only display interfaces which has a peak throughput higher than 1000 packets/min.\n\nSupported aggregation functions: ``average``, ``median``, ``sum``, ``min``,\n``max``, ``diff``, ``stddev``, ``range``, ``multiply`` & ``last``.\n\nSupported operators: ``=``, ``!=``, ``>``, ``>=``, ``<`` & ``<=``.",
+			Description: "Takes one metric or a wildcard seriesList followed by a consolidation function, an operator and a threshold.\nDraws only the metrics which match the filter expression.\n\nExample:\n\n.. code-block:: none\n\n  &target=filterSeries(system.interface.eth*.packetsSent, 'max', '>', 1000)\n\nThis would only display interfaces which has a peak throughput higher than 1000 packets/min.\n\nSupported aggregation functions: ``average``, ``median``, ``sum``, ``min``,\n``max``, ``diff``, ``stddev``, ``range``, ``multiply`` & ``last``.\n\nSupported operators: ``=``, ``!=``, ``>``, ``>=``, ``<`` & ``<=``.\n\nSeries without any valid points are always filtered out.",
 			Module:      "graphite.render.functions",
 			Group:       "Filter Series",
 			Params: []types.FunctionParam{
@@ -22,7 +22,7 @@ func init() {
 	}
 }
 
-func TestConstantLine(t *testing.T) {
+func TestFilterSeries(t *testing.T) {
 	now32 := int64(time.Now().Unix())
 
 	tests := []th.EvalTestItem{
@@ -40,6 +40,20 @@ func TestConstantLine(t *testing.T) {
 				types.MakeMetricData("metric3", []float64{3.0, math.NaN(), 4.0, 5.0, 6.0, math.NaN()}, 1, now32),
 			},
 		},
+		{
+			"filterSeries(metric[123], 'p50', '>=', 4)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric[123]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1.0, math.NaN(), 2.0, 3.0, 4.0, 5.0}, 1, now32),
+					types.MakeMetricData("metric2", []float64{2.0, math.NaN(), 3.0, math.NaN(), 5.0, 6.0}, 1, now32),
+					types.MakeMetricData("metric3", []float64{3.0, math.NaN(), 4.0, 5.0, 6.0, math.NaN()}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metric2", []float64{2.0, math.NaN(), 3.0, math.NaN(), 5.0, 6.0}, 1, now32),
+				types.MakeMetricData("metric3", []float64{3.0, math.NaN(), 4.0, 5.0, 6.0, math.NaN()}, 1, now32),
+			},
+		},
 		{
 			"filterSeries(metric[123], 'max', '=', 5)",
 			map[parser.MetricRequest][]*types.MetricData{
@@ -107,6 +121,19 @@ func TestConstantLine(t *testing.T) {
 				types.MakeMetricData("metric1", []float64{1.0, math.NaN(), 2.0, 3.0, 4.0, 5.0}, 1, now32),
 			},
 		},
+		{
+			"filterSeries(metric[123], 'sum', '!=', 15)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric[123]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1.0, math.NaN(), 2.0, 3.0, 4.0, 5.0}, 1, now32),
+					types.MakeMetricData("metric2", []float64{2.0, math.NaN(), 3.0, math.NaN(), 5.0, 6.0}, 1, now32),
+					types.MakeMetricData("metric3", []float64{math.NaN(), math.NaN(), math.NaN()}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metric2", []float64{2.0, math.NaN(), 3.0, math.NaN(), 5.0, 6.0}, 1, now32),
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -117,3 +144,31 @@ func TestConstantLine(t *testing.T) {
 	}
 
 }
+
+func TestFilterSeriesErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "filterSeries(metric1, 'max', '~', 5)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1.0, 2.0}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+		{
+			Target: "filterSeries(metric1, 'unknown', '>', 5)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1.0, 2.0}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
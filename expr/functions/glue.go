@@ -7,6 +7,7 @@ import (
 	"github.com/go-graphite/carbonapi/expr/functions/absolute"
 	"github.com/go-graphite/carbonapi/expr/functions/aggregate"
 	"github.com/go-graphite/carbonapi/expr/functions/aggregateLine"
+	"github.com/go-graphite/carbonapi/expr/functions/aggregateWithWildcards"
 	"github.com/go-graphite/carbonapi/expr/functions/alias"
 	"github.com/go-graphite/carbonapi/expr/functions/aliasByBase64"
 	"github.com/go-graphite/carbonapi/expr/functions/aliasByMetric"
@@ -14,21 +15,31 @@ import (
 	"github.com/go-graphite/carbonapi/expr/functions/aliasByPostgres"
 	"github.com/go-graphite/carbonapi/expr/functions/aliasByRedis"
 	"github.com/go-graphite/carbonapi/expr/functions/aliasSub"
+	"github.com/go-graphite/carbonapi/expr/functions/alpha"
+	"github.com/go-graphite/carbonapi/expr/functions/areaBetween"
 	"github.com/go-graphite/carbonapi/expr/functions/asPercent"
+	"github.com/go-graphite/carbonapi/expr/functions/averageOutsidePercentile"
 	"github.com/go-graphite/carbonapi/expr/functions/averageSeriesWithWildcards"
 	"github.com/go-graphite/carbonapi/expr/functions/baselines"
 	"github.com/go-graphite/carbonapi/expr/functions/below"
 	"github.com/go-graphite/carbonapi/expr/functions/cactiStyle"
 	"github.com/go-graphite/carbonapi/expr/functions/cairo"
+	"github.com/go-graphite/carbonapi/expr/functions/changeCase"
 	"github.com/go-graphite/carbonapi/expr/functions/changed"
+	"github.com/go-graphite/carbonapi/expr/functions/clamp"
+	"github.com/go-graphite/carbonapi/expr/functions/color"
 	"github.com/go-graphite/carbonapi/expr/functions/consolidateBy"
 	"github.com/go-graphite/carbonapi/expr/functions/constantLine"
+	"github.com/go-graphite/carbonapi/expr/functions/countValues"
 	"github.com/go-graphite/carbonapi/expr/functions/cumulative"
+	"github.com/go-graphite/carbonapi/expr/functions/dashed"
 	"github.com/go-graphite/carbonapi/expr/functions/delay"
 	"github.com/go-graphite/carbonapi/expr/functions/derivative"
 	"github.com/go-graphite/carbonapi/expr/functions/divideSeries"
+	"github.com/go-graphite/carbonapi/expr/functions/drawAsInfinite"
 	"github.com/go-graphite/carbonapi/expr/functions/ewma"
 	"github.com/go-graphite/carbonapi/expr/functions/exclude"
+	"github.com/go-graphite/carbonapi/expr/functions/exp"
 	"github.com/go-graphite/carbonapi/expr/functions/fallbackSeries"
 	"github.com/go-graphite/carbonapi/expr/functions/fft"
 	"github.com/go-graphite/carbonapi/expr/functions/filter"
@@ -43,6 +54,7 @@ import (
 	"github.com/go-graphite/carbonapi/expr/functions/holtWintersAberration"
 	"github.com/go-graphite/carbonapi/expr/functions/holtWintersConfidenceBands"
 	"github.com/go-graphite/carbonapi/expr/functions/holtWintersForecast"
+	"github.com/go-graphite/carbonapi/expr/functions/identity"
 	"github.com/go-graphite/carbonapi/expr/functions/ifft"
 	"github.com/go-graphite/carbonapi/expr/functions/integral"
 	"github.com/go-graphite/carbonapi/expr/functions/integralByInterval"
@@ -54,13 +66,15 @@ import (
 	"github.com/go-graphite/carbonapi/expr/functions/kolmogorovSmirnovTest2"
 	"github.com/go-graphite/carbonapi/expr/functions/legendValue"
 	"github.com/go-graphite/carbonapi/expr/functions/limit"
+	"github.com/go-graphite/carbonapi/expr/functions/lineWidth"
 	"github.com/go-graphite/carbonapi/expr/functions/linearRegression"
 	"github.com/go-graphite/carbonapi/expr/functions/logarithm"
 	"github.com/go-graphite/carbonapi/expr/functions/lowPass"
 	"github.com/go-graphite/carbonapi/expr/functions/mapSeries"
+	"github.com/go-graphite/carbonapi/expr/functions/minMax"
+	"github.com/go-graphite/carbonapi/expr/functions/minMaxValue"
 	"github.com/go-graphite/carbonapi/expr/functions/mostDeviant"
 	"github.com/go-graphite/carbonapi/expr/functions/moving"
-	"github.com/go-graphite/carbonapi/expr/functions/movingMedian"
 	"github.com/go-graphite/carbonapi/expr/functions/multiplySeriesWithWildcards"
 	"github.com/go-graphite/carbonapi/expr/functions/nPercentile"
 	"github.com/go-graphite/carbonapi/expr/functions/nonNegativeDerivative"
@@ -72,21 +86,28 @@ import (
 	"github.com/go-graphite/carbonapi/expr/functions/percentileOfSeries"
 	"github.com/go-graphite/carbonapi/expr/functions/polyfit"
 	"github.com/go-graphite/carbonapi/expr/functions/pow"
+	"github.com/go-graphite/carbonapi/expr/functions/powSeries"
 	"github.com/go-graphite/carbonapi/expr/functions/randomWalk"
 	"github.com/go-graphite/carbonapi/expr/functions/rangeOfSeries"
 	"github.com/go-graphite/carbonapi/expr/functions/reduce"
 	"github.com/go-graphite/carbonapi/expr/functions/removeBelowSeries"
+	"github.com/go-graphite/carbonapi/expr/functions/removeBetweenPercentile"
+	"github.com/go-graphite/carbonapi/expr/functions/removeConsecutiveDuplicates"
 	"github.com/go-graphite/carbonapi/expr/functions/removeEmptySeries"
+	"github.com/go-graphite/carbonapi/expr/functions/removeSeries"
 	"github.com/go-graphite/carbonapi/expr/functions/round"
 	"github.com/go-graphite/carbonapi/expr/functions/scale"
 	"github.com/go-graphite/carbonapi/expr/functions/scaleToSeconds"
+	"github.com/go-graphite/carbonapi/expr/functions/secondYAxis"
 	"github.com/go-graphite/carbonapi/expr/functions/seriesByTag"
 	"github.com/go-graphite/carbonapi/expr/functions/seriesList"
+	"github.com/go-graphite/carbonapi/expr/functions/sigmoid"
 	"github.com/go-graphite/carbonapi/expr/functions/slo"
 	"github.com/go-graphite/carbonapi/expr/functions/smartSummarize"
 	"github.com/go-graphite/carbonapi/expr/functions/sortBy"
 	"github.com/go-graphite/carbonapi/expr/functions/sortByName"
 	"github.com/go-graphite/carbonapi/expr/functions/squareRoot"
+	"github.com/go-graphite/carbonapi/expr/functions/stacked"
 	"github.com/go-graphite/carbonapi/expr/functions/stdev"
 	"github.com/go-graphite/carbonapi/expr/functions/substr"
 	"github.com/go-graphite/carbonapi/expr/functions/sumSeriesWithWildcards"
@@ -115,6 +136,7 @@ func New(configs map[string]string) {
 		{name: "absolute", filename: "absolute", order: absolute.GetOrder(), f: absolute.New},
 		{name: "aggregate", filename: "aggregate", order: aggregate.GetOrder(), f: aggregate.New},
 		{name: "aggregateLine", filename: "aggregateLine", order: aggregateLine.GetOrder(), f: aggregateLine.New},
+		{name: "aggregateWithWildcards", filename: "aggregateWithWildcards", order: aggregateWithWildcards.GetOrder(), f: aggregateWithWildcards.New},
 		{name: "alias", filename: "alias", order: alias.GetOrder(), f: alias.New},
 		{name: "aliasByBase64", filename: "aliasByBase64", order: aliasByBase64.GetOrder(), f: aliasByBase64.New},
 		{name: "aliasByMetric", filename: "aliasByMetric", order: aliasByMetric.GetOrder(), f: aliasByMetric.New},
@@ -122,21 +144,31 @@ func New(configs map[string]string) {
 		{name: "aliasByPostgres", filename: "aliasByPostgres", order: aliasByPostgres.GetOrder(), f: aliasByPostgres.New},
 		{name: "aliasByRedis", filename: "aliasByRedis", order: aliasByRedis.GetOrder(), f: aliasByRedis.New},
 		{name: "aliasSub", filename: "aliasSub", order: aliasSub.GetOrder(), f: aliasSub.New},
+		{name: "alpha", filename: "alpha", order: alpha.GetOrder(), f: alpha.New},
+		{name: "areaBetween", filename: "areaBetween", order: areaBetween.GetOrder(), f: areaBetween.New},
 		{name: "asPercent", filename: "asPercent", order: asPercent.GetOrder(), f: asPercent.New},
+		{name: "averageOutsidePercentile", filename: "averageOutsidePercentile", order: averageOutsidePercentile.GetOrder(), f: averageOutsidePercentile.New},
 		{name: "averageSeriesWithWildcards", filename: "averageSeriesWithWildcards", order: averageSeriesWithWildcards.GetOrder(), f: averageSeriesWithWildcards.New},
 		{name: "baselines", filename: "baselines", order: baselines.GetOrder(), f: baselines.New},
 		{name: "below", filename: "below", order: below.GetOrder(), f: below.New},
 		{name: "cactiStyle", filename: "cactiStyle", order: cactiStyle.GetOrder(), f: cactiStyle.New},
 		{name: "cairo", filename: "cairo", order: cairo.GetOrder(), f: cairo.New},
+		{name: "changeCase", filename: "changeCase", order: changeCase.GetOrder(), f: changeCase.New},
 		{name: "changed", filename: "changed", order: changed.GetOrder(), f: changed.New},
+		{name: "clamp", filename: "clamp", order: clamp.GetOrder(), f: clamp.New},
+		{name: "color", filename: "color", order: color.GetOrder(), f: color.New},
 		{name: "consolidateBy", filename: "consolidateBy", order: consolidateBy.GetOrder(), f: consolidateBy.New},
 		{name: "constantLine", filename: "constantLine", order: constantLine.GetOrder(), f: constantLine.New},
+		{name: "countValues", filename: "countValues", order: countValues.GetOrder(), f: countValues.New},
 		{name: "cumulative", filename: "cumulative", order: cumulative.GetOrder(), f: cumulative.New},
+		{name: "dashed", filename: "dashed", order: dashed.GetOrder(), f: dashed.New},
 		{name: "delay", filename: "delay", order: delay.GetOrder(), f: delay.New},
 		{name: "derivative", filename: "derivative", order: derivative.GetOrder(), f: derivative.New},
 		{name: "divideSeries", filename: "divideSeries", order: divideSeries.GetOrder(), f: divideSeries.New},
+		{name: "drawAsInfinite", filename: "drawAsInfinite", order: drawAsInfinite.GetOrder(), f: drawAsInfinite.New},
 		{name: "ewma", filename: "ewma", order: ewma.GetOrder(), f: ewma.New},
 		{name: "exclude", filename: "exclude", order: exclude.GetOrder(), f: exclude.New},
+		{name: "exp", filename: "exp", order: exp.GetOrder(), f: exp.New},
 		{name: "fallbackSeries", filename: "fallbackSeries", order: fallbackSeries.GetOrder(), f: fallbackSeries.New},
 		{name: "fft", filename: "fft", order: fft.GetOrder(), f: fft.New},
 		{name: "filter", filename: "filter", order: filter.GetOrder(), f: filter.New},
@@ -151,6 +183,7 @@ func New(configs map[string]string) {
 		{name: "holtWintersAberration", filename: "holtWintersAberration", order: holtWintersAberration.GetOrder(), f: holtWintersAberration.New},
 		{name: "holtWintersConfidenceBands", filename: "holtWintersConfidenceBands", order: holtWintersConfidenceBands.GetOrder(), f: holtWintersConfidenceBands.New},
 		{name: "holtWintersForecast", filename: "holtWintersForecast", order: holtWintersForecast.GetOrder(), f: holtWintersForecast.New},
+		{name: "identity", filename: "identity", order: identity.GetOrder(), f: identity.New},
 		{name: "ifft", filename: "ifft", order: ifft.GetOrder(), f: ifft.New},
 		{name: "integral", filename: "integral", order: integral.GetOrder(), f: integral.New},
 		{name: "integralByInterval", filename: "integralByInterval", order: integralByInterval.GetOrder(), f: integralByInterval.New},
@@ -162,13 +195,15 @@ func New(configs map[string]string) {
 		{name: "kolmogorovSmirnovTest2", filename: "kolmogorovSmirnovTest2", order: kolmogorovSmirnovTest2.GetOrder(), f: kolmogorovSmirnovTest2.New},
 		{name: "legendValue", filename: "legendValue", order: legendValue.GetOrder(), f: legendValue.New},
 		{name: "limit", filename: "limit", order: limit.GetOrder(), f: limit.New},
+		{name: "lineWidth", filename: "lineWidth", order: lineWidth.GetOrder(), f: lineWidth.New},
 		{name: "linearRegression", filename: "linearRegression", order: linearRegression.GetOrder(), f: linearRegression.New},
 		{name: "logarithm", filename: "logarithm", order: logarithm.GetOrder(), f: logarithm.New},
 		{name: "lowPass", filename: "lowPass", order: lowPass.GetOrder(), f: lowPass.New},
 		{name: "mapSeries", filename: "mapSeries", order: mapSeries.GetOrder(), f: mapSeries.New},
+		{name: "minMax", filename: "minMax", order: minMax.GetOrder(), f: minMax.New},
+		{name: "minMaxValue", filename: "minMaxValue", order: minMaxValue.GetOrder(), f: minMaxValue.New},
 		{name: "mostDeviant", filename: "mostDeviant", order: mostDeviant.GetOrder(), f: mostDeviant.New},
 		{name: "moving", filename: "moving", order: moving.GetOrder(), f: moving.New},
-		{name: "movingMedian", filename: "movingMedian", order: movingMedian.GetOrder(), f: movingMedian.New},
 		{name: "multiplySeriesWithWildcards", filename: "multiplySeriesWithWildcards", order: multiplySeriesWithWildcards.GetOrder(), f: multiplySeriesWithWildcards.New},
 		{name: "nPercentile", filename: "nPercentile", order: nPercentile.GetOrder(), f: nPercentile.New},
 		{name: "nonNegativeDerivative", filename: "nonNegativeDerivative", order: nonNegativeDerivative.GetOrder(), f: nonNegativeDerivative.New},
@@ -180,21 +215,28 @@ func New(configs map[string]string) {
 		{name: "percentileOfSeries", filename: "percentileOfSeries", order: percentileOfSeries.GetOrder(), f: percentileOfSeries.New},
 		{name: "polyfit", filename: "polyfit", order: polyfit.GetOrder(), f: polyfit.New},
 		{name: "pow", filename: "pow", order: pow.GetOrder(), f: pow.New},
+		{name: "powSeries", filename: "powSeries", order: powSeries.GetOrder(), f: powSeries.New},
 		{name: "randomWalk", filename: "randomWalk", order: randomWalk.GetOrder(), f: randomWalk.New},
 		{name: "rangeOfSeries", filename: "rangeOfSeries", order: rangeOfSeries.GetOrder(), f: rangeOfSeries.New},
 		{name: "reduce", filename: "reduce", order: reduce.GetOrder(), f: reduce.New},
 		{name: "removeBelowSeries", filename: "removeBelowSeries", order: removeBelowSeries.GetOrder(), f: removeBelowSeries.New},
+		{name: "removeBetweenPercentile", filename: "removeBetweenPercentile", order: removeBetweenPercentile.GetOrder(), f: removeBetweenPercentile.New},
+		{name: "removeConsecutiveDuplicates", filename: "removeConsecutiveDuplicates", order: removeConsecutiveDuplicates.GetOrder(), f: removeConsecutiveDuplicates.New},
 		{name: "removeEmptySeries", filename: "removeEmptySeries", order: removeEmptySeries.GetOrder(), f: removeEmptySeries.New},
+		{name: "removeSeries", filename: "removeSeries", order: removeSeries.GetOrder(), f: removeSeries.New},
 		{name: "round", filename: "round", order: round.GetOrder(), f: round.New},
 		{name: "scale", filename: "scale", order: scale.GetOrder(), f: scale.New},
 		{name: "scaleToSeconds", filename: "scaleToSeconds", order: scaleToSeconds.GetOrder(), f: scaleToSeconds.New},
+		{name: "secondYAxis", filename: "secondYAxis", order: secondYAxis.GetOrder(), f: secondYAxis.New},
 		{name: "seriesByTag", filename: "seriesByTag", order: seriesByTag.GetOrder(), f: seriesByTag.New},
 		{name: "seriesList", filename: "seriesList", order: seriesList.GetOrder(), f: seriesList.New},
+		{name: "sigmoid", filename: "sigmoid", order: sigmoid.GetOrder(), f: sigmoid.New},
 		{name: "slo", filename: "slo", order: slo.GetOrder(), f: slo.New},
 		{name: "smartSummarize", filename: "smartSummarize", order: smartSummarize.GetOrder(), f: smartSummarize.New},
 		{name: "sortBy", filename: "sortBy", order: sortBy.GetOrder(), f: sortBy.New},
 		{name: "sortByName", filename: "sortByName", order: sortByName.GetOrder(), f: sortByName.New},
 		{name: "squareRoot", filename: "squareRoot", order: squareRoot.GetOrder(), f: squareRoot.New},
+		{name: "stacked", filename: "stacked", order: stacked.GetOrder(), f: stacked.New},
 		{name: "stdev", filename: "stdev", order: stdev.GetOrder(), f: stdev.New},
 		{name: "substr", filename: "substr", order: substr.GetOrder(), f: substr.New},
 		{name: "sumSeriesWithWildcards", filename: "sumSeriesWithWildcards", order: sumSeriesWithWildcards.GetOrder(), f: sumSeriesWithWildcards.New},
@@ -17,7 +17,6 @@ import (
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/limiter"
 	"github.com/go-graphite/carbonapi/pkg/parser"
-	pb "github.com/go-graphite/protocol/carbonapi_v3_pb"
 	"github.com/lomik/zapwriter"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -282,43 +281,6 @@ smartSummarise will be performed by graphite-web and then results will be passed
 	return res
 }
 
-type target string
-
-func (t *target) UnmarshalJSON(d []byte) error {
-	var res interface{}
-	err := json.Unmarshal(d, &res)
-	if err != nil {
-		return err
-	}
-	switch v := res.(type) {
-	case int:
-		*t = target(strconv.FormatInt(int64(v), 10))
-	case int32:
-		*t = target(strconv.FormatInt(int64(v), 10))
-	case int64:
-		*t = target(strconv.FormatInt(v, 10))
-	case float64:
-		*t = target(strconv.FormatFloat(v, 'f', -1, 64))
-	case string:
-		*t = target(v)
-	case bool:
-		*t = target(strconv.FormatBool(v))
-	default:
-		return fmt.Errorf("unsupported type for target")
-	}
-
-	return nil
-}
-
-type graphiteMetric struct {
-	Tags              map[string]json.RawMessage
-	Target            target
-	PathExpression    target
-	Datapoints        [][2]float64
-	XFilesFactor      float32
-	ConsolidationFunc string
-}
-
 type graphiteError struct {
 	server string
 	err    error
@@ -409,57 +371,7 @@ func (f *graphiteWeb) Do(ctx context.Context, e parser.Expr, from, until int64,
 		zap.String("body", string(body)),
 	)
 
-	var tmp []graphiteMetric
-
-	err := json.Unmarshal(body, &tmp)
-	if err != nil {
-		return nil, err
-	}
-
-	res := make([]*types.MetricData, 0, len(tmp))
-
-	for _, m := range tmp {
-		stepTime := int64(60)
-		if len(m.Datapoints) > 1 {
-			stepTime = int64(m.Datapoints[1][1] - m.Datapoints[0][1])
-		}
-
-		if m.ConsolidationFunc == "" {
-			m.ConsolidationFunc = "avg"
-		}
-
-		pbResp := pb.FetchResponse{
-			Name:              string(m.Target),
-			StartTime:         int64(m.Datapoints[0][1]),
-			StopTime:          int64(m.Datapoints[len(m.Datapoints)-1][1]),
-			StepTime:          stepTime,
-			Values:            make([]float64, len(m.Datapoints)),
-			XFilesFactor:      m.XFilesFactor,
-			PathExpression:    string(m.PathExpression),
-			ConsolidationFunc: m.ConsolidationFunc,
-		}
-		tags := make(map[string]string, len(m.Tags))
-		for tag, rawValue := range m.Tags {
-			var value string
-			err = json.Unmarshal(rawValue, &value)
-			// TODO(civil): check if invalid message can ever occur
-			// We are currently ignoring all invalid tags
-			if err != nil {
-				continue
-			}
-			tags[tag] = value
-		}
-
-		for i, v := range m.Datapoints {
-			pbResp.Values[i] = v[0]
-		}
-		res = append(res, &types.MetricData{
-			FetchResponse: pbResp,
-			Tags: tags,
-		})
-	}
-
-	return res, nil
+	return types.UnmarshalJSON(body)
 }
 
 func (f *graphiteWeb) Description() map[string]types.FunctionDescription {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"regexp"
 
+	"github.com/ansel1/merry"
+
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/types"
@@ -30,7 +32,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // grep(seriesList, pattern)
 func (f *grep) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -40,9 +42,10 @@ func (f *grep) Do(ctx context.Context, e parser.Expr, from, until int64, values
 		return nil, err
 	}
 
+	// graphite-web uses re.search, so pattern matches any part of the name unless it's anchored with ^ or $
 	patre, err := regexp.Compile(pat)
 	if err != nil {
-		return nil, err
+		return nil, merry.WithMessagef(parser.ErrInvalidRegex, "%s: %q: %v", parser.ErrInvalidRegex, pat, err)
 	}
 
 	var results []*types.MetricData
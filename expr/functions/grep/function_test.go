@@ -37,6 +37,31 @@ func TestGrep(t *testing.T) {
 			[]*types.MetricData{types.MakeMetricData("metricBar", // NOTE(dgryski): not sure if this matches graphite
 				[]float64{2, 2, 2, 2, 2}, 1, now32)},
 		},
+		{
+			// pattern matches any part of the name, like re.search in graphite-web
+			"grep(metric1,\"Ba\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {
+					types.MakeMetricData("metricFoo", []float64{1, 1, 1, 1, 1}, 1, now32),
+					types.MakeMetricData("metricBar", []float64{2, 2, 2, 2, 2}, 1, now32),
+					types.MakeMetricData("metricBaz", []float64{3, 3, 3, 3, 3}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metricBar", []float64{2, 2, 2, 2, 2}, 1, now32),
+				types.MakeMetricData("metricBaz", []float64{3, 3, 3, 3, 3}, 1, now32),
+			},
+		},
+		{
+			"grep(metric1,\"^metricBar$\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {
+					types.MakeMetricData("metricBar", []float64{2, 2, 2, 2, 2}, 1, now32),
+					types.MakeMetricData("metricBarBaz", []float64{3, 3, 3, 3, 3}, 1, now32),
+				},
+			},
+			[]*types.MetricData{types.MakeMetricData("metricBar", []float64{2, 2, 2, 2, 2}, 1, now32)},
+		},
 	}
 
 	for _, tt := range tests {
@@ -47,3 +72,16 @@ func TestGrep(t *testing.T) {
 	}
 
 }
+
+func TestGrepInvalidRegex(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tt := th.EvalTestItemWithError{
+		Target: "grep(metric1,\"[a-\")",
+		M: map[parser.MetricRequest][]*types.MetricData{
+			{"metric1", 0, 1}: {types.MakeMetricData("metricFoo", []float64{1, 1, 1, 1, 1}, 1, now32)},
+		},
+		Error: parser.ErrInvalidRegex,
+	}
+	th.TestEvalExprWithError(t, &tt)
+}
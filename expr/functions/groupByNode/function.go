@@ -2,7 +2,6 @@ package groupByNode
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
 	"github.com/go-graphite/carbonapi/expr/consolidations"
@@ -33,7 +32,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 // groupByNode(seriesList, nodeNum, callback)
 // groupByNodes(seriesList, callback, *nodes)
 func (f *groupByNode) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +62,10 @@ func (f *groupByNode) Do(ctx context.Context, e parser.Expr, from, until int64,
 		}
 	}
 
+	if err := helper.CheckReducer(callback); err != nil {
+		return nil, err
+	}
+
 	var results []*types.MetricData
 
 	groups := make(map[string][]*types.MetricData)
@@ -85,36 +88,13 @@ func (f *groupByNode) Do(ctx context.Context, e parser.Expr, from, until int64,
 	}
 
 	for _, k := range nodeList {
-		k := k // k's reference is used later, so it's important to make it unique per loop
-		v := groups[k]
-
-		// Ensure that names won't be parsed as consts, appending stub to them
-		expr := fmt.Sprintf("%s(stub_%s)", callback, k)
-
-		// create a stub context to evaluate the callback in
-		nexpr, _, err := parser.ParseExpr(expr)
+		r, err := helper.Reduce(ctx, callback, groups[k], from, until)
 		if err != nil {
 			return nil, err
 		}
-		// remove all stub_ prefixes we've prepended before
-		nexpr.SetRawArgs(strings.Replace(nexpr.RawArgs(), "stub_", "", 1))
-		for argIdx := range nexpr.Args() {
-			nexpr.Args()[argIdx].SetTarget(strings.Replace(nexpr.Args()[0].Target(), "stub_", "", 1))
-		}
-
-		nvalues := values
-		if e.Target() == "groupByNode" || e.Target() == "groupByNodes" {
-			nvalues = map[parser.MetricRequest][]*types.MetricData{
-				parser.MetricRequest{k, from, until}: v,
-			}
-		}
-
-		r, _ := f.Evaluator.Eval(ctx, nexpr, from, until, nvalues)
-		if r != nil {
-			r[0].Name = k
-			r[0].Tags["name"] = k
-			results = append(results, r...)
-		}
+		r.Name = k
+		r.Tags["name"] = k
+		results = append(results, r)
 	}
 
 	return results, nil
@@ -124,7 +104,7 @@ func (f *groupByNode) Do(ctx context.Context, e parser.Expr, from, until int64,
 func (f *groupByNode) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"groupByNode": {
-			Description: "Takes a serieslist and maps a callback to subgroups within as defined by a common node\n\n.. code-block:: none\n\n  &target=groupByNode(ganglia.by-function.*.*.cpu.load5,2,\"sumSeries\")\n\nWould return multiple series which are each the result of applying the \"sumSeries\" function\nto groups joined on the second node (0 indexed) resulting in a list of targets like\n\n.. code-block :: none\n\n  sumSeries(ganglia.by-function.server1.*.cpu.load5),sumSeries(ganglia.by-function.server2.*.cpu.load5),...\n\nNode may be an integer referencing a node in the series name or a string identifying a tag.\n\nThis is an alias for using :py:func:`groupByNodes <groupByNodes>` with a single node.",
+			Description: "Takes a serieslist and maps a callback to subgroups within as defined by a common node\n\n.. code-block:: none\n\n  &target=groupByNode(ganglia.by-function.*.*.cpu.load5,2,\"sumSeries\")\n\nWould return multiple series which are each the result of applying the \"sumSeries\" function\nto groups joined on the second node (0 indexed) resulting in a list of targets like\n\n.. code-block :: none\n\n  sumSeries(ganglia.by-function.server1.*.cpu.load5),sumSeries(ganglia.by-function.server2.*.cpu.load5),...\n\nNode may be an integer referencing a node in the series name or a string identifying a tag.\n\nThis is an alias for using :py:func:`groupByNodes <groupByNodes>` with a single node.\n\nCarbonAPI also accepts the name of any function that takes a seriesList as its only argument and\nreturns a single series, e.x. ``highestMax``. Other functions fail the request.",
 			Function:    "groupByNode(seriesList, nodeNum, callback='average')",
 			Group:       "Combine",
 			Module:      "graphite.render.functions",
@@ -150,7 +130,7 @@ func (f *groupByNode) Description() map[string]types.FunctionDescription {
 			},
 		},
 		"groupByNodes": {
-			Description: "Takes a serieslist and maps a callback to subgroups within as defined by multiple nodes\n\n.. code-block:: none\n\n  &target=groupByNodes(ganglia.server*.*.cpu.load*,\"sum\",1,4)\n\nWould return multiple series which are each the result of applying the \"sum\" aggregation\nto groups joined on the nodes' list (0 indexed) resulting in a list of targets like\n\n.. code-block :: none\n\n  sumSeries(ganglia.server1.*.cpu.load5),sumSeries(ganglia.server1.*.cpu.load10),sumSeries(ganglia.server1.*.cpu.load15),sumSeries(ganglia.server2.*.cpu.load5),sumSeries(ganglia.server2.*.cpu.load10),sumSeries(ganglia.server2.*.cpu.load15),...\n\nThis function can be used with all aggregation functions supported by\n:py:func:`aggregate <aggregate>`: ``average``, ``median``, ``sum``, ``min``, ``max``, ``diff``,\n``stddev``, ``range`` & ``multiply``.\n\nEach node may be an integer referencing a node in the series name or a string identifying a tag.\n\n.. code-block :: none\n\n  &target=seriesByTag(\"name=~cpu.load.*\", \"server=~server[1-9}+\", \"datacenter=~dc[1-9}+\")|groupByNodes(\"average\", \"datacenter\", 1)\n\n  # will produce output series like\n  # dc1.load5, dc2.load5, dc1.load10, dc2.load10\n\nThis complements :py:func:`aggregateWithWildcards <aggregateWithWildcards>` which takes a list of wildcard nodes.",
+			Description: "Takes a serieslist and maps a callback to subgroups within as defined by multiple nodes\n\n.. code-block:: none\n\n  &target=groupByNodes(ganglia.server*.*.cpu.load*,\"sum\",1,4)\n\nWould return multiple series which are each the result of applying the \"sum\" aggregation\nto groups joined on the nodes' list (0 indexed) resulting in a list of targets like\n\n.. code-block :: none\n\n  sumSeries(ganglia.server1.*.cpu.load5),sumSeries(ganglia.server1.*.cpu.load10),sumSeries(ganglia.server1.*.cpu.load15),sumSeries(ganglia.server2.*.cpu.load5),sumSeries(ganglia.server2.*.cpu.load10),sumSeries(ganglia.server2.*.cpu.load15),...\n\nThis function can be used with all aggregation functions supported by\n:py:func:`aggregate <aggregate>`: ``average``, ``median``, ``sum``, ``min``, ``max``, ``diff``,\n``stddev``, ``range`` & ``multiply``.\n\nEach node may be an integer referencing a node in the series name or a string identifying a tag.\n\n.. code-block :: none\n\n  &target=seriesByTag(\"name=~cpu.load.*\", \"server=~server[1-9}+\", \"datacenter=~dc[1-9}+\")|groupByNodes(\"average\", \"datacenter\", 1)\n\n  # will produce output series like\n  # dc1.load5, dc2.load5, dc1.load10, dc2.load10\n\nThis complements :py:func:`aggregateWithWildcards <aggregateWithWildcards>` which takes a list of wildcard nodes.\n\nCarbonAPI also accepts the name of any function that takes a seriesList as its only argument and\nreturns a single series, e.x. ``highestMax``. Other functions fail the request.",
 			Function:    "groupByNodes(seriesList, callback, *nodes)",
 			Group:       "Combine",
 			Module:      "graphite.render.functions",
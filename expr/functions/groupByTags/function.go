@@ -2,7 +2,6 @@ package groupByTags
 
 import (
 	"context"
-	"fmt"
 	"sort"
 	"strings"
 
@@ -34,7 +33,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // seriesByTag("name=cpu")|groupByTags("average","dc","os")
 func (f *groupByTags) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -51,6 +50,10 @@ func (f *groupByTags) Do(ctx context.Context, e parser.Expr, from, until int64,
 
 	sort.Strings(tagNames)
 
+	if err := helper.CheckReducer(callback); err != nil {
+		return nil, err
+	}
+
 	var results []*types.MetricData
 
 	names := make(map[string]string)
@@ -78,35 +81,12 @@ func (f *groupByTags) Do(ctx context.Context, e parser.Expr, from, until int64,
 	}
 
 	for k, v := range groups {
-		k := k // k's reference is used later, so it's important to make it unique per loop
-		v := v
-
-		var expr string
-		_, ok := consolidations.ConsolidationToFunc[callback]
-		if ok {
-			expr = fmt.Sprintf("aggregate(stub, \"%s\")", callback)
-		} else {
-			expr = fmt.Sprintf("%s(stub)", callback)
-		}
-
-		// create a stub context to evaluate the callback in
-		nexpr, _, err := parser.ParseExpr(expr)
+		r, err := helper.Reduce(ctx, callback, v, from, until)
 		if err != nil {
 			return nil, err
 		}
-
-		nvalues := map[parser.MetricRequest][]*types.MetricData{
-			parser.MetricRequest{"stub", from, until}: v,
-		}
-
-		r, err := f.Evaluator.Eval(ctx, nexpr, from, until, nvalues)
-		if err != nil {
-			return nil, err
-		}
-		if r != nil {
-			r[0].Name = names[k] + k
-			results = append(results, r...)
-		}
+		r.Name = names[k] + k
+		results = append(results, r)
 	}
 
 	return results, nil
@@ -115,7 +95,7 @@ func (f *groupByTags) Do(ctx context.Context, e parser.Expr, from, until int64,
 func (f *groupByTags) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"groupByTags": {
-			Description: "Takes a serieslist and maps a callback to subgroups within as defined by multiple tags\n\n.. code-block:: none\n\n  &target=seriesByTag(\"name=cpu\")|groupByTags(\"average\",\"dc\")\n\nWould return multiple series which are each the result of applying the \"averageSeries\" function\nto groups joined on the specified tags resulting in a list of targets like\n\n.. code-block :: none\n\n  averageSeries(seriesByTag(\"name=cpu\",\"dc=dc1\")),averageSeries(seriesByTag(\"name=cpu\",\"dc=dc2\")),...\n\nThis function can be used with all aggregation functions supported by\n:py:func:`aggregate <aggregate>`: ``average``, ``median``, ``sum``, ``min``, ``max``, ``diff``,\n``stddev``, ``range`` & ``multiply``.",
+			Description: "Takes a serieslist and maps a callback to subgroups within as defined by multiple tags\n\n.. code-block:: none\n\n  &target=seriesByTag(\"name=cpu\")|groupByTags(\"average\",\"dc\")\n\nWould return multiple series which are each the result of applying the \"averageSeries\" function\nto groups joined on the specified tags resulting in a list of targets like\n\n.. code-block :: none\n\n  averageSeries(seriesByTag(\"name=cpu\",\"dc=dc1\")),averageSeries(seriesByTag(\"name=cpu\",\"dc=dc2\")),...\n\nThis function can be used with all aggregation functions supported by\n:py:func:`aggregate <aggregate>`: ``average``, ``median``, ``sum``, ``min``, ``max``, ``diff``,\n``stddev``, ``range`` & ``multiply``.\n\nCarbonAPI also accepts the name of any function that takes a seriesList as its only argument and\nreturns a single series, e.x. ``highestMax``. Other functions fail the request.",
 			Function:    "groupByTags(seriesList, callback, *tags)",
 			Group:       "Combine",
 			Module:      "graphite.render.functions",
@@ -7,6 +7,8 @@ import (
 	"math"
 	"strings"
 
+	"github.com/ansel1/merry"
+
 	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
@@ -33,29 +35,21 @@ func New(configFile string) []interfaces.FunctionMetadata {
 }
 
 // highestAverage(seriesList, n) , highestCurrent(seriesList, n), highestMax(seriesList, n)
+// n between 0 and 1 selects that fraction of series, rounded up
 func (f *highest) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
 
 	n := 1
 	if len(e.Args()) > 1 && e.Target() != "highest" && e.Target() != "lowest" {
-		n, err = e.GetIntArg(1)
+		n, err = getCount(e, 1, len(arg))
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	var results []*types.MetricData
-
-	// we have fewer arguments than we want result series
-	if len(arg) < n {
-		return arg, nil
-	}
-
-	var mh types.MetricHeap
-
 	var compute func([]float64) float64
 
 	isHighest := strings.HasPrefix(e.Target(), "highest")
@@ -64,7 +58,7 @@ func (f *highest) Do(ctx context.Context, e parser.Expr, from, until int64, valu
 		consolidation := "average"
 		switch len(e.Args()) {
 		case 2:
-			n, err = e.GetIntArg(1)
+			n, err = getCount(e, 1, len(arg))
 			if err != nil {
 				// We need to support case where only function specified
 				n = 1
@@ -74,7 +68,7 @@ func (f *highest) Do(ctx context.Context, e parser.Expr, from, until int64, valu
 				}
 			}
 		case 3:
-			n, err = e.GetIntArg(1)
+			n, err = getCount(e, 1, len(arg))
 
 			if err != nil {
 				return nil, err
@@ -102,6 +96,18 @@ func (f *highest) Do(ctx context.Context, e parser.Expr, from, until int64, valu
 		return nil, fmt.Errorf("unsupported function %v", e.Target())
 	}
 
+	var results []*types.MetricData
+
+	// we have fewer arguments than we want result series
+	if len(arg) < n {
+		return arg, nil
+	}
+	if n == 0 {
+		return results, nil
+	}
+
+	var mh types.MetricHeap
+
 	if isHighest {
 		for i, a := range arg {
 			m := compute(a.Values)
@@ -145,6 +151,27 @@ func (f *highest) Do(ctx context.Context, e parser.Expr, from, until int64, valu
 	return results, nil
 }
 
+// getCount returns number of series to select out of seriesCount. n >= 1 is the number of series as in graphite-web,
+// n between 0 and 1 is a fraction of series, rounded up to at least one series.
+func getCount(e parser.Expr, pos, seriesCount int) (int, error) {
+	v, err := e.GetFloatArg(pos)
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 || math.IsNaN(v) {
+		return 0, merry.WithMessagef(parser.ErrBadType, "%s: n must be non-negative, got %v", parser.ErrBadType, v)
+	}
+	if v > 0 && v < 1 {
+		// tolerance keeps e.g. 0.1 of 30 series at 3, despite 0.1*30 being slightly above 3 in floating point
+		n := int(math.Ceil(v*float64(seriesCount) - 1e-9))
+		if n < 1 {
+			n = 1
+		}
+		return n, nil
+	}
+	return int(v), nil
+}
+
 // Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
 func (f *highest) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
@@ -161,9 +188,9 @@ func (f *highest) Description() map[string]types.FunctionDescription {
 					Required: true,
 				},
 				{
-					Name:     "n",
-					Type:     types.Integer,
-					Required: true,
+					Name:    "n",
+					Default: types.NewSuggestion(1),
+					Type:    types.Float,
 				},
 				{
 					Name: "func",
@@ -189,9 +216,9 @@ func (f *highest) Description() map[string]types.FunctionDescription {
 					Type:     types.SeriesList,
 				},
 				{
-					Name:     "n",
-					Required: true,
-					Type:     types.Integer,
+					Name:    "n",
+					Default: types.NewSuggestion(1),
+					Type:    types.Float,
 				},
 			},
 		},
@@ -208,9 +235,9 @@ func (f *highest) Description() map[string]types.FunctionDescription {
 					Type:     types.SeriesList,
 				},
 				{
-					Name:     "n",
-					Required: true,
-					Type:     types.Integer,
+					Name:    "n",
+					Default: types.NewSuggestion(1),
+					Type:    types.Float,
 				},
 			},
 		},
@@ -227,9 +254,9 @@ func (f *highest) Description() map[string]types.FunctionDescription {
 					Type:     types.SeriesList,
 				},
 				{
-					Name:     "n",
-					Required: true,
-					Type:     types.Integer,
+					Name:    "n",
+					Default: types.NewSuggestion(1),
+					Type:    types.Float,
 				},
 			},
 		},
@@ -246,9 +273,9 @@ func (f *highest) Description() map[string]types.FunctionDescription {
 					Type:     types.SeriesList,
 				},
 				{
-					Name:     "n",
-					Required: true,
-					Type:     types.Integer,
+					Name:    "n",
+					Default: types.NewSuggestion(1),
+					Type:    types.Float,
 				},
 			},
 		},
@@ -265,9 +292,9 @@ func (f *highest) Description() map[string]types.FunctionDescription {
 					Required: true,
 				},
 				{
-					Name:     "n",
-					Type:     types.Integer,
-					Required: true,
+					Name:    "n",
+					Default: types.NewSuggestion(1),
+					Type:    types.Float,
 				},
 				{
 					Name: "func",
@@ -293,9 +320,9 @@ func (f *highest) Description() map[string]types.FunctionDescription {
 					Type:     types.SeriesList,
 				},
 				{
-					Name:     "n",
-					Required: true,
-					Type:     types.Integer,
+					Name:    "n",
+					Default: types.NewSuggestion(1),
+					Type:    types.Float,
 				},
 			},
 		},
@@ -312,9 +339,9 @@ func (f *highest) Description() map[string]types.FunctionDescription {
 					Type:     types.SeriesList,
 				},
 				{
-					Name:     "n",
-					Required: true,
-					Type:     types.Integer,
+					Name:    "n",
+					Default: types.NewSuggestion(1),
+					Type:    types.Float,
 				},
 			},
 		},
@@ -331,9 +358,9 @@ func (f *highest) Description() map[string]types.FunctionDescription {
 					Type:     types.SeriesList,
 				},
 				{
-					Name:     "n",
-					Required: true,
-					Type:     types.Integer,
+					Name:    "n",
+					Default: types.NewSuggestion(1),
+					Type:    types.Float,
 				},
 			},
 		},
@@ -350,9 +377,9 @@ func (f *highest) Description() map[string]types.FunctionDescription {
 					Type:     types.SeriesList,
 				},
 				{
-					Name:     "n",
-					Required: true,
-					Type:     types.Integer,
+					Name:    "n",
+					Default: types.NewSuggestion(1),
+					Type:    types.Float,
 				},
 			},
 		},
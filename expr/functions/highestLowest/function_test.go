@@ -2,6 +2,7 @@ package highestLowest
 
 import (
 	"math"
+	"strconv"
 	"testing"
 	"time"
 
@@ -276,3 +277,107 @@ func TestHighest(t *testing.T) {
 		})
 	}
 }
+
+func TestHighestFraction(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	// metric00 has current value of 0, metric24 - 24
+	makeSeries := func(count int) []*types.MetricData {
+		series := make([]*types.MetricData, 0, count)
+		for i := 0; i < count; i++ {
+			name := "metric" + strconv.Itoa(i/10) + strconv.Itoa(i%10)
+			series = append(series, types.MakeMetricData(name, []float64{1, float64(i)}, 1, now32))
+		}
+		return series
+	}
+	want := func(series []*types.MetricData, idx ...int) []*types.MetricData {
+		res := make([]*types.MetricData, 0, len(idx))
+		for _, i := range idx {
+			res = append(res, series[i])
+		}
+		return res
+	}
+
+	series25 := makeSeries(25)
+	series30 := makeSeries(30)
+
+	tests := []th.EvalTestItem{
+		{
+			"highestCurrent(metric1,0.1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: series25,
+			},
+			want(series25, 24, 23, 22),
+		},
+		{
+			"highestCurrent(metric1,0.1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: series30,
+			},
+			want(series30, 29, 28, 27),
+		},
+		{
+			"lowestCurrent(metric1,0.1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: series25,
+			},
+			want(series25, 0, 1, 2),
+		},
+		{
+			"highestMax(metric1,0.01)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: series25,
+			},
+			want(series25, 24),
+		},
+		{
+			"highest(metric1,0.1,'last')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: series25,
+			},
+			want(series25, 24, 23, 22),
+		},
+		{
+			"highestCurrent(metric1,0)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: series25,
+			},
+			[]*types.MetricData{},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+}
+
+func TestHighestErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "highestCurrent(metric1,-1)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+		{
+			Target: "lowest(metric1,-0.5,'max')",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
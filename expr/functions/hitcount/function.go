@@ -2,13 +2,17 @@ package hitcount
 
 import (
 	"context"
-	"fmt"
 	"math"
+	"strconv"
 
+	"github.com/ansel1/merry"
+
+	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/pkg/parser"
+	utilctx "github.com/go-graphite/carbonapi/util/ctx"
 	pb "github.com/go-graphite/protocol/carbonapi_v3_pb"
 )
 
@@ -30,10 +34,10 @@ func New(configFile string) []interfaces.FunctionMetadata {
 	return res
 }
 
-// hitcount(seriesList, intervalString, alignToInterval=False)
+// hitcount(seriesList, intervalString, alignToInterval=False, xFilesFactor=0)
 func (f *hitcount) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
 	// TODO(dgryski): make sure the arrays are all the same 'size'
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -53,21 +57,36 @@ func (f *hitcount) Do(ctx context.Context, e parser.Expr, from, until int64, val
 		ok = len(e.Args()) > 2
 	}
 
+	xFilesFactor, err := e.GetFloatNamedOrPosArgDefault("xFilesFactor", 3, 0)
+	if err != nil {
+		return nil, err
+	}
+	if xFilesFactor < 0 || xFilesFactor > 1 {
+		return nil, merry.WithMessagef(parser.ErrBadType, "%s: xFilesFactor should be between 0 and 1, got %v", parser.ErrBadType, xFilesFactor)
+	}
+	_, xffOk := e.NamedArgs()["xFilesFactor"]
+	if !xffOk {
+		xffOk = len(e.Args()) > 3
+	}
+
 	start := args[0].StartTime
 	stop := args[0].StopTime
 	if alignToInterval {
-		start = helper.AlignStartToInterval(start, stop, bucketSize)
+		start = helper.AlignStartToInterval(start, stop, bucketSize, utilctx.GetTimezone(ctx))
 	}
 
 	buckets := helper.GetBuckets(start, stop, bucketSize)
 	results := make([]*types.MetricData, 0, len(args))
 	for _, arg := range args {
 
-		name := fmt.Sprintf("hitcount(%s,'%s'", arg.Name, e.Args()[1].StringValue())
+		nameArgs := []interface{}{arg.Name, helper.Quote(e.Args()[1].StringValue())}
 		if ok {
-			name += fmt.Sprintf(",%v", alignToInterval)
+			nameArgs = append(nameArgs, alignToInterval)
 		}
-		name += ")"
+		if xffOk {
+			nameArgs = append(nameArgs, "xFilesFactor="+strconv.FormatFloat(xFilesFactor, 'g', -1, 64))
+		}
+		name := helper.FuncName("hitcount", nameArgs...)
 
 		r := types.MetricData{
 			FetchResponse: pb.FetchResponse{
@@ -86,6 +105,12 @@ func (f *hitcount) Do(ctx context.Context, e parser.Expr, from, until int64, val
 		ridx := 0
 		var count float64
 		bucketItems := 0
+		// buckets on the edges of the series are checked against the points of a full bucket
+		bucketPoints := int(bucketSize / arg.StepTime)
+		if bucketPoints < 1 {
+			bucketPoints = 1
+		}
+		nonNull := 0
 		for _, v := range arg.Values {
 			bucketItems++
 			if !math.IsNaN(v) {
@@ -94,6 +119,7 @@ func (f *hitcount) Do(ctx context.Context, e parser.Expr, from, until int64, val
 				}
 
 				count += v * float64(arg.StepTime)
+				nonNull++
 			}
 
 			t += arg.StepTime
@@ -104,17 +130,24 @@ func (f *hitcount) Do(ctx context.Context, e parser.Expr, from, until int64, val
 
 			if t >= bucketEnd {
 				r.Values[ridx] = count
+				if xFilesFactor > 0 && !consolidations.XFilesFactor(nonNull, bucketPoints, float32(xFilesFactor)) {
+					r.Values[ridx] = math.NaN()
+				}
 
 				ridx++
 				bucketEnd += bucketSize
 				count = math.NaN()
 				bucketItems = 0
+				nonNull = 0
 			}
 		}
 
 		// remaining values
 		if bucketItems > 0 {
 			r.Values[ridx] = count
+			if xFilesFactor > 0 && !consolidations.XFilesFactor(nonNull, bucketPoints, float32(xFilesFactor)) {
+				r.Values[ridx] = math.NaN()
+			}
 		}
 
 		results = append(results, &r)
@@ -126,8 +159,8 @@ func (f *hitcount) Do(ctx context.Context, e parser.Expr, from, until int64, val
 func (f *hitcount) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"hitcount": {
-			Description: "Estimate hit counts from a list of time series.\n\nThis function assumes the values in each time series represent\nhits per second.  It calculates hits per some larger interval\nsuch as per day or per hour.  This function is like summarize(),\nexcept that it compensates automatically for different time scales\n(so that a similar graph results from using either fine-grained\nor coarse-grained records) and handles rarely-occurring events\ngracefully.",
-			Function:    "hitcount(seriesList, intervalString, alignToInterval=False)",
+			Description: "Estimate hit counts from a list of time series.\n\nThis function assumes the values in each time series represent\nhits per second.  It calculates hits per some larger interval\nsuch as per day or per hour.  This function is like summarize(),\nexcept that it compensates automatically for different time scales\n(so that a similar graph results from using either fine-grained\nor coarse-grained records) and handles rarely-occurring events\ngracefully.\n\nBuckets with a ratio of non-null points to the points of a full bucket below xFilesFactor are\nset to None (carbonapi only).",
+			Function:    "hitcount(seriesList, intervalString, alignToInterval=False, xFilesFactor=0)",
 			Group:       "Transform",
 			Module:      "graphite.render.functions",
 			Name:        "hitcount",
@@ -152,6 +185,11 @@ func (f *hitcount) Description() map[string]types.FunctionDescription {
 					Name:    "alignToInterval",
 					Type:    types.Boolean,
 				},
+				{
+					Default: types.NewSuggestion(0),
+					Name:    "xFilesFactor",
+					Type:    types.Float,
+				},
 			},
 		},
 	}
@@ -43,6 +43,23 @@ func TestHitcount(t *testing.T) {
 			now32,
 			now32 + 31*5,
 		},
+		{
+			"hitcount(metric1,\"30s\",xFilesFactor=0.5)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{
+					1, 1, 1, 1, 1, 2,
+					2, 2, 2, 2, 3, 3,
+					3, 3, 3, 4, 4, 4,
+					4, 4, 5, 5, 5, 5,
+					math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(),
+					5}, 5, now32)},
+			},
+			[]float64{35, 70, 105, 140, math.NaN(), math.NaN()},
+			"hitcount(metric1,'30s',xFilesFactor=0.5)",
+			30,
+			now32,
+			now32 + 31*5,
+		},
 		{
 			"hitcount(metric1,\"1h\")",
 			map[parser.MetricRequest][]*types.MetricData{
@@ -2,9 +2,10 @@ package holtWintersAberration
 
 import (
 	"context"
-	"fmt"
 	"math"
 
+	"github.com/ansel1/merry"
+
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/holtwinters"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
@@ -32,12 +33,12 @@ func New(configFile string) []interfaces.FunctionMetadata {
 }
 
 func (f *holtWintersAberration) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	bootstrapInterval, err := e.GetIntervalNamedOrPosArgDefault("bootstrapInterval", 2, 1, 7*86400)
+	bootstrapInterval, seasonality, err := holtwinters.GetBootstrapIntervalAndSeasonality(e, 2)
 	if err != nil {
 		return nil, err
 	}
 
-	args, err := helper.GetSeriesArg(e.Args()[0], from-bootstrapInterval, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from-bootstrapInterval, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +50,9 @@ func (f *holtWintersAberration) Do(ctx context.Context, e parser.Expr, from, unt
 
 	results := make([]*types.MetricData, 0, len(args))
 	for _, arg := range args {
+		if err := ctx.Err(); err != nil {
+			return nil, merry.Wrap(err)
+		}
 		var (
 			aberration []float64
 			series []float64
@@ -56,7 +60,7 @@ func (f *holtWintersAberration) Do(ctx context.Context, e parser.Expr, from, unt
 
 		stepTime := arg.StepTime
 
-		lowerBand, upperBand := holtwinters.HoltWintersConfidenceBands(arg.Values, stepTime, delta, bootstrapInterval/86400)
+		lowerBand, upperBand := holtwinters.HoltWintersConfidenceBands(arg.Values, stepTime, delta, bootstrapInterval, seasonality)
 
 		windowPoints := int(bootstrapInterval / stepTime)
 		if len(arg.Values) > windowPoints {
@@ -77,12 +81,12 @@ func (f *holtWintersAberration) Do(ctx context.Context, e parser.Expr, from, unt
 
 		r := types.MetricData{
 			FetchResponse: pb.FetchResponse{
-				Name:              fmt.Sprintf("holtWintersAberration(%s)", arg.Name),
+				Name:              helper.FuncName("holtWintersAberration", arg.Name),
 				Values:            aberration,
 				StepTime:          arg.StepTime,
 				StartTime:         arg.StartTime + bootstrapInterval,
 				StopTime:          arg.StopTime,
-				PathExpression:    fmt.Sprintf("holtWintersAberration(%s)", arg.Name),
+				PathExpression:    helper.FuncName("holtWintersAberration", arg.Name),
 				ConsolidationFunc: arg.ConsolidationFunc,
 				XFilesFactor:      arg.XFilesFactor,
 			},
@@ -99,7 +103,7 @@ func (f *holtWintersAberration) Description() map[string]types.FunctionDescripti
 	return map[string]types.FunctionDescription{
 		"holtWintersAberration": {
 			Description: "Performs a Holt-Winters forecast using the series as input data and plots the\npositive or negative deviation of the series data from the forecast.",
-			Function:    "holtWintersAberration(seriesList, delta=3, bootstrapInterval='7d')",
+			Function:    "holtWintersAberration(seriesList, delta=3, bootstrapInterval='7d', seasonality='1d')",
 			Group:       "Calculate",
 			Module:      "graphite.render.functions",
 			Name:        "holtWintersAberration",
@@ -123,6 +127,15 @@ func (f *holtWintersAberration) Description() map[string]types.FunctionDescripti
 					),
 					Type: types.Interval,
 				},
+				{
+					Default: types.NewSuggestion("1d"),
+					Name:    "seasonality",
+					Suggestions: types.NewSuggestions(
+						"1d",
+						"7d",
+					),
+					Type: types.Interval,
+				},
 			},
 		},
 	}
@@ -2,7 +2,8 @@ package holtWintersConfidenceBands
 
 import (
 	"context"
-	"fmt"
+
+	"github.com/ansel1/merry"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/holtwinters"
@@ -31,12 +32,12 @@ func New(configFile string) []interfaces.FunctionMetadata {
 }
 
 func (f *holtWintersConfidenceBands) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	bootstrapInterval, err := e.GetIntervalNamedOrPosArgDefault("bootstrapInterval", 2, 1, 7*86400)
+	bootstrapInterval, seasonality, err := holtwinters.GetBootstrapIntervalAndSeasonality(e, 2)
 	if err != nil {
 		return nil, err
 	}
 
-	args, err := helper.GetSeriesArg(e.Args()[0], from-bootstrapInterval, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from-bootstrapInterval, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -48,34 +49,37 @@ func (f *holtWintersConfidenceBands) Do(ctx context.Context, e parser.Expr, from
 
 	results := make([]*types.MetricData, 0, len(args)*2)
 	for _, arg := range args {
+		if err := ctx.Err(); err != nil {
+			return nil, merry.Wrap(err)
+		}
 		stepTime := arg.StepTime
 
-		lowerBand, upperBand := holtwinters.HoltWintersConfidenceBands(arg.Values, stepTime, delta, bootstrapInterval/86400)
+		lowerBand, upperBand := holtwinters.HoltWintersConfidenceBands(arg.Values, stepTime, delta, bootstrapInterval, seasonality)
 
 		lowerSeries := types.MetricData{
 			FetchResponse: pb.FetchResponse{
-				Name:              fmt.Sprintf("holtWintersConfidenceLower(%s)", arg.Name),
+				Name:              helper.FuncName("holtWintersConfidenceLower", arg.Name),
 				Values:            lowerBand,
 				StepTime:          arg.StepTime,
 				StartTime:         arg.StartTime + bootstrapInterval,
 				StopTime:          arg.StopTime,
 				ConsolidationFunc: arg.ConsolidationFunc,
 				XFilesFactor:      arg.XFilesFactor,
-				PathExpression:    fmt.Sprintf("holtWintersConfidenceLower(%s)", arg.Name),
+				PathExpression:    helper.FuncName("holtWintersConfidenceLower", arg.Name),
 			},
 			Tags: arg.Tags,
 		}
 
 		upperSeries := types.MetricData{
 			FetchResponse: pb.FetchResponse{
-				Name:              fmt.Sprintf("holtWintersConfidenceUpper(%s)", arg.Name),
+				Name:              helper.FuncName("holtWintersConfidenceUpper", arg.Name),
 				Values:            upperBand,
 				StepTime:          arg.StepTime,
 				StartTime:         arg.StartTime + bootstrapInterval,
 				StopTime:          arg.StopTime,
 				ConsolidationFunc: arg.ConsolidationFunc,
 				XFilesFactor:      arg.XFilesFactor,
-				PathExpression:    fmt.Sprintf("holtWintersConfidenceLower(%s)", arg.Name),
+				PathExpression:    helper.FuncName("holtWintersConfidenceUpper", arg.Name),
 			},
 			Tags: arg.Tags,
 		}
@@ -90,7 +94,7 @@ func (f *holtWintersConfidenceBands) Description() map[string]types.FunctionDesc
 	return map[string]types.FunctionDescription{
 		"holtWintersConfidenceBands": {
 			Description: "Performs a Holt-Winters forecast using the series as input data and plots\nupper and lower bands with the predicted forecast deviations.",
-			Function:    "holtWintersConfidenceBands(seriesList, delta=3, bootstrapInterval='7d')",
+			Function:    "holtWintersConfidenceBands(seriesList, delta=3, bootstrapInterval='7d', seasonality='1d')",
 			Group:       "Calculate",
 			Module:      "graphite.render.functions",
 			Name:        "holtWintersConfidenceBands",
@@ -114,6 +118,15 @@ func (f *holtWintersConfidenceBands) Description() map[string]types.FunctionDesc
 					),
 					Type: types.Interval,
 				},
+				{
+					Default: types.NewSuggestion("1d"),
+					Name:    "seasonality",
+					Suggestions: types.NewSuggestions(
+						"1d",
+						"7d",
+					),
+					Type: types.Interval,
+				},
 			},
 		},
 	}
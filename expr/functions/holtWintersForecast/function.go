@@ -2,7 +2,8 @@ package holtWintersForecast
 
 import (
 	"context"
-	"fmt"
+
+	"github.com/ansel1/merry"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/holtwinters"
@@ -31,12 +32,12 @@ func New(configFile string) []interfaces.FunctionMetadata {
 }
 
 func (f *holtWintersForecast) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	bootstrapInterval, err := e.GetIntervalNamedOrPosArgDefault("bootstrapInterval", 2, 1, 7*86400)
+	bootstrapInterval, seasonality, err := holtwinters.GetBootstrapIntervalAndSeasonality(e, 1)
 	if err != nil {
 		return nil, err
 	}
 
-	args, err := helper.GetSeriesArgsAndRemoveNonExisting(e, from-bootstrapInterval, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from-bootstrapInterval, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -44,9 +45,12 @@ func (f *holtWintersForecast) Do(ctx context.Context, e parser.Expr, from, until
 	var predictionsOfInterest []float64
 	results := make([]*types.MetricData, 0, len(args))
 	for _, arg := range args {
+		if err := ctx.Err(); err != nil {
+			return nil, merry.Wrap(err)
+		}
 		stepTime := arg.StepTime
 
-		predictions, _ := holtwinters.HoltWintersAnalysis(arg.Values, stepTime)
+		predictions, _ := holtwinters.HoltWintersAnalysis(arg.Values, stepTime, seasonality)
 
 		windowPoints := int(bootstrapInterval / stepTime)
 		if len(predictions) < windowPoints {
@@ -57,12 +61,12 @@ func (f *holtWintersForecast) Do(ctx context.Context, e parser.Expr, from, until
 
 		r := types.MetricData{
 			FetchResponse: pb.FetchResponse{
-				Name:              fmt.Sprintf("holtWintersForecast(%s)", arg.Name),
+				Name:              helper.FuncName("holtWintersForecast", arg.Name),
 				Values:            predictionsOfInterest,
 				StepTime:          arg.StepTime,
 				StartTime:         arg.StartTime + bootstrapInterval,
 				StopTime:          arg.StopTime,
-				PathExpression:    fmt.Sprintf("holtWintersForecast(%s)", arg.Name),
+				PathExpression:    helper.FuncName("holtWintersForecast", arg.Name),
 				XFilesFactor:      arg.XFilesFactor,
 				ConsolidationFunc: arg.ConsolidationFunc,
 			},
@@ -77,8 +81,8 @@ func (f *holtWintersForecast) Do(ctx context.Context, e parser.Expr, from, until
 func (f *holtWintersForecast) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"holtWintersForecast": {
-			Description: "Performs a Holt-Winters forecast using the series as input data. Data from\n`bootstrapInterval` (one week by default) previous to the series is used to bootstrap the initial forecast.",
-			Function:    "holtWintersForecast(seriesList, bootstrapInterval='7d')",
+			Description: "Performs a Holt-Winters forecast using the series as input data. Data from\n`bootstrapInterval` (one week by default) previous to the series is used to bootstrap the initial forecast. The `seasonality` parameter\nspecifies the length of the season, e.g. 1d for daily cycles or 7d for weekly ones, and `bootstrapInterval`\nmust be a multiple of it.",
+			Function:    "holtWintersForecast(seriesList, bootstrapInterval='7d', seasonality='1d')",
 			Group:       "Calculate",
 			Module:      "graphite.render.functions",
 			Name:        "holtWintersForecast",
@@ -97,6 +101,15 @@ func (f *holtWintersForecast) Description() map[string]types.FunctionDescription
 					),
 					Type: types.Interval,
 				},
+				{
+					Default: types.NewSuggestion("1d"),
+					Name:    "seasonality",
+					Suggestions: types.NewSuggestions(
+						"1d",
+						"7d",
+					),
+					Type: types.Interval,
+				},
 			},
 		},
 	}
@@ -0,0 +1,79 @@
+package holtWintersForecast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestHoltWintersForecast(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItem{
+		{
+			// forecast of a constant series is the same constant whatever the season is
+			"holtWintersForecast(metric1,'4s','2s')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", -4, 1}: {types.MakeMetricData("metric1", []float64{5, 5, 5, 5, 5, 5, 5}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("holtWintersForecast(metric1)", []float64{5, 5, 5}, 1, now32+4)},
+		},
+		{
+			"holtWintersForecast(metric1,bootstrapInterval='4s',seasonality='1s')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", -4, 1}: {types.MakeMetricData("metric1", []float64{5, 5, 5, 5, 5, 5}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("holtWintersForecast(metric1)", []float64{5, 5}, 1, now32+4)},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+}
+
+func TestHoltWintersForecastErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "holtWintersForecast(metric1,'3s','2s')",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", -3, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 4}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+		{
+			Target: "holtWintersForecast(metric1,'7d',seasonality='-1d')",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", -7 * 86400, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 4}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
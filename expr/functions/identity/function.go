@@ -0,0 +1,123 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ansel1/merry"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	pb "github.com/go-graphite/protocol/carbonapi_v3_pb"
+)
+
+type identity struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &identity{}
+	functions := []string{"identity"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// identity(name, step=60), identity(seriesList)
+func (f *identity) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	if len(e.Args()) == 0 {
+		return nil, parser.ErrMissingArgument
+	}
+
+	// graphite-web form: identity("name") returns timestamp as a value for each point
+	if e.Args()[0].IsString() {
+		return f.timeSeries(e, from, until)
+	}
+
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*types.MetricData, 0, len(args))
+	for _, a := range args {
+		r := *a
+		r.Name = helper.FuncName("identity", a.Name)
+		r.Values = make([]float64, len(a.Values))
+		copy(r.Values, a.Values)
+		results = append(results, &r)
+	}
+
+	return results, nil
+}
+
+func (f *identity) timeSeries(e parser.Expr, from, until int64) ([]*types.MetricData, error) {
+	name, err := e.GetStringArg(0)
+	if err != nil {
+		return nil, err
+	}
+
+	stepInt, err := e.GetIntNamedOrPosArgDefault("step", 1, 60)
+	if err != nil {
+		return nil, err
+	}
+	if stepInt <= 0 {
+		return nil, merry.WithMessagef(parser.ErrBadType, "%s: step must be positive, got %d", parser.ErrBadType, stepInt)
+	}
+	step := int64(stepInt)
+
+	newValues := make([]float64, (until-from-1+step)/step)
+	value := from
+	for i := 0; i < len(newValues); i++ {
+		newValues[i] = float64(value)
+		value += step
+	}
+
+	p := types.MetricData{
+		FetchResponse: pb.FetchResponse{
+			Name:              name,
+			PathExpression:    fmt.Sprintf("identity(%q)", name),
+			StartTime:         from,
+			StopTime:          until,
+			StepTime:          step,
+			Values:            newValues,
+			ConsolidationFunc: "average",
+		},
+		Tags: map[string]string{"name": name},
+	}
+
+	return []*types.MetricData{&p}, nil
+}
+
+// Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
+func (f *identity) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"identity": {
+			Description: "Identity function:\nReturns datapoints where the value equals the timestamp of the datapoint.\nUseful when you have another series where the value is a timestamp, and\nyou want to compare it to the time of the datapoint, to render an age\n\nExample:\n\n.. code-block:: none\n\n  &target=identity(\"The.time.series\")\n\nThis would create a series named \"The.time.series\" that contains points where\nx(t) == t.\n\nIf seriesList is passed instead of a name, it is returned as-is, with series renamed to identity(name).\nAccepts optional second argument as 'step' parameter (default step is 60 sec)",
+			Function:    "identity(name, step=60)",
+			Group:       "Calculate",
+			Module:      "graphite.render.functions",
+			Name:        "identity",
+			Params: []types.FunctionParam{
+				{
+					Name:     "name",
+					Required: true,
+					Type:     types.String,
+				},
+				{
+					Default: types.NewSuggestion(60),
+					Name:    "step",
+					Type:    types.Integer,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,105 @@
+package identity
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestFunction(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItem{
+		{
+			"identity(metric1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), 3}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("identity(metric1)", []float64{1, math.NaN(), 3}, 1, now32)},
+		},
+		{
+			"identity(identity(metric*))",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32),
+					types.MakeMetricData("metric2", []float64{4, 5, 6}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("identity(identity(metric1))", []float64{1, 2, 3}, 1, now32),
+				types.MakeMetricData("identity(identity(metric2))", []float64{4, 5, 6}, 1, now32),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+
+}
+
+func TestFunctionName(t *testing.T) {
+	tests := []th.EvalTestItem{
+		{
+			`identity("the.time.series")`,
+			map[parser.MetricRequest][]*types.MetricData{},
+			[]*types.MetricData{types.MakeMetricData("the.time.series", []float64{0, 60, 120}, 60, 0)},
+		},
+		{
+			`identity("the.time.series", 90)`,
+			map[parser.MetricRequest][]*types.MetricData{},
+			[]*types.MetricData{types.MakeMetricData("the.time.series", []float64{0, 90}, 90, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			err := th.TestEvalExprModifiedOrigin(t, &tt, 0, 180, false)
+			if err != nil {
+				t.Errorf("unexpected error while evaluating %s: got `%+v`", tt.Target, err)
+			}
+		})
+	}
+}
+
+func TestFunctionErrors(t *testing.T) {
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: `identity("the.time.series", 0)`,
+			M:      map[parser.MetricRequest][]*types.MetricData{},
+			Error:  parser.ErrBadType,
+		},
+		{
+			Target: `identity("the.time.series", -60)`,
+			M:      map[parser.MetricRequest][]*types.MetricData{},
+			Error:  parser.ErrBadType,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
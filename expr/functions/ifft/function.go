@@ -2,7 +2,6 @@ package ifft
 
 import (
 	"context"
-	"fmt"
 	"math"
 	"math/cmplx"
 
@@ -33,14 +32,14 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // ifft(absSeriesList, phaseSeriesList)
 func (f *ifft) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	absSeriesList, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	absSeriesList, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
 
 	var phaseSeriesList []*types.MetricData
 	if len(e.Args()) > 1 {
-		phaseSeriesList, err = helper.GetSeriesArg(e.Args()[1], from, until, values)
+		phaseSeriesList, err = helper.GetSeriesArg(ctx, e.Args()[1], from, until, values)
 		if err != nil {
 			return nil, err
 		}
@@ -52,7 +51,7 @@ func (f *ifft) Do(ctx context.Context, e parser.Expr, from, until int64, values
 		r.Values = make([]float64, len(a.Values))
 		if len(phaseSeriesList) > j {
 			p := phaseSeriesList[j]
-			name := fmt.Sprintf("ifft(%s, %s)", a.Name, p.Name)
+			name := helper.FuncName("ifft", a.Name, p.Name)
 			r.Name = name
 			values := make([]complex128, len(a.Values))
 			for i, v := range a.Values {
@@ -68,7 +67,7 @@ func (f *ifft) Do(ctx context.Context, e parser.Expr, from, until int64, values
 				r.Values[i] = cmplx.Abs(v)
 			}
 		} else {
-			name := fmt.Sprintf("ifft(%s)", a.Name)
+			name := helper.FuncName("ifft", a.Name)
 			r.Name = name
 			values := realFFT.IFFTReal(a.Values)
 			for i, v := range values {
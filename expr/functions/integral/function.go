@@ -28,13 +28,21 @@ func New(configFile string) []interfaces.FunctionMetadata {
 	return res
 }
 
-// integral(seriesList)
+// integral(seriesList, resetOnGap=False)
 func (f *integral) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	return helper.ForEachSeriesDo(e, from, until, values, func(a *types.MetricData, r *types.MetricData) *types.MetricData {
+	resetOnGap, err := e.GetBoolNamedOrPosArgDefault("resetOnGap", 1, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return helper.ForEachSeriesDo(ctx, e, from, until, values, func(a *types.MetricData, r *types.MetricData) *types.MetricData {
 		current := 0.0
 		for i, v := range a.Values {
 			if math.IsNaN(v) {
 				r.Values[i] = math.NaN()
+				if resetOnGap {
+					current = 0
+				}
 				continue
 			}
 			current += v
@@ -48,8 +56,8 @@ func (f *integral) Do(ctx context.Context, e parser.Expr, from, until int64, val
 func (f *integral) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"integral": {
-			Description: "This will show the sum over time, sort of like a continuous addition function.\nUseful for finding totals or trends in metrics that are collected per minute.\n\nExample:\n\n.. code-block:: none\n\n  &target=integral(company.sales.perMinute)\n\nThis would start at zero on the left side of the graph, adding the sales each\nminute, and show the total sales for the time period selected at the right\nside, (time now, or the time specified by '&until=').",
-			Function:    "integral(seriesList)",
+			Description: "This will show the sum over time, sort of like a continuous addition function.\nUseful for finding totals or trends in metrics that are collected per minute.\n\nExample:\n\n.. code-block:: none\n\n  &target=integral(company.sales.perMinute)\n\nThis would start at zero on the left side of the graph, adding the sales each\nminute, and show the total sales for the time period selected at the right\nside, (time now, or the time specified by '&until=').\n\nAbsent values are drawn as None and skipped, the sum continues after them. With resetOnGap=true\nthe sum starts from zero again after every gap:\n\n.. code-block:: none\n\n  &target=integral(company.sales.perMinute,resetOnGap=true)",
+			Function:    "integral(seriesList, resetOnGap=False)",
 			Group:       "Transform",
 			Module:      "graphite.render.functions",
 			Name:        "integral",
@@ -59,6 +67,11 @@ func (f *integral) Description() map[string]types.FunctionDescription {
 					Required: true,
 					Type:     types.SeriesList,
 				},
+				{
+					Default: types.NewSuggestion(false),
+					Name:    "resetOnGap",
+					Type:    types.Boolean,
+				},
 			},
 		},
 	}
@@ -34,6 +34,31 @@ func TestFunction(t *testing.T) {
 			[]*types.MetricData{types.MakeMetricData("integral(metric1)",
 				[]float64{1, 1, 3, 6, 10, 15, math.NaN(), 22, 30}, 1, now32)},
 		},
+		{
+			// the sum is carried over a gap by default
+			"integral(metric1,false)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, math.NaN(), math.NaN(), 3, 4}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("integral(metric1)",
+				[]float64{1, 3, math.NaN(), math.NaN(), 6, 10}, 1, now32)},
+		},
+		{
+			"integral(metric1,resetOnGap=true)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, math.NaN(), math.NaN(), 3, 4}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("integral(metric1)",
+				[]float64{1, 3, math.NaN(), math.NaN(), 3, 7}, 1, now32)},
+		},
+		{
+			"integral(metric1,true)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{math.NaN(), 5, 5, math.NaN(), 1, math.NaN()}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("integral(metric1)",
+				[]float64{math.NaN(), 5, 10, math.NaN(), 1, math.NaN()}, 1, now32)},
+		},
 	}
 
 	for _, tt := range tests {
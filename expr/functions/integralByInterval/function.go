@@ -2,13 +2,13 @@ package integralByInterval
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/pkg/parser"
+	utilctx "github.com/go-graphite/carbonapi/util/ctx"
 	pb "github.com/go-graphite/protocol/carbonapi_v3_pb"
 )
 
@@ -32,7 +32,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // integralByInterval(seriesList, intervalString)
 func (f *integralByInterval) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -46,13 +46,14 @@ func (f *integralByInterval) Do(ctx context.Context, e parser.Expr, from, until
 	}
 	bucketSize := int64(bucketSizeInt32)
 
-	startTime := from
+	// buckets start at the boundaries in the request timezone, e.x. at the local midnight for 1d
+	startTime := helper.TruncateInLocation(from, bucketSize, utilctx.GetTimezone(ctx))
 	results := make([]*types.MetricData, 0, len(args))
 	for _, arg := range args {
 		current := 0.0
 		currentTime := arg.StartTime
 
-		name := fmt.Sprintf("integralByInterval(%s,'%s')", arg.Name, e.Args()[1].StringValue())
+		name := helper.FuncName("integralByInterval", arg.Name, helper.Quote(e.Args()[1].StringValue()))
 		result := types.MetricData{
 			FetchResponse: pb.FetchResponse{
 				Name:              name,
@@ -67,13 +68,12 @@ func (f *integralByInterval) Do(ctx context.Context, e parser.Expr, from, until
 			Tags: arg.Tags,
 		}
 		for i, v := range arg.Values {
-			if (currentTime-startTime)/bucketSize != (currentTime-startTime-arg.StepTime)/bucketSize {
+			if bucketIndex(currentTime-startTime, bucketSize) != bucketIndex(currentTime-startTime-arg.StepTime, bucketSize) {
 				current = 0
 			}
-			if math.IsNaN(v) {
-				v = 0
+			if !math.IsNaN(v) {
+				current += v
 			}
-			current += v
 			result.Values[i] = current
 			currentTime += arg.StepTime
 		}
@@ -84,11 +84,21 @@ func (f *integralByInterval) Do(ctx context.Context, e parser.Expr, from, until
 	return results, nil
 }
 
+// bucketIndex returns the number of the interval t belongs to. It rounds towards negative infinity,
+// as python's floor division does, so series that start before `from` are split at the same boundaries.
+func bucketIndex(t, bucketSize int64) int64 {
+	idx := t / bucketSize
+	if t%bucketSize != 0 && t < 0 {
+		idx--
+	}
+	return idx
+}
+
 // Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
 func (f *integralByInterval) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"integralByInterval": {
-			Description: "This will do the same as integralByInterval() funcion, except resetting the total to 0 at the given time in the parameter “from” Useful for finding totals per hour/day/week/..",
+			Description: "This will do the same as integral() function, except resetting the total to 0\nat the given time in the parameter “from”\nUseful for finding totals per hour/day/week/..\n\nExample:\n\n.. code-block:: none\n\n  &target=integralByInterval(company.sales.perMinute, \"1d\")&from=midnight-10days\n\nThis would start at zero on the left side of the graph, adding the sales each\nminute, and show the evolution of sales per day during the last 10 days.",
 			Function:    "integralByInterval(seriesList, intervalString)",
 			Group:       "Transform",
 			Module:      "graphite.render.functions",
@@ -1,13 +1,17 @@
 package integralByInterval
 
 import (
+	"context"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/metadata"
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/pkg/parser"
 	th "github.com/go-graphite/carbonapi/tests"
+	utilctx "github.com/go-graphite/carbonapi/util/ctx"
 )
 
 func init() {
@@ -34,6 +38,31 @@ func TestFunction(t *testing.T) {
 				[]float64{1, 1, 3, 6, 10, 5, 5, 12, 20, 29, 10}, 2, 0),
 			},
 		},
+		{
+			"integralByInterval(10s,'10s')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"10s", 0, 1}: {
+					types.MakeMetricData("10s", []float64{1, math.NaN(), 2, 3, math.NaN(), 5, 6}, 2, 0),
+				},
+			},
+			[]*types.MetricData{types.MakeMetricData(
+				"integralByInterval(10s,'10s')",
+				[]float64{1, 1, 3, 6, 6, 5, 11}, 2, 0),
+			},
+		},
+		{
+			// series starts before `from`, so it is reset at from as well
+			"integralByInterval(10s,'10s')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"10s", 0, 1}: {
+					types.MakeMetricData("10s", []float64{1, 2, 3, 4, 5, 6, 7, 8}, 2, -4),
+				},
+			},
+			[]*types.MetricData{types.MakeMetricData(
+				"integralByInterval(10s,'10s')",
+				[]float64{1, 3, 3, 7, 12, 18, 25, 8}, 2, -4),
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -44,3 +73,49 @@ func TestFunction(t *testing.T) {
 	}
 
 }
+
+func TestIntegralByIntervalTimezone(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*3600)
+
+	// 6-hourly points for two days starting at the local midnight, requested from 01:00
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, loc).Unix()
+	from, until := start+3600, start+2*86400
+	values := make([]float64, 8)
+	for i := range values {
+		values[i] = 1
+	}
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric1", from, until}: {types.MakeMetricData("metric1", values, 6*3600, start)},
+	}
+
+	exp, _, err := parser.ParseExpr(`integralByInterval(metric1,'1d')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := New("")[0].F
+	tests := []struct {
+		name string
+		loc  *time.Location
+		want []float64
+	}{
+		{"UTC+3", loc, []float64{1, 2, 3, 4, 1, 2, 3, 4}},
+		// local midnight is 21:00 UTC, so UTC days end after the first point of each local day
+		{"UTC", time.UTC, []float64{1, 1, 2, 3, 4, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := utilctx.SetTimezone(context.Background(), tt.loc)
+			res, err := f.Do(ctx, exp, from, until, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res) != 1 {
+				t.Fatalf("unexpected number of results: %d", len(res))
+			}
+			if !th.NearlyEqual(res[0].Values, tt.want) {
+				t.Errorf("values: got %v, want %v", res[0].Values, tt.want)
+			}
+		})
+	}
+}
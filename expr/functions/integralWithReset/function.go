@@ -2,7 +2,6 @@ package integralWithReset
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/ansel1/merry"
@@ -33,11 +32,11 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // integralWithReset(seriesList, resettingSeries)
 func (f *integralWithReset) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
-	resettingSeriesList, err := helper.GetSeriesArg(e.Args()[1], from, until, values)
+	resettingSeriesList, err := helper.GetSeriesArg(ctx, e.Args()[1], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -55,7 +54,7 @@ func (f *integralWithReset) Do(ctx context.Context, e parser.Expr, from, until i
 	results := make([]*types.MetricData, 0, len(arg))
 	for _, a := range arg {
 		r := *a
-		r.Name = fmt.Sprintf("integralWithReset(%s,%s)", a.Name, resettingSeries.Name)
+		r.Name = helper.FuncName("integralWithReset", a.Name, resettingSeries.Name)
 		r.Values = make([]float64, len(a.Values))
 
 		current := 0.0
@@ -2,7 +2,6 @@ package interpolate
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -27,7 +26,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 }
 
 func (f *interpolate) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) (resultData []*types.MetricData, resultError error) {
-	seriesList, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	seriesList, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +40,7 @@ func (f *interpolate) Do(ctx context.Context, e parser.Expr, from, until int64,
 	for _, series := range seriesList {
 		pointsQty := len(series.Values)
 		resultSeries := *series
-		resultSeries.Name = fmt.Sprintf("interpolate(%s)", series.Name)
+		resultSeries.Name = helper.FuncName("interpolate", series.Name)
 
 		resultSeries.Values = make([]float64, pointsQty)
 		copy(resultSeries.Values, series.Values)
@@ -96,7 +95,8 @@ func (f *interpolate) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"interpolate": {
 			Description: "Takes one metric or a wildcard seriesList, and optionally a limit to the number of 'None' values to skip over." +
-				"\nContinues the line with the last received value when gaps ('None' values) appear in your data, rather than breaking your line." +
+				"\nFills gaps ('None' values) in your data by linear interpolation between the surrounding values, rather than breaking your line." +
+				"\nLeading and trailing gaps are left as is, as well as gaps longer than limit." +
 				"\n\n.. code-block:: none\n\n  &target=interpolate(Server01.connections.handled)\n  &target=interpolate(Server01.connections.handled, 10)",
 			Function: "interpolate(seriesList, limit)",
 			Group:    "Transform",
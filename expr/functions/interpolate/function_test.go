@@ -128,6 +128,31 @@ func TestInterpolate_Do(t *testing.T) {
 				),
 			},
 		},
+		{
+			"interpolate(x1.y1.z1, 3)",
+			map[parser.MetricRequest][]*types.MetricData{
+				parser.MetricRequest{
+					Metric: "x1.y1.z1",
+					From:   0,
+					Until:  1,
+				}: {
+					types.MakeMetricData(
+						"x1.y1.z1",
+						[]float64{2, nan, 6, nan, nan, nan, 2, nan, nan},
+						1,
+						now32,
+					),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData(
+					"interpolate(x1.y1.z1)",
+					[]float64{2, 4, 6, 5, 4, 3, 2, nan, nan}, // there are no values after the last gap
+					1,
+					now32,
+				),
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -2,7 +2,6 @@ package keepLastValue
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -31,7 +30,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // keepLastValue(seriesList, limit=inf)
 func (f *keepLastValue) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -50,9 +49,9 @@ func (f *keepLastValue) Do(ctx context.Context, e parser.Expr, from, until int64
 	for _, a := range arg {
 		var name string
 		if ok {
-			name = fmt.Sprintf("keepLastValue(%s,%d)", a.Name, keep)
+			name = helper.FuncName("keepLastValue", a.Name, keep)
 		} else {
-			name = fmt.Sprintf("keepLastValue(%s)", a.Name)
+			name = helper.FuncName("keepLastValue", a.Name)
 		}
 
 		r := *a
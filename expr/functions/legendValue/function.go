@@ -31,7 +31,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // legendValue(seriesList, newName)
 func (f *legendValue) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -46,13 +46,27 @@ func (f *legendValue) Do(ctx context.Context, e parser.Expr, from, until int64,
 		methods[i-1] = method
 	}
 
+	// the last argument can be unit system, that is used to format values
+	system := ""
+	if len(methods) > 0 {
+		last := methods[len(methods)-1]
+		if helper.ValidUnitSystem(last) {
+			system = last
+			methods = methods[:len(methods)-1]
+		}
+	}
+
 	var results []*types.MetricData
 
 	for _, a := range arg {
 		r := *a
 		for _, method := range methods {
 			summary := consolidations.SummarizeValues(method, a.Values, a.XFilesFactor)
-			r.Name = fmt.Sprintf("%s (%s: %f)", r.Name, method, summary)
+			if system == "" {
+				r.Name = fmt.Sprintf("%s (%s: %f)", r.Name, method, summary)
+			} else {
+				r.Name = fmt.Sprintf("%-20s%-5s%-10s", r.Name, method, helper.FormatUnits(summary, system))
+			}
 		}
 
 		results = append(results, &r)
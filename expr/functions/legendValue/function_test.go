@@ -57,6 +57,30 @@ func TestFunction(t *testing.T) {
 			[]*types.MetricData{types.MakeMetricData("metric1 (sum: 15.000000) (avg: 3.000000)",
 				[]float64{1, 2, 3, 4, 5}, 1, now32)},
 		},
+		{
+			"legendValue(metric1,\"avg\",\"si\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1000, 2000, 3000}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("metric1             avg  2.00K     ",
+				[]float64{1000, 2000, 3000}, 1, now32)},
+		},
+		{
+			"legendValue(metric1,\"max\",\"last\",\"binary\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{2097152, 512}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("metric1             max  2.00Mi    last 512.00    ",
+				[]float64{2097152, 512}, 1, now32)},
+		},
+		{
+			"legendValue(metric1,\"unknown\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("metric1 (unknown: NaN)",
+				[]float64{1, 2, 3, 4, 5}, 1, now32)},
+		},
 	}
 
 	for _, tt := range tests {
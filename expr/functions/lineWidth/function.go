@@ -0,0 +1,82 @@
+package lineWidth
+
+import (
+	"context"
+
+	"github.com/ansel1/merry"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type lineWidth struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &lineWidth{}
+	functions := []string{"lineWidth"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// lineWidth(seriesList, width)
+func (f *lineWidth) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	width, err := e.GetFloatArg(1)
+	if err != nil {
+		return nil, err
+	}
+	if !(width >= 0) {
+		return nil, merry.WithMessagef(parser.ErrBadType, "width can't be negative, got %g", width)
+	}
+
+	results := make([]*types.MetricData, 0, len(args))
+	for _, a := range args {
+		r := a.Copy(true)
+		r.Name = helper.FuncName(e.Target(), a.Name, width)
+		r.LineWidth = width
+		r.HasLineWidth = true
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
+func (f *lineWidth) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"lineWidth": {
+			Name: "lineWidth",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+				{
+					Name:     "width",
+					Required: true,
+					Type:     types.Float,
+				},
+			},
+			Module:      "graphite.render.functions",
+			Description: "Takes one metric or a wildcard seriesList, followed by a float F.\n\nDraw the selected metrics with a line width of F, overriding the default\nvalue of 1, or the &lineWidth=X.X parameter.\n\nUseful for highlighting a single metric out of many, or having multiple\nline widths in one graph.\n\nExample:\n\n.. code-block:: none\n\n  &target=lineWidth(server01.instance01.memory.free,5)",
+			Function:    "lineWidth(seriesList, width)",
+			Group:       "Graph",
+		},
+	}
+}
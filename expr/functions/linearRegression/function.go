@@ -2,7 +2,6 @@ package linearRegression
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/go-graphite/carbonapi/expr/consolidations"
@@ -33,7 +32,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // linearRegression(seriesList, startSourceAt=None, endSourceAt=None)
 func (f *linearRegression) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -45,11 +44,11 @@ func (f *linearRegression) Do(ctx context.Context, e parser.Expr, from, until in
 	for _, a := range arg {
 		r := *a
 		if len(e.Args()) > 2 {
-			r.Name = fmt.Sprintf("linearRegression(%s,'%s','%s')", a.GetName(), e.Args()[1].StringValue(), e.Args()[2].StringValue())
+			r.Name = helper.FuncName("linearRegression", a.GetName(), helper.Quote(e.Args()[1].StringValue()), helper.Quote(e.Args()[2].StringValue()))
 		} else if len(e.Args()) > 1 {
-			r.Name = fmt.Sprintf("linearRegression(%s,'%s')", a.GetName(), e.Args()[2].StringValue())
+			r.Name = helper.FuncName("linearRegression", a.GetName(), helper.Quote(e.Args()[2].StringValue()))
 		} else {
-			r.Name = fmt.Sprintf("linearRegression(%s)", a.GetName())
+			r.Name = helper.FuncName("linearRegression", a.GetName())
 		}
 
 		r.Values = make([]float64, len(a.Values))
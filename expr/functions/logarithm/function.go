@@ -2,7 +2,6 @@ package logarithm
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -32,7 +31,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 // logarithm(seriesList, base=10)
 // Alias: log
 func (f *logarithm) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -53,9 +52,9 @@ func (f *logarithm) Do(ctx context.Context, e parser.Expr, from, until int64, va
 
 		var name string
 		if ok {
-			name = fmt.Sprintf("logarithm(%s,%d)", a.Name, base)
+			name = helper.FuncName("logarithm", a.Name, base)
 		} else {
-			name = fmt.Sprintf("logarithm(%s)", a.Name)
+			name = helper.FuncName("logarithm", a.Name)
 		}
 
 		r := *a
@@ -2,7 +2,6 @@ package lowPass
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -31,7 +30,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // lowPass(seriesList, cutPercent)
 func (f *lowPass) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +42,7 @@ func (f *lowPass) Do(ctx context.Context, e parser.Expr, from, until int64, valu
 
 	var results []*types.MetricData
 	for _, a := range arg {
-		name := fmt.Sprintf("lowPass(%s,%v)", a.Name, cutPercent)
+		name := helper.FuncName("lowPass", a.Name, cutPercent)
 		r := *a
 		r.Name = name
 		r.Values = make([]float64, len(a.Values))
@@ -31,7 +31,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 // mapSeries(seriesList, *mapNodes)
 // Alias: map
 func (f *mapSeries) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,87 @@
+package minMax
+
+import (
+	"context"
+	"math"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type minMax struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &minMax{}
+	functions := []string{"minMax"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// minMax(seriesList)
+func (f *minMax) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+	var results []*types.MetricData
+
+	for _, a := range arg {
+		r := *a
+		r.Name = helper.FuncName("minMax", a.Name)
+		r.Values = make([]float64, len(a.Values))
+
+		minValue, maxValue := math.Inf(1), math.Inf(-1)
+		for _, v := range a.Values {
+			if math.IsNaN(v) {
+				continue
+			}
+			minValue = math.Min(minValue, v)
+			maxValue = math.Max(maxValue, v)
+		}
+
+		for i, v := range a.Values {
+			switch {
+			case math.IsNaN(v):
+				r.Values[i] = math.NaN()
+			case maxValue == minValue:
+				// constant series, same as graphite-web
+				r.Values[i] = 0
+			default:
+				r.Values[i] = (v - minValue) / (maxValue - minValue)
+			}
+		}
+		results = append(results, &r)
+	}
+	return results, nil
+}
+
+// Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
+func (f *minMax) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"minMax": {
+			Description: "Applies the popular min max normalization technique, which takes\neach point and applies the following normalization transformation\nto it: normalized = (point - min) / (max - min).\n\nExample:\n\n.. code-block:: none\n\n  &target=minMax(Server.instance01.threads.busy)\n\nSeries where all points have the same value are transformed to zero.",
+			Function:    "minMax(seriesList)",
+			Group:       "Transform",
+			Module:      "graphite.render.functions",
+			Name:        "minMax",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+			},
+		},
+	}
+}
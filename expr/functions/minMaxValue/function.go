@@ -0,0 +1,91 @@
+package minMaxValue
+
+import (
+	"context"
+
+	"github.com/go-graphite/carbonapi/expr/consolidations"
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type minMaxValue struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &minMaxValue{}
+	functions := []string{"maxValue", "minValue"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// maxValue(seriesList), minValue(seriesList)
+func (f *minMaxValue) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	aggFunc := consolidations.AggMax
+	if e.Target() == "minValue" {
+		aggFunc = consolidations.AggMin
+	}
+
+	results := make([]*types.MetricData, 0, len(args))
+	for _, a := range args {
+		// single point covers the whole range of the series
+		r := a.Copy(false)
+		r.Name = helper.FuncName(e.Target(), a.Name)
+		r.Values = []float64{aggFunc(a.Values)}
+		r.StepTime = a.StopTime - a.StartTime
+		if r.StepTime <= 0 {
+			r.StepTime = a.StepTime
+		}
+		r.StopTime = r.StartTime + r.StepTime
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+func (f *minMaxValue) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"maxValue": {
+			Description: "Takes a metric or a wildcard seriesList and collapses each series to a single point\nholding its maximum value over the requested time range. Absent values are ignored,\nseries without any values produce None.\n\nExample:\n\n.. code-block:: none\n\n  &target=maxValue(server*.connections.total)",
+			Function:    "maxValue(seriesList)",
+			Group:       "Transform",
+			Module:      "graphite.render.functions.custom",
+			Name:        "maxValue",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+			},
+		},
+		"minValue": {
+			Description: "Takes a metric or a wildcard seriesList and collapses each series to a single point\nholding its minimum value over the requested time range. Absent values are ignored,\nseries without any values produce None.\n\nExample:\n\n.. code-block:: none\n\n  &target=minValue(server*.connections.total)",
+			Function:    "minValue(seriesList)",
+			Group:       "Transform",
+			Module:      "graphite.render.functions.custom",
+			Name:        "minValue",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package minMaxValue
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestMinMaxValue(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric*", 0, 1}: {
+			types.MakeMetricData("metric1", []float64{1, math.NaN(), 5, -2, 3}, 1, now32),
+			types.MakeMetricData("metric2", []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()}, 1, now32),
+			types.MakeMetricData("metric3", []float64{7, 7, 7, 7, 7}, 1, now32),
+		},
+	}
+
+	tests := []th.EvalTestItem{
+		{
+			"maxValue(metric*)",
+			m,
+			[]*types.MetricData{
+				types.MakeMetricData("maxValue(metric1)", []float64{5}, 5, now32),
+				types.MakeMetricData("maxValue(metric2)", []float64{math.NaN()}, 5, now32),
+				types.MakeMetricData("maxValue(metric3)", []float64{7}, 5, now32),
+			},
+		},
+		{
+			"minValue(metric*)",
+			m,
+			[]*types.MetricData{
+				types.MakeMetricData("minValue(metric1)", []float64{-2}, 5, now32),
+				types.MakeMetricData("minValue(metric2)", []float64{math.NaN()}, 5, now32),
+				types.MakeMetricData("minValue(metric3)", []float64{7}, 5, now32),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+}
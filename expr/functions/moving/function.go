@@ -6,6 +6,12 @@ import (
 	"math"
 	"strconv"
 
+	"github.com/ansel1/merry"
+	"github.com/lomik/zapwriter"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/types"
@@ -14,118 +20,312 @@ import (
 
 type moving struct {
 	interfaces.FunctionBase
+
+	config movingConfig
 }
 
 func GetOrder() interfaces.Order {
 	return interfaces.Any
 }
 
+type movingConfig struct {
+	// EmitPartialWindows enables values for the leading points that don't have full window yet,
+	// computed over the points seen so far. graphite-web emits None for them, so it's disabled by default.
+	EmitPartialWindows bool
+}
+
 func New(configFile string) []interfaces.FunctionMetadata {
+	logger := zapwriter.Logger("functionInit").With(zap.String("function", "moving"))
 	res := make([]interfaces.FunctionMetadata, 0)
 	f := &moving{}
-	functions := []string{"movingAverage", "movingMin", "movingMax", "movingSum"}
+	functions := []string{"movingWindow", "movingAverage", "movingMin", "movingMax", "movingSum", "movingMedian"}
 	for _, n := range functions {
 		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
 	}
+
+	if configFile == "" {
+		return res
+	}
+
+	cfg := movingConfig{}
+	v := viper.New()
+	v.SetConfigFile(configFile)
+	err := v.ReadInConfig()
+	if err != nil {
+		logger.Info("failed to read config file, using default",
+			zap.Error(err),
+		)
+	} else {
+		err = v.Unmarshal(&cfg)
+		if err != nil {
+			logger.Fatal("failed to parse config",
+				zap.Error(err),
+			)
+			return nil
+		}
+
+		f.config = cfg
+	}
+
 	return res
 }
 
-// movingXyz(seriesList, windowSize)
+// movingFuncs are shortcuts for movingWindow with the given aggregation function
+var movingFuncs = map[string]string{
+	"movingAverage": "average",
+	"movingSum":     "sum",
+	"movingMin":     "min",
+	"movingMax":     "max",
+	"movingMedian":  "median",
+}
+
+// movingWindow(seriesList, windowSize, func='average'), movingXyz(seriesList, windowSize)
 func (f *moving) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	var n int
-	var err error
+	window, err := parseWindow(e)
+	if err != nil {
+		return nil, err
+	}
+
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], window.start(from), until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	// window in points can't be fetched in advance as the step isn't known, so history is fetched now.
+	// Series may have different steps, history is fetched for the largest one.
+	var lookback bool
+	if !window.scaleByStep && window.n > 0 && len(arg) > 0 {
+		var step int64
+		for _, a := range arg {
+			if a.StepTime > step {
+				step = a.StepTime
+			}
+		}
+		start := from - int64(window.n)*step
+		if extended, err := helper.FetchSeriesArg(ctx, e.Args()[0], start, until, values); err == nil && len(extended) > 0 {
+			arg = extended
+			lookback = true
+		}
+	}
+
+	return f.movingSeriesList(ctx, arg, from, window, lookback)
+}
+
+func (f *moving) movingSeriesList(ctx context.Context, arg []*types.MetricData, from int64, window movingWindow, lookback bool) ([]*types.MetricData, error) {
+	result := make([]*types.MetricData, 0, len(arg))
+	// series usually have windows of the same size, so the window is allocated once and reused
+	w := &types.Windowed{}
+	for _, a := range arg {
+		if err := ctx.Err(); err != nil {
+			return nil, merry.Wrap(err)
+		}
+		result = append(result, f.movingSeries(a, from, window, lookback, w))
+	}
+	return result, nil
+}
 
-	var scaleByStep bool
+// Transform implements interfaces.StreamingFunction. Window given as an interval is converted to the number of points
+// with the step of each series. Window in points needs the step to fetch history, so it's not streamed.
+func (f *moving) Transform(ctx context.Context, e parser.Expr, from, until int64) (interfaces.SeriesTransform, int64, int64, error) {
+	window, err := parseWindow(e)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !window.scaleByStep {
+		return nil, 0, 0, interfaces.ErrNotStreamable
+	}
+
+	transform := func(a *types.MetricData) *types.MetricData {
+		return f.movingSeries(a, from, window, false, &types.Windowed{})
+	}
+	return transform, window.start(from), until, nil
+}
+
+type movingWindow struct {
+	// n is a number of points or, if scaleByStep is set, number of seconds
+	n           int
+	scaleByStep bool
+	argstr      string
+
+	// target and fn are the name of the function, for movingWindow it includes the name of aggregation function
+	target    string
+	fn        string
+	aggregate func([]float64) float64
+
+	// xFilesFactor is the ratio of present points in the window needed for the value to be present, 0 disables the check
+	xFilesFactor float32
 
-	var argstr string
+	// namePrefix and nameSuffix surround the name of a series in the name of result series
+	namePrefix, nameSuffix string
+}
+
+func parseWindow(e parser.Expr) (movingWindow, error) {
+	var w movingWindow
+	var err error
 
 	if len(e.Args()) < 2 {
-		return nil, parser.ErrMissingArgument
+		return w, parser.ErrMissingArgument
 	}
 
+	w.target = e.Target()
+	if fn, ok := movingFuncs[w.target]; ok {
+		w.fn = fn
+	} else {
+		w.fn, err = e.GetStringNamedOrPosArgDefault("func", 2, "average")
+		if err != nil {
+			return w, err
+		}
+	}
+	var ok bool
+	if w.aggregate, ok = consolidations.GetAggregateFunc(w.fn); !ok {
+		return w, merry.WithMessagef(parser.ErrBadType, "%s: unsupported aggregation function %q", parser.ErrBadType, w.fn)
+	}
+
+	xFilesFactorPos := 2
+	if w.target == "movingWindow" {
+		xFilesFactorPos = 3
+	}
+	xFilesFactor, err := e.GetFloatNamedOrPosArgDefault("xFilesFactor", xFilesFactorPos, 0)
+	if err != nil {
+		return w, err
+	}
+	if xFilesFactor < 0 || xFilesFactor > 1 {
+		return w, merry.WithMessagef(parser.ErrBadType, "%s: xFilesFactor should be between 0 and 1, got %v", parser.ErrBadType, xFilesFactor)
+	}
+	w.xFilesFactor = float32(xFilesFactor)
+
 	switch e.Args()[1].Type() {
 	case parser.EtConst:
-		// In this case, zipper does not request additional retrospective points,
-		// and leading `n` values, that used to calculate window, become NaN
-		n, err = e.GetIntArg(1)
-		argstr = strconv.Itoa(n)
+		w.n, err = e.GetIntArg(1)
+		w.argstr = strconv.Itoa(w.n)
 	case parser.EtString:
 		var n32 int32
 		n32, err = e.GetIntervalArg(1, 1)
-		argstr = fmt.Sprintf("%q", e.Args()[1].StringValue())
-		n = int(n32)
-		scaleByStep = true
+		w.argstr = fmt.Sprintf("%q", e.Args()[1].StringValue())
+		w.n = int(n32)
+		w.scaleByStep = true
 	default:
 		err = parser.ErrBadType
 	}
 	if err != nil {
-		return nil, err
+		return w, err
 	}
 
-	windowSize := n
-
-	start := from
-	if scaleByStep {
-		start -= int64(n)
+	w.nameSuffix = "," + w.argstr + ")"
+	if w.target == "movingWindow" {
+		w.nameSuffix = "," + w.argstr + "," + strconv.Quote(w.fn) + ")"
 	}
+	w.namePrefix = w.target + "("
 
-	arg, err := helper.GetSeriesArg(e.Args()[0], start, until, values)
-	if err != nil {
-		return nil, err
+	return w, nil
+}
+
+// start returns start of the range the series should be evaluated for, window given as an interval is fetched in advance
+func (w movingWindow) start(from int64) int64 {
+	if w.scaleByStep {
+		return from - int64(w.n)
 	}
+	return from
+}
 
-	var result []*types.MetricData
+// name returns name of the result series for a series named name, e.x. movingAverage(name,10)
+func (w movingWindow) name(name string) string {
+	return w.namePrefix + name + w.nameSuffix
+}
 
-	if len(arg) == 0 {
-		return result, nil
+// windowedFunc returns aggregation of data pushed to w for functions that types.Windowed computes incrementally
+func windowedFunc(fn string, w *types.Windowed) func() float64 {
+	switch fn {
+	case "average", "avg":
+		return w.Mean
+	case "sum":
+		return w.Sum
+	//TODO(cldellow): consider a linear time min/max-heap for these,
+	// e.g. http://stackoverflow.com/questions/8905525/computing-a-moving-maximum/8905575#8905575
+	case "min", "minimum":
+		return w.Min
+	case "max", "maximum":
+		return w.Max
 	}
+	return nil
+}
 
+// movingSeries computes moving function of a. Leading points that are only needed to fill the window (window size
+// for interval windows or points before from if lookback is set for windows in points) are trimmed, result starts at from.
+// Value at each point is the aggregation of the window of points before it. w is reset and used to keep the window.
+func (f *moving) movingSeries(a *types.MetricData, from int64, window movingWindow, lookback bool, w *types.Windowed) *types.MetricData {
+	windowSize := window.n
 	var offset int
 
-	if scaleByStep {
-		windowSize /= int(arg[0].StepTime)
+	if window.scaleByStep {
+		windowSize /= int(a.StepTime)
 		offset = windowSize
+	} else if lookback && from > a.StartTime {
+		offset = int((from - a.StartTime) / a.StepTime)
+	}
+	if offset > len(a.Values) {
+		offset = len(a.Values)
 	}
 
-	for _, a := range arg {
-		r := *a
-		r.Name = fmt.Sprintf("%s(%s,%s)", e.Target(), a.Name, argstr)
-		r.Values = make([]float64, len(a.Values)-offset)
-		r.StartTime = (from + r.StepTime - 1) / r.StepTime * r.StepTime // align StartTime to closest >= StepTime
-		r.StopTime = r.StartTime + int64(len(r.Values))*r.StepTime
-
-		if windowSize == 0 {
-			// Fix error on long time ranges (greater than 30 days), sampling to 10 min
-			// https://github.com/go-graphite/carbonapi/issues/371
-			for i := range a.Values {
-				r.Values[i] = math.NaN()
+	r := *a
+	r.Name = window.name(a.Name)
+	r.Values = make([]float64, len(a.Values)-offset)
+	r.StartTime = (from + r.StepTime - 1) / r.StepTime * r.StepTime // align StartTime to closest >= StepTime
+	r.StopTime = r.StartTime + int64(len(r.Values))*r.StepTime
+
+	if windowSize == 0 {
+		// Fix error on long time ranges (greater than 30 days), sampling to 10 min
+		// https://github.com/go-graphite/carbonapi/issues/371
+		for i := range r.Values {
+			r.Values[i] = math.NaN()
+		}
+		return &r
+	}
+
+	windowed := windowedFunc(window.fn, w)
+	if windowed != nil {
+		w.Reset(windowSize)
+	}
+
+	// nonNull[i] is the number of present points before i, so xFilesFactor of any window is checked in constant time
+	var nonNull []int
+	if window.xFilesFactor > 0 {
+		nonNull = make([]int, len(a.Values)+1)
+		for i, v := range a.Values {
+			nonNull[i+1] = nonNull[i]
+			if !math.IsNaN(v) {
+				nonNull[i+1]++
 			}
-		} else {
-			w := &types.Windowed{Data: make([]float64, windowSize)}
-			for i, v := range a.Values {
-				if ridx := i - offset; ridx >= 0 {
-					switch e.Target() {
-					case "movingAverage":
-						r.Values[ridx] = w.Mean()
-					case "movingSum":
-						r.Values[ridx] = w.Sum()
-						//TODO(cldellow): consider a linear time min/max-heap for these,
-						// e.g. http://stackoverflow.com/questions/8905525/computing-a-moving-maximum/8905575#8905575
-					case "movingMin":
-						r.Values[ridx] = w.Min()
-					case "movingMax":
-						r.Values[ridx] = w.Max()
-					}
-					if i < windowSize || math.IsNaN(r.Values[ridx]) {
-						r.Values[ridx] = math.NaN()
-					}
+		}
+	}
+	for i, v := range a.Values {
+		if ridx := i - offset; ridx >= 0 {
+			switch {
+			case i < windowSize:
+				r.Values[ridx] = math.NaN()
+				if f.config.EmitPartialWindows {
+					r.Values[ridx] = window.aggregate(a.Values[:i])
 				}
-				w.Push(v)
+			case windowed != nil:
+				r.Values[ridx] = windowed()
+			default:
+				r.Values[ridx] = window.aggregate(a.Values[i-windowSize : i])
 			}
+			if nonNull != nil {
+				start := i - windowSize
+				if start < 0 {
+					start = 0
+				}
+				if !consolidations.XFilesFactor(nonNull[i]-nonNull[start], i-start, window.xFilesFactor) {
+					r.Values[ridx] = math.NaN()
+				}
+			}
+		}
+		if windowed != nil {
+			w.Push(v)
 		}
-		result = append(result, &r)
 	}
-	return result, nil
+	return &r
 }
 
 // Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
@@ -263,5 +463,77 @@ func (f *moving) Description() map[string]types.FunctionDescription {
 				},
 			},
 		},
+		"movingMedian": {
+			Description: "Graphs the moving median of a metric (or metrics) over a fixed number of\npast points, or a time interval.\n\nTakes one metric or a wildcard seriesList followed by a number N of datapoints\nor a quoted string with a length of time like '1hour' or '5min' (See ``from /\nuntil`` in the render\\_api_ for examples of time formats), and an xFilesFactor value to specify\nhow many points in the window must be non-null for the output to be considered valid. Graphs the\nmedian of the preceeding datapoints for each point on the graph.\n\nExample:\n\n.. code-block:: none\n\n  &target=movingMedian(Server.instance01.threads.busy,10)\n  &target=movingMedian(Server.instance*.threads.idle,'5min')",
+			Function:    "movingMedian(seriesList, windowSize, xFilesFactor=None)",
+			Group:       "Calculate",
+			Module:      "graphite.render.functions",
+			Name:        "movingMedian",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+				{
+					Name:     "windowSize",
+					Required: true,
+					Suggestions: types.NewSuggestions(
+						5,
+						7,
+						10,
+						"1min",
+						"5min",
+						"10min",
+						"30min",
+						"1hour",
+					),
+					Type: types.IntOrInterval,
+				},
+				{
+					Name: "xFilesFactor",
+					Type: types.Float,
+				},
+			},
+		},
+		"movingWindow": {
+			Description: "Graphs a moving window function of a metric (or metrics) over a fixed number of\npast points, or a time interval.\n\nTakes one metric or a wildcard seriesList, a number N of datapoints\nor a quoted string with a length of time like '1hour' or '5min' (See ``from /\nuntil`` in the render\\_api_ for examples of time formats), a function to apply to the points\nin the window to produce the output, and an xFilesFactor value to specify how many points in the\nwindow must be non-null for the output to be considered valid. Graphs the\noutput of the function for the preceeding datapoints for each point on the graph.\n\nExample:\n\n.. code-block:: none\n\n  &target=movingWindow(Server.instance01.threads.busy,10)\n  &target=movingWindow(Server.instance*.threads.idle,'5min','median',0.5)\n\n.. note::\n\n  `xFilesFactor` follows the same semantics as in Whisper storage schemas.  Setting it to 0 (the\n  default) means that only a single value in a given interval needs to be non-null, setting it to\n  1 means that all values in the interval must be non-null.  A setting of 0.5 means that at least\n  half the values in the interval must be non-null.",
+			Function:    "movingWindow(seriesList, windowSize, func='average', xFilesFactor=None)",
+			Group:       "Calculate",
+			Module:      "graphite.render.functions",
+			Name:        "movingWindow",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+				{
+					Name:     "windowSize",
+					Required: true,
+					Suggestions: types.NewSuggestions(
+						5,
+						7,
+						10,
+						"1min",
+						"5min",
+						"10min",
+						"30min",
+						"1hour",
+					),
+					Type: types.IntOrInterval,
+				},
+				{
+					Name:    "func",
+					Type:    types.AggFunc,
+					Default: types.NewSuggestion("average"),
+					Options: types.StringsToSuggestionList(consolidations.AvailableConsolidationFuncs()),
+				},
+				{
+					Name: "xFilesFactor",
+					Type: types.Float,
+				},
+			},
+		},
 	}
 }
@@ -1,11 +1,16 @@
 package moving
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
 	"math"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/metadata"
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/pkg/parser"
@@ -40,6 +45,22 @@ func TestMoving(t *testing.T) {
 			},
 			[]*types.MetricData{types.MakeMetricData("movingAverage(metric1,4)", []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), 1, 1.25, 1.5, 1.75, 2.5, 3.5, 4, 5}, 1, 0)}, // StartTime = from
 		},
+		{
+			// window is reused between series, its size depends on the step of each series
+			"movingSum(metric*,'2sec')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", -2, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5, 6}, 1, now32),
+					types.MakeMetricData("metric2", []float64{10, 20, 30}, 2, now32),
+					types.MakeMetricData("metric3", []float64{1, 1, 1, 1}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData(`movingSum(metric1,"2sec")`, []float64{3, 5, 7, 9}, 1, 0),
+				types.MakeMetricData(`movingSum(metric2,"2sec")`, []float64{10, 20}, 2, 0),
+				types.MakeMetricData(`movingSum(metric3,"2sec")`, []float64{2, 2}, 1, 0),
+			},
+		},
 		{
 			"movingSum(metric1,2)",
 			map[parser.MetricRequest][]*types.MetricData{
@@ -61,6 +82,119 @@ func TestMoving(t *testing.T) {
 			},
 			[]*types.MetricData{types.MakeMetricData("movingMax(metric1,2)", []float64{math.NaN(), math.NaN(), 2, 3, 3, 2}, 1, 0)}, // StartTime = from
 		},
+		{
+			// window in points is fetched before from once the step is known, so the first points have full window
+			"movingSum(metric1,2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}:  {types.MakeMetricData("metric1", []float64{3, 4, 5, 6, 7, 8}, 1, now32)},
+				{"metric1", -2, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5, 6, 7, 8}, 1, -2)},
+			},
+			[]*types.MetricData{types.MakeMetricData("movingSum(metric1,2)", []float64{3, 5, 7, 9, 11, 13}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingAverage(metric1,3)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}:   {types.MakeMetricData("metric1", []float64{4, 5, 6}, 10, now32)},
+				{"metric1", -30, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5, 6}, 10, -30)},
+			},
+			[]*types.MetricData{types.MakeMetricData("movingAverage(metric1,3)", []float64{2, 3, 4}, 10, 0)}, // StartTime = from
+		},
+		{
+			// history is fetched for the largest step, each series is trimmed by the points before from it has
+			"movingAverage(metric*,2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{5, 6, 7}, 10, 0),
+					types.MakeMetricData("metric2", []float64{6, 8}, 20, 0),
+				},
+				{"metric*", -40, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5, 6, 7}, 10, -40),
+					types.MakeMetricData("metric2", []float64{2, 4, 6, 8}, 20, -40),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("movingAverage(metric1,2)", []float64{3.5, 4.5, 5.5}, 10, 0), // StartTime = from
+				types.MakeMetricData("movingAverage(metric2,2)", []float64{3, 5}, 20, 0),
+			},
+		},
+		{
+			"movingMedian(metric1,4)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 1, 1, 1, 2, 2, 2, 4, 6, 4, 6, 8}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("movingMedian(metric1,4)", []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), 1, 1, 1.5, 2, 2, 3, 4, 5}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingMedian(metric1,5)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 1, 1, 1, 2, 2, 2, 4, 6, 4, 6, 8, 1, 2, math.NaN()}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("movingMedian(metric1,5)", []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), 1, 1, 2, 2, 2, 4, 4, 6, 6, 4}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingMedian(metric1,\"1s\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", -1, 1}: {types.MakeMetricData("metric1", []float64{1, 1, 1, 1, 1, 2, 2, 2, 4, 6, 4, 6, 8, 1, 2, 0}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("movingMedian(metric1,\"1s\")", []float64{1, 1, 1, 1, 1, 2, 2, 2, 4, 6, 4, 6, 8, 1, 2}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingMedian(metric1,\"3s\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", -3, 1}: {types.MakeMetricData("metric1", []float64{0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 4, 6, 4, 6, 8, 1, 2}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("movingMedian(metric1,\"3s\")", []float64{0, 0, 1, 1, 1, 1, 2, 2, 2, 4, 4, 6, 6, 6}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingWindow(metric1,'3s')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", -3, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 1, 2, 3}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData(`movingWindow(metric1,"3s","average")`, []float64{2, 2, 2}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingWindow(metric1,2,'max')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 2, 1, 0}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData(`movingWindow(metric1,2,"max")`, []float64{math.NaN(), math.NaN(), 2, 3, 3, 2}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingWindow(metric1,3,'range')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 5, 2, 8, 3}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData(`movingWindow(metric1,3,"range")`, []float64{math.NaN(), math.NaN(), math.NaN(), 4, 6}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingWindow(metric1,2,func='median')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), 3, 5}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData(`movingWindow(metric1,2,"median")`, []float64{math.NaN(), math.NaN(), 1, 3}, 1, 0)}, // StartTime = from
+		},
+		{
+			// windows with less than xFilesFactor of present points are absent
+			"movingAverage(metric1,2,0.6)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), 3, 4, math.NaN(), 6}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("movingAverage(metric1,2)", []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), 3.5, math.NaN()}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingWindow(metric1,2,'sum',0.5)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), 3, 4, math.NaN(), 6}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData(`movingWindow(metric1,2,"sum")`, []float64{math.NaN(), math.NaN(), 1, 3, 7, 4}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingMedian(metric1,'2s',xFilesFactor=1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", -2, 1}: {types.MakeMetricData("metric1", []float64{1, 2, math.NaN(), 4, 5, 6}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData(`movingMedian(metric1,"2s")`, []float64{1.5, math.NaN(), math.NaN(), 4.5}, 1, 0)}, // StartTime = from
+		},
 	}
 
 	for _, tt := range tests {
@@ -71,3 +205,124 @@ func TestMoving(t *testing.T) {
 	}
 
 }
+
+func TestMovingWindowErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "movingWindow(metric1,2,'foo')",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+		{
+			Target: "movingAverage(metric1,2,xFilesFactor=1.5)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
+
+func TestMovingPartialWindows(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	configFile := filepath.Join(t.TempDir(), "moving.yaml")
+	if err := ioutil.WriteFile(configFile, []byte("emitPartialWindows: true\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	setEvaluator := func(md []interfaces.FunctionMetadata) {
+		evaluator := th.EvaluatorFromFunc(md[0].F)
+		metadata.SetEvaluator(evaluator)
+		helper.SetEvaluator(evaluator)
+	}
+	setEvaluator(New(configFile))
+	defer setEvaluator(New(""))
+
+	tests := []th.EvalTestItem{
+		{
+			"movingAverage(metric1,4)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), 3, 1, 2, 2}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("movingAverage(metric1,4)", []float64{math.NaN(), 1, 1, 2, 5.0 / 3, 2}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingSum(metric1,2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 4}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("movingSum(metric1,2)", []float64{math.NaN(), 1, 3, 5}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingMin(metric1,3)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{3, 2, 1, 2}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("movingMin(metric1,3)", []float64{math.NaN(), 3, 2, 1}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingMax(metric1,3)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{-3, -2, -1, -2}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("movingMax(metric1,3)", []float64{math.NaN(), -3, -2, -1}, 1, 0)}, // StartTime = from
+		},
+		{
+			"movingMedian(metric1,3)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), 3, 1}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("movingMedian(metric1,3)", []float64{math.NaN(), 1, 1, 2}, 1, 0)}, // StartTime = from
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+}
+
+func BenchmarkMovingAverage(b *testing.B) {
+	const seriesCount, pointsCount = 1000, 10000
+
+	args := make([]*types.MetricData, 0, seriesCount)
+	for i := 0; i < seriesCount; i++ {
+		values := make([]float64, pointsCount)
+		for j := range values {
+			values[j] = float64(i + j)
+			if (i+j)%10 == 0 {
+				values[j] = math.NaN()
+			}
+		}
+		args = append(args, types.MakeMetricData(fmt.Sprintf("metric.%d", i), values, 1, 1))
+	}
+
+	e, _, err := parser.ParseExpr("movingAverage(metric.*,10)")
+	if err != nil {
+		b.Fatal(err)
+	}
+	window, err := parseWindow(e)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	f := &moving{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = f.movingSeriesList(context.Background(), args, 1, window, false)
+	}
+}
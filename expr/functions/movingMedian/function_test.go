@@ -1,66 +0,0 @@
-package movingMedian
-
-import (
-	"math"
-	"testing"
-	"time"
-
-	"github.com/go-graphite/carbonapi/expr/helper"
-	"github.com/go-graphite/carbonapi/expr/metadata"
-	"github.com/go-graphite/carbonapi/expr/types"
-	"github.com/go-graphite/carbonapi/pkg/parser"
-	th "github.com/go-graphite/carbonapi/tests"
-)
-
-func init() {
-	md := New("")
-	evaluator := th.EvaluatorFromFunc(md[0].F)
-	metadata.SetEvaluator(evaluator)
-	helper.SetEvaluator(evaluator)
-	for _, m := range md {
-		metadata.RegisterFunction(m.Name, m.F)
-	}
-}
-
-func TestMovingMedian(t *testing.T) {
-	now32 := int64(time.Now().Unix())
-
-	tests := []th.EvalTestItem{
-		{
-			"movingMedian(metric1,4)",
-			map[parser.MetricRequest][]*types.MetricData{
-				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 1, 1, 1, 2, 2, 2, 4, 6, 4, 6, 8}, 1, now32)},
-			},
-			[]*types.MetricData{types.MakeMetricData("movingMedian(metric1,4)", []float64{math.NaN(), math.NaN(), math.NaN(), 1, 1, 1.5, 2, 2, 3, 4, 5, 6}, 1, 0)}, // StartTime = from
-		},
-		{
-			"movingMedian(metric1,5)",
-			map[parser.MetricRequest][]*types.MetricData{
-				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 1, 1, 1, 2, 2, 2, 4, 6, 4, 6, 8, 1, 2, math.NaN()}, 1, now32)},
-			},
-			[]*types.MetricData{types.MakeMetricData("movingMedian(metric1,5)", []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), 1, 1, 2, 2, 2, 4, 4, 6, 6, 4, 2}, 1, 0)}, // StartTime = from
-		},
-		{
-			"movingMedian(metric1,\"1s\")",
-			map[parser.MetricRequest][]*types.MetricData{
-				{"metric1", -1, 1}: {types.MakeMetricData("metric1", []float64{1, 1, 1, 1, 1, 2, 2, 2, 4, 6, 4, 6, 8, 1, 2, 0}, 1, now32)},
-			},
-			[]*types.MetricData{types.MakeMetricData("movingMedian(metric1,\"1s\")", []float64{1, 1, 1, 1, 2, 2, 2, 4, 6, 4, 6, 8, 1, 2, 0}, 1, 0)}, // StartTime = from
-		},
-		{
-			"movingMedian(metric1,\"3s\")",
-			map[parser.MetricRequest][]*types.MetricData{
-				{"metric1", -3, 1}: {types.MakeMetricData("metric1", []float64{0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 4, 6, 4, 6, 8, 1, 2}, 1, now32)},
-			},
-			[]*types.MetricData{types.MakeMetricData("movingMedian(metric1,\"3s\")", []float64{0, 1, 1, 1, 1, 2, 2, 2, 4, 4, 6, 6, 6, 2}, 1, 0)}, // StartTime = from
-		},
-	}
-
-	for _, tt := range tests {
-		testName := tt.Target
-		t.Run(testName, func(t *testing.T) {
-			th.TestEvalExpr(t, &tt)
-		})
-	}
-
-}
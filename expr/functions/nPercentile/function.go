@@ -2,8 +2,6 @@ package nPercentile
 
 import (
 	"context"
-	"fmt"
-	"math"
 
 	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -32,7 +30,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // nPercentile(seriesList, n)
 func (f *nPercentile) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -44,17 +42,10 @@ func (f *nPercentile) Do(ctx context.Context, e parser.Expr, from, until int64,
 	var results []*types.MetricData
 	for _, a := range arg {
 		r := *a
-		r.Name = fmt.Sprintf("nPercentile(%s,%g)", a.Name, percent)
+		r.Name = helper.FuncName("nPercentile", a.Name, percent)
 		r.Values = make([]float64, len(a.Values))
 
-		var values []float64
-		for _, v := range a.Values {
-			if !math.IsNaN(v) {
-				values = append(values, v)
-			}
-		}
-
-		value := consolidations.Percentile(values, percent, true)
+		value := consolidations.Percentile(a.Values, percent, false)
 		for i := range r.Values {
 			r.Values[i] = value
 		}
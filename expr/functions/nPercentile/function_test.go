@@ -31,7 +31,7 @@ func TestNPercentile(t *testing.T) {
 			map[parser.MetricRequest][]*types.MetricData{
 				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{2, 4, 6, 10, 14, 20, math.NaN()}, 1, now32)},
 			},
-			[]*types.MetricData{types.MakeMetricData("nPercentile(metric1,50)", []float64{8, 8, 8, 8, 8, 8, 8}, 1, now32)},
+			[]*types.MetricData{types.MakeMetricData("nPercentile(metric1,50)", []float64{10, 10, 10, 10, 10, 10, 10}, 1, now32)},
 		},
 	}
 
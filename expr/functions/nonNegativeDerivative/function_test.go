@@ -47,6 +47,20 @@ func TestNonNegativeDerivative(t *testing.T) {
 			},
 			[]*types.MetricData{types.MakeMetricData("nonNegativeDerivative(metric1,32)", []float64{math.NaN(), 2, 29, 10, 24, math.NaN(), math.NaN(), 32, math.NaN()}, 1, now32)},
 		},
+		{
+			"nonNegativeDerivative(metric1,4294967295)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{4294967290, 4294967295, 3, 10, 4294967295, 0}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("nonNegativeDerivative(metric1,4294967295)", []float64{math.NaN(), 5, 4, 7, 4294967285, 1}, 1, now32)},
+		},
+		{
+			"nonNegativeDerivative(metric1,maxValue=32)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{30, 2, 50, 5, 10}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("nonNegativeDerivative(metric1,32)", []float64{math.NaN(), 5, 48, math.NaN(), 5}, 1, now32)},
+		},
 		{
 			"nonNegativeDerivative(metric1,minValue=1)",
 			map[parser.MetricRequest][]*types.MetricData{
@@ -54,6 +68,34 @@ func TestNonNegativeDerivative(t *testing.T) {
 			},
 			[]*types.MetricData{types.MakeMetricData("nonNegativeDerivative(metric1,minValue=1)", []float64{math.NaN(), 2, 1, 8, 0, math.NaN(), math.NaN(), 32, 36}, 1, now32)},
 		},
+		{
+			"nonNegativeDerivative(metric1,maxGap=1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{2, 4, math.NaN(), 8, math.NaN(), math.NaN(), 14, 15}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("nonNegativeDerivative(metric1,maxGap=1)", []float64{math.NaN(), 2, 2, 2, math.NaN(), math.NaN(), math.NaN(), 1}, 1, now32)},
+		},
+		{
+			"nonNegativeDerivative(metric1,maxGap=2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{2, 4, math.NaN(), 8, math.NaN(), math.NaN(), 14, 15}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("nonNegativeDerivative(metric1,maxGap=2)", []float64{math.NaN(), 2, 2, 2, 2, 2, 2, 1}, 1, now32)},
+		},
+		{
+			"nonNegativeDerivative(metric1,maxGap=0)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{2, 4, math.NaN(), 8, 10}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("nonNegativeDerivative(metric1)", []float64{math.NaN(), 2, math.NaN(), math.NaN(), 2}, 1, now32)},
+		},
+		{
+			"nonNegativeDerivative(metric1,32,maxGap=1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{30, math.NaN(), 2, 1}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("nonNegativeDerivative(metric1,32,maxGap=1)", []float64{math.NaN(), 2.5, 2.5, 32}, 1, now32)},
+		},
 	}
 
 	for _, tt := range tests {
@@ -64,3 +106,24 @@ func TestNonNegativeDerivative(t *testing.T) {
 	}
 
 }
+
+func TestNonNegativeDerivativeErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "nonNegativeDerivative(metric1,maxGap=-1)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package offset
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
@@ -29,12 +28,13 @@ func New(configFile string) []interfaces.FunctionMetadata {
 }
 
 // offset(seriesList,factor)
+// factor can also be an expression that evaluates to a single series with a single value, e.x. nPercentile(a,50)
 func (f *offset) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
-	factor, err := e.GetFloatArg(1)
+	factor, factorName, err := helper.GetScalarArg(ctx, e, 1, from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +42,7 @@ func (f *offset) Do(ctx context.Context, e parser.Expr, from, until int64, value
 
 	for _, a := range arg {
 		r := *a
-		r.Name = fmt.Sprintf("%s(%s,%g)", e.Target(), a.Name, factor)
+		r.Name = helper.FuncName(e.Target(), a.Name, factorName)
 		r.Values = make([]float64, len(a.Values))
 
 		for i, v := range a.Values {
@@ -57,7 +57,7 @@ func (f *offset) Do(ctx context.Context, e parser.Expr, from, until int64, value
 func (f *offset) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"add": {
-			Description: "Takes one metric or a wildcard seriesList followed by a constant, and adds the constant to\neach datapoint.\n\nExample:\n\n.. code-block:: none\n\n  &target=add(Server.instance01.threads.busy,10)\n  &target=add(Server.instance*.threads.busy, 10)",
+			Description: "Takes one metric or a wildcard seriesList followed by a constant, and adds the constant to\neach datapoint.\ncarbonapi also accepts a series with a single value as the constant, e.x. add(a,nPercentile(b,50))\n\nExample:\n\n.. code-block:: none\n\n  &target=add(Server.instance01.threads.busy,10)\n  &target=add(Server.instance*.threads.busy, 10)",
 			Function:    "add(seriesList, constant)",
 			Group:       "Transform",
 			Module:      "graphite.render.functions",
@@ -76,7 +76,7 @@ func (f *offset) Description() map[string]types.FunctionDescription {
 			},
 		},
 		"offset": {
-			Description: "Takes one metric or a wildcard seriesList followed by a constant, and adds the constant to\neach datapoint.\n\nExample:\n\n.. code-block:: none\n\n  &target=offset(Server.instance01.threads.busy,10)",
+			Description: "Takes one metric or a wildcard seriesList followed by a constant, and adds the constant to\neach datapoint.\ncarbonapi also accepts a series with a single value as the constant, e.x. offset(a,nPercentile(b,50))\n\nExample:\n\n.. code-block:: none\n\n  &target=offset(Server.instance01.threads.busy,10)",
 			Function:    "offset(seriesList, factor)",
 			Group:       "Transform",
 			Module:      "graphite.render.functions",
@@ -46,6 +46,13 @@ func TestFunction(t *testing.T) {
 				types.MakeMetricData("add(metric1,-10)", []float64{83, 84, 85, math.NaN(), 87, 88, 89, 90, 91}, 1, now32),
 				types.MakeMetricData("add(metric2,-10)", []float64{183, 184, 185, math.NaN(), 187, 188, 189, 190, 191}, 1, now32),
 			},
+		}, {
+			"offset(metric1,base)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, math.NaN(), 4}, 1, now32)},
+				{"base", 0, 1}:    {types.MakeMetricData("base", []float64{-1}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("offset(metric1,base)", []float64{0, 1, math.NaN(), 3}, 1, now32)},
 		},
 	}
 
@@ -37,7 +37,7 @@ func (f *pearsonClosest) Do(ctx context.Context, e parser.Expr, from, until int6
 		return nil, types.ErrTooManyArguments
 	}
 
-	ref, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	ref, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -46,7 +46,7 @@ func (f *pearsonClosest) Do(ctx context.Context, e parser.Expr, from, until int6
 		return nil, types.ErrWildcardNotAllowed
 	}
 
-	compare, err := helper.GetSeriesArg(e.Args()[1], from, until, values)
+	compare, err := helper.GetSeriesArg(ctx, e.Args()[1], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -173,8 +173,8 @@ and will manifest as if the entire window/series had missing values.`,
 					Type:     types.Integer,
 				},
 				{
-					Name:     "direction",
-					Required: true,
+					Name:    "direction",
+					Default: types.NewSuggestion("abs"),
 					Options: types.StringsToSuggestionList([]string{
 						"abs",
 						"pos",
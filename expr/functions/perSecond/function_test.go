@@ -47,6 +47,21 @@ func TestPerSecond(t *testing.T) {
 			},
 			[]*types.MetricData{types.MakeMetricData("perSecond(metric1,minValue=1)", []float64{math.NaN(), math.NaN(), 1, 1, 1, 26, 2, 29, math.NaN()}, 1, now32)},
 		},
+		{
+			// previous value above maxValue means it's not a counter of that size, the decrease isn't a wrap
+			"perSecond(metric1,maxValue=32)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{30, 2, 50, 5, 10}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("perSecond(metric1,32)", []float64{math.NaN(), 5, 48, math.NaN(), 5}, 1, now32)},
+		},
+		{
+			"perSecond(metric1,maxGap=1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{10, math.NaN(), 14, 18, math.NaN(), math.NaN(), 30}, 2, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("perSecond(metric1,maxGap=1)", []float64{math.NaN(), 1, 1, 2, math.NaN(), math.NaN(), math.NaN()}, 2, now32)},
+		},
 	}
 
 	for _, tt := range tests {
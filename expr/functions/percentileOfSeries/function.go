@@ -31,7 +31,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 // percentileOfSeries(seriesList, n, interpolate=False)
 func (f *percentileOfSeries) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
 	// TODO(dgryski): make sure the arrays are all the same 'size'
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -46,7 +46,7 @@ func (f *percentileOfSeries) Do(ctx context.Context, e parser.Expr, from, until
 		return nil, err
 	}
 
-	return helper.AggregateSeries(e, args, func(values []float64) float64 {
+	return helper.AggregateSeries(ctx, e, args, func(values []float64) float64 {
 		return consolidations.Percentile(values, percent, interpolate)
 	})
 }
@@ -3,7 +3,6 @@ package polyfit
 import (
 	"context"
 	"errors"
-	"fmt"
 	"math"
 
 	"github.com/go-graphite/carbonapi/expr/consolidations"
@@ -36,7 +35,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 func (f *polyfit) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
 	// Fitting Nth degree polynom to the dataset
 	// https://en.wikipedia.org/wiki/Polynomial_regression#Matrix_form_and_calculation_of_estimates
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -62,11 +61,11 @@ func (f *polyfit) Do(ctx context.Context, e parser.Expr, from, until int64, valu
 	for _, a := range arg {
 		r := *a
 		if len(e.Args()) > 2 {
-			r.Name = fmt.Sprintf("polyfit(%s,%d,'%s')", a.Name, degree, e.Args()[2].StringValue())
+			r.Name = helper.FuncName("polyfit", a.Name, degree, helper.Quote(e.Args()[2].StringValue()))
 		} else if len(e.Args()) > 1 {
-			r.Name = fmt.Sprintf("polyfit(%s,%d)", a.Name, degree)
+			r.Name = helper.FuncName("polyfit", a.Name, degree)
 		} else {
-			r.Name = fmt.Sprintf("polyfit(%s)", a.Name)
+			r.Name = helper.FuncName("polyfit", a.Name)
 		}
 		// Extending slice by "offset" so our graph slides into future!
 		r.Values = make([]float64, len(a.Values)+int(offs)/int(r.StepTime))
@@ -2,7 +2,6 @@ package pow
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -31,7 +30,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // pow(seriesList,factor)
 func (f *pow) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +42,7 @@ func (f *pow) Do(ctx context.Context, e parser.Expr, from, until int64, values m
 
 	for _, a := range arg {
 		r := *a
-		r.Name = fmt.Sprintf("pow(%s,%g)", a.Name, factor)
+		r.Name = helper.FuncName("pow", a.Name, factor)
 		r.Values = make([]float64, len(a.Values))
 
 		for i, v := range a.Values {
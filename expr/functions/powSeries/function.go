@@ -0,0 +1,76 @@
+package powSeries
+
+import (
+	"context"
+	"math"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type powSeries struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &powSeries{}
+	functions := []string{"powSeries"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// powSeries(*seriesLists)
+func (f *powSeries) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	series, err := helper.GetSeriesArgsAndRemoveNonExisting(ctx, e, from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return helper.AggregateSeries(ctx, e, series, pow)
+}
+
+// pow computes v[0]^v[1]^... from left to right. Like graphite's safePow, result is absent if any of the values is
+// absent or if the power can't be computed (e.x. 0^-1 or -8^(1/3))
+func pow(v []float64) float64 {
+	r := v[0]
+	for _, x := range v[1:] {
+		if math.IsNaN(r) || math.IsNaN(x) {
+			return math.NaN()
+		}
+		r = math.Pow(r, x)
+	}
+	if math.IsInf(r, 0) {
+		return math.NaN()
+	}
+	return r
+}
+
+// Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
+func (f *powSeries) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"powSeries": {
+			Description: "Takes two or more series and pows their points. A constant line may be\nused.\n\nExample:\n\n.. code-block:: none\n\n  &target=powSeries(Server.instance01.app.requests, Server.instance01.app.replies)",
+			Function:    "powSeries(*seriesLists)",
+			Group:       "Combine",
+			Module:      "graphite.render.functions",
+			Name:        "powSeries",
+			Params: []types.FunctionParam{
+				{
+					Multiple: true,
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+			},
+		},
+	}
+}
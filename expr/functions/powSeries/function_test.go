@@ -0,0 +1,68 @@
+package powSeries
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestPowSeries(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItem{
+		{
+			"powSeries(metric1,metric2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{2, 3, math.NaN(), 4, 0, -8}, 1, now32)},
+				{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{3, 2, 5, math.NaN(), -1, 1.0 / 3}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("powSeries(metric1,metric2)",
+				[]float64{8, 9, math.NaN(), math.NaN(), math.NaN(), math.NaN()}, 1, now32)},
+		},
+		{
+			"powSeries(metric*)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{2, 3, 1}, 1, now32),
+					types.MakeMetricData("metric2", []float64{3, 2, 5}, 1, now32),
+					types.MakeMetricData("metric3", []float64{2, 0.5, 7}, 1, now32),
+				},
+			},
+			[]*types.MetricData{types.MakeMetricData("powSeries(metric*)",
+				[]float64{64, 3, 1}, 1, now32)},
+		},
+		{
+			// missing series are removed from the name, different steps are aligned
+			"powSeries(metric1,metric2,nonexistent)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{2, 2, 3, 3}, 1, 0)},
+				{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{2, 3}, 2, 0)},
+			},
+			[]*types.MetricData{types.MakeMetricData("powSeries(metric1,metric2)",
+				[]float64{4, 27}, 2, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+}
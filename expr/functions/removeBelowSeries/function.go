@@ -2,7 +2,6 @@ package removeBelowSeries
 
 import (
 	"context"
-	"fmt"
 	"math"
 	"strings"
 
@@ -31,9 +30,11 @@ func New(configFile string) []interfaces.FunctionMetadata {
 	return res
 }
 
-// removeBelowValue(seriesLists, n), removeAboveValue(seriesLists, n), removeBelowPercentile(seriesLists, percent), removeAbovePercentile(seriesLists, percent)
+// removeBelowValue(seriesLists, n, inclusive=False), removeAboveValue(seriesLists, n, inclusive=False),
+// removeBelowPercentile(seriesLists, percent), removeAbovePercentile(seriesLists, percent)
+// Values strictly below (above) the threshold are removed as in graphite-web, with inclusive the threshold is removed too
 func (f *removeBelowSeries) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -43,33 +44,41 @@ func (f *removeBelowSeries) Do(ctx context.Context, e parser.Expr, from, until i
 		return nil, err
 	}
 
-	condition := func(v float64, threshold float64) bool {
-		return v < threshold
+	isValue := strings.HasSuffix(e.Target(), "Value")
+	var inclusive bool
+	if isValue {
+		inclusive, err = e.GetBoolNamedOrPosArgDefault("inclusive", 2, false)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	if strings.HasPrefix(e.Target(), "removeAbove") {
-		condition = func(v float64, threshold float64) bool {
-			return v > threshold
-		}
+	var condition func(v float64, threshold float64) bool
+	switch {
+	case strings.HasPrefix(e.Target(), "removeAbove") && inclusive:
+		condition = func(v float64, threshold float64) bool { return v >= threshold }
+	case strings.HasPrefix(e.Target(), "removeAbove"):
+		condition = func(v float64, threshold float64) bool { return v > threshold }
+	case inclusive:
+		condition = func(v float64, threshold float64) bool { return v <= threshold }
+	default:
+		condition = func(v float64, threshold float64) bool { return v < threshold }
 	}
 
 	var results []*types.MetricData
 
 	for _, a := range args {
 		threshold := number
-		if strings.HasSuffix(e.Target(), "Percentile") {
-			var values []float64
-			for _, v := range a.Values {
-				if !math.IsNaN(v) {
-					values = append(values, v)
-				}
-			}
-
-			threshold = consolidations.Percentile(values, number, true)
+		if !isValue {
+			threshold = consolidations.Percentile(a.Values, number, false)
 		}
 
 		r := *a
-		r.Name = fmt.Sprintf("%s(%s, %g)", e.Target(), a.Name, number)
+		if inclusive {
+			r.Name = helper.FuncName(e.Target(), a.Name, number, "inclusive=True")
+		} else {
+			r.Name = helper.FuncName(e.Target(), a.Name, number)
+		}
 		r.Values = make([]float64, len(a.Values))
 
 		for i, v := range a.Values {
@@ -91,8 +100,8 @@ func (f *removeBelowSeries) Do(ctx context.Context, e parser.Expr, from, until i
 func (f *removeBelowSeries) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"removeBelowValue": {
-			Description: "Removes data below the given threshold from the series or list of series provided.\nValues below this threshold are assigned a value of None.",
-			Function:    "removeBelowValue(seriesList, n)",
+			Description: "Removes data below the given threshold from the series or list of series provided.\nValues below this threshold are assigned a value of None.\nIf inclusive is set, values equal to the threshold are removed as well (carbonapi only).",
+			Function:    "removeBelowValue(seriesList, n, inclusive=False)",
 			Group:       "Filter Data",
 			Module:      "graphite.render.functions",
 			Name:        "removeBelowValue",
@@ -107,11 +116,16 @@ func (f *removeBelowSeries) Description() map[string]types.FunctionDescription {
 					Required: true,
 					Type:     types.Integer,
 				},
+				{
+					Default: types.NewSuggestion(false),
+					Name:    "inclusive",
+					Type:    types.Boolean,
+				},
 			},
 		},
 		"removeAboveValue": {
-			Description: "Removes data above the given threshold from the series or list of series provided.\nValues above this threshold are assigned a value of None.",
-			Function:    "removeAboveValue(seriesList, n)",
+			Description: "Removes data above the given threshold from the series or list of series provided.\nValues above this threshold are assigned a value of None.\nIf inclusive is set, values equal to the threshold are removed as well (carbonapi only).",
+			Function:    "removeAboveValue(seriesList, n, inclusive=False)",
 			Group:       "Filter Data",
 			Module:      "graphite.render.functions",
 			Name:        "removeAboveValue",
@@ -126,6 +140,11 @@ func (f *removeBelowSeries) Description() map[string]types.FunctionDescription {
 					Required: true,
 					Type:     types.Integer,
 				},
+				{
+					Default: types.NewSuggestion(false),
+					Name:    "inclusive",
+					Type:    types.Boolean,
+				},
 			},
 		},
 		"removeBelowPercentile": {
@@ -31,7 +31,7 @@ func TestFunction(t *testing.T) {
 			map[parser.MetricRequest][]*types.MetricData{
 				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, -1, 7, 8, 20, 30, math.NaN()}, 1, now32)},
 			},
-			[]*types.MetricData{types.MakeMetricData("removeBelowValue(metric1, 0)",
+			[]*types.MetricData{types.MakeMetricData("removeBelowValue(metric1,0)",
 				[]float64{1, 2, math.NaN(), 7, 8, 20, 30, math.NaN()}, 1, now32)},
 		},
 		{
@@ -39,15 +39,56 @@ func TestFunction(t *testing.T) {
 			map[parser.MetricRequest][]*types.MetricData{
 				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, -1, 7, 8, 20, 30, math.NaN()}, 1, now32)},
 			},
-			[]*types.MetricData{types.MakeMetricData("removeAboveValue(metric1, 10)",
+			[]*types.MetricData{types.MakeMetricData("removeAboveValue(metric1,10)",
 				[]float64{1, 2, -1, 7, 8, math.NaN(), math.NaN(), math.NaN()}, 1, now32)},
 		},
+		{
+			// boundary value is kept by default
+			"removeBelowValue(metric1, 2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, math.NaN()}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("removeBelowValue(metric1,2)",
+				[]float64{math.NaN(), 2, 3, math.NaN()}, 1, now32)},
+		},
+		{
+			"removeBelowValue(metric1, 2, inclusive=true)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, math.NaN()}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("removeBelowValue(metric1,2,inclusive=True)",
+				[]float64{math.NaN(), math.NaN(), 3, math.NaN()}, 1, now32)},
+		},
+		{
+			"removeAboveValue(metric1, 2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, math.NaN()}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("removeAboveValue(metric1,2)",
+				[]float64{1, 2, math.NaN(), math.NaN()}, 1, now32)},
+		},
+		{
+			"removeAboveValue(metric1, 2, true)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, math.NaN()}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("removeAboveValue(metric1,2,inclusive=True)",
+				[]float64{1, math.NaN(), math.NaN(), math.NaN()}, 1, now32)},
+		},
+		{
+			"removeAboveValue(metric1, 2, inclusive=false)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, math.NaN()}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("removeAboveValue(metric1,2)",
+				[]float64{1, 2, math.NaN(), math.NaN()}, 1, now32)},
+		},
 		{
 			"removeBelowPercentile(metric1, 50)",
 			map[parser.MetricRequest][]*types.MetricData{
 				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, -1, 7, 8, 20, 30, math.NaN()}, 1, now32)},
 			},
-			[]*types.MetricData{types.MakeMetricData("removeBelowPercentile(metric1, 50)",
+			[]*types.MetricData{types.MakeMetricData("removeBelowPercentile(metric1,50)",
 				[]float64{math.NaN(), math.NaN(), math.NaN(), 7, 8, 20, 30, math.NaN()}, 1, now32)},
 		},
 		{
@@ -55,7 +96,7 @@ func TestFunction(t *testing.T) {
 			map[parser.MetricRequest][]*types.MetricData{
 				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, -1, 7, 8, 20, 30, math.NaN()}, 1, now32)},
 			},
-			[]*types.MetricData{types.MakeMetricData("removeAbovePercentile(metric1, 50)",
+			[]*types.MetricData{types.MakeMetricData("removeAbovePercentile(metric1,50)",
 				[]float64{1, 2, -1, 7, math.NaN(), math.NaN(), math.NaN(), math.NaN()}, 1, now32)},
 		},
 	}
@@ -0,0 +1,104 @@
+package removeBetweenPercentile
+
+import (
+	"context"
+	"math"
+
+	"github.com/go-graphite/carbonapi/expr/consolidations"
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type removeBetweenPercentile struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &removeBetweenPercentile{}
+	functions := []string{"removeBetweenPercentile"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// removeBetweenPercentile(seriesList, n)
+func (f *removeBetweenPercentile) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return []*types.MetricData{}, nil
+	}
+
+	n, err := e.GetFloatArg(1)
+	if err != nil {
+		return nil, err
+	}
+	if n < 50 {
+		n = 100 - n
+	}
+
+	aligned := helper.AlignSeries(types.CopyMetricDataSlice(args))
+
+	// Envelope is computed for each timestamp independently, over non-NaN values of all series at that timestamp
+	points := len(aligned[0].Values)
+	lowPercentiles := make([]float64, points)
+	highPercentiles := make([]float64, points)
+	column := make([]float64, len(aligned))
+	for i := 0; i < points; i++ {
+		for j, a := range aligned {
+			column[j] = a.Values[i]
+		}
+		lowPercentiles[i] = consolidations.Percentile(column, 100-n, false)
+		highPercentiles[i] = consolidations.Percentile(column, n, false)
+	}
+
+	results := make([]*types.MetricData, 0, len(args))
+	for j, a := range aligned {
+		for i, v := range a.Values {
+			if math.IsNaN(v) {
+				continue
+			}
+			if v <= lowPercentiles[i] || v >= highPercentiles[i] {
+				results = append(results, args[j])
+				break
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
+func (f *removeBetweenPercentile) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"removeBetweenPercentile": {
+			Description: "Removes series that do not have a value lying in the x-percentile of all the values at a moment\n\nFor each timestamp the n-th and (100-n)-th percentiles (n < 50 is treated as 100-n) of all non-null values\nat that timestamp are computed. A series is kept if at least one of its non-null values is less or equal\nto the lower percentile or greater or equal to the upper one, otherwise it is removed.",
+			Function:    "removeBetweenPercentile(seriesList, n)",
+			Group:       "Filter Series",
+			Module:      "graphite.render.functions",
+			Name:        "removeBetweenPercentile",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+				{
+					Name:     "n",
+					Required: true,
+					Type:     types.Float,
+				},
+			},
+		},
+	}
+}
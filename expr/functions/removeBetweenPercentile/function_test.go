@@ -0,0 +1,74 @@
+package removeBetweenPercentile
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestFunction(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	input := map[parser.MetricRequest][]*types.MetricData{
+		{"metric*", 0, 1}: {
+			types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32),
+			types.MakeMetricData("metric2", []float64{2, 3, 4}, 1, now32),
+			types.MakeMetricData("metric3", []float64{3, 4, 5}, 1, now32),
+			types.MakeMetricData("metric4", []float64{4, 5, 6}, 1, now32),
+			types.MakeMetricData("metric5", []float64{10, 1, math.NaN()}, 1, now32),
+		},
+	}
+	want := []*types.MetricData{
+		types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32),
+		types.MakeMetricData("metric4", []float64{4, 5, 6}, 1, now32),
+		types.MakeMetricData("metric5", []float64{10, 1, math.NaN()}, 1, now32),
+	}
+
+	tests := []th.EvalTestItem{
+		{
+			"removeBetweenPercentile(metric*, 10)",
+			input,
+			want,
+		},
+		{
+			"removeBetweenPercentile(metric*, 90)",
+			input,
+			want,
+		},
+		{
+			"removeBetweenPercentile(metric*, 50)",
+			input,
+			[]*types.MetricData{
+				types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32),
+				types.MakeMetricData("metric2", []float64{2, 3, 4}, 1, now32),
+				types.MakeMetricData("metric3", []float64{3, 4, 5}, 1, now32),
+				types.MakeMetricData("metric4", []float64{4, 5, 6}, 1, now32),
+				types.MakeMetricData("metric5", []float64{10, 1, math.NaN()}, 1, now32),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprOrdered(t, &tt)
+		})
+	}
+
+}
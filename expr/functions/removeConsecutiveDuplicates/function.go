@@ -0,0 +1,78 @@
+package removeConsecutiveDuplicates
+
+import (
+	"context"
+	"math"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type removeConsecutiveDuplicates struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &removeConsecutiveDuplicates{}
+	functions := []string{"removeConsecutiveDuplicates"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// removeConsecutiveDuplicates(seriesList)
+func (f *removeConsecutiveDuplicates) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*types.MetricData, 0, len(args))
+	for _, a := range args {
+		r := *a
+		r.Name = helper.FuncName(e.Target(), a.Name)
+		r.Values = make([]float64, len(a.Values))
+
+		// absent points are kept and don't change the value the following points are compared with
+		prev := math.NaN()
+		for i, v := range a.Values {
+			if v == prev {
+				r.Values[i] = math.NaN()
+				continue
+			}
+			r.Values[i] = v
+			if !math.IsNaN(v) {
+				prev = v
+			}
+		}
+		results = append(results, &r)
+	}
+	return results, nil
+}
+
+func (f *removeConsecutiveDuplicates) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"removeConsecutiveDuplicates": {
+			Description: "Takes one metric or a wildcard seriesList and keeps only the points where the value differs\nfrom the previous one, the rest are drawn as None. It shows state changes without repeating\nthe same state at every point.\n\nAbsent values are kept as None and are not compared: the point after them is compared with the\nlast present value, so a value repeated after a gap is removed too.\n\nExample:\n\n.. code-block:: none\n\n  &target=removeConsecutiveDuplicates(service.state)",
+			Function:    "removeConsecutiveDuplicates(seriesList)",
+			Group:       "Filter Data",
+			Module:      "graphite.render.functions.custom",
+			Name:        "removeConsecutiveDuplicates",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+			},
+		},
+	}
+}
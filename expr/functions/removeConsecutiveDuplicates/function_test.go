@@ -0,0 +1,67 @@
+package removeConsecutiveDuplicates
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestRemoveConsecutiveDuplicates(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItem{
+		{
+			"removeConsecutiveDuplicates(metric1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 1, 1, 2, 2, 1, 0, 0, -1}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("removeConsecutiveDuplicates(metric1)",
+				[]float64{1, math.NaN(), math.NaN(), 2, math.NaN(), 1, 0, math.NaN(), -1}, 1, now32)},
+		},
+		{
+			// absent points are kept and don't reset the value points are compared with
+			"removeConsecutiveDuplicates(metric1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{math.NaN(), 3, math.NaN(), 3, math.NaN(), math.NaN(), 4, 4}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("removeConsecutiveDuplicates(metric1)",
+				[]float64{math.NaN(), 3, math.NaN(), math.NaN(), math.NaN(), math.NaN(), 4, math.NaN()}, 1, now32)},
+		},
+		{
+			"removeConsecutiveDuplicates(metric[12])",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric[12]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{5, 5, 6}, 1, now32),
+					types.MakeMetricData("metric2", []float64{0, 1, 1}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("removeConsecutiveDuplicates(metric1)", []float64{5, math.NaN(), 6}, 1, now32),
+				types.MakeMetricData("removeConsecutiveDuplicates(metric2)", []float64{0, 1, math.NaN()}, 1, now32),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+}
@@ -30,7 +30,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // removeEmptySeries(seriesLists, n), removeZeroSeries(seriesLists, n)
 func (f *removeEmptySeries) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -79,9 +79,8 @@ func (f *removeEmptySeries) Description() map[string]types.FunctionDescription {
 					Type:     types.SeriesList,
 				},
 				{
-					Name:     "xFilesFactor",
-					Required: true,
-					Type:     types.Float,
+					Name: "xFilesFactor",
+					Type: types.Float,
 				},
 			},
 		},
@@ -98,9 +97,8 @@ func (f *removeEmptySeries) Description() map[string]types.FunctionDescription {
 					Type:     types.SeriesList,
 				},
 				{
-					Name:     "xFilesFactor",
-					Required: true,
-					Type:     types.Float,
+					Name: "xFilesFactor",
+					Type: types.Float,
 				},
 			},
 		},
@@ -0,0 +1,80 @@
+package removeSeries
+
+import (
+	"context"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type removeSeries struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &removeSeries{}
+	functions := []string{"removeSeries"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// removeSeries(seriesList, *names)
+func (f *removeSeries) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := e.GetStringArgs(1)
+	if err != nil {
+		return nil, err
+	}
+
+	remove := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		remove[name] = struct{}{}
+	}
+
+	var results []*types.MetricData
+	for _, a := range arg {
+		if _, ok := remove[a.Name]; !ok {
+			results = append(results, a)
+		}
+	}
+
+	return results, nil
+}
+
+func (f *removeSeries) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"removeSeries": {
+			Description: "Takes a metric or a wildcard seriesList, followed by one or more series names\nin double quotes. Removes series which names are exactly equal to any of the given names,\nunlike exclude no regular expressions are involved.\n\nExample:\n\n.. code-block:: none\n\n  &target=removeSeries(servers*.instance*.threads.busy,\"servers02.instance01.threads.busy\")",
+			Function:    "removeSeries(seriesList, *names)",
+			Group:       "Filter Series",
+			Module:      "graphite.render.functions.custom",
+			Name:        "removeSeries",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+				{
+					Name:     "names",
+					Required: true,
+					Multiple: true,
+					Type:     types.String,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package removeSeries
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestRemoveSeries(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItem{
+		{
+			"removeSeries(metric*,\"metric.foo\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric.foo", []float64{1, 1, 1}, 1, now32),
+					types.MakeMetricData("metric.foobar", []float64{2, 2, 2}, 1, now32),
+					types.MakeMetricData("metric.bar", []float64{3, 3, 3}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metric.foobar", []float64{2, 2, 2}, 1, now32),
+				types.MakeMetricData("metric.bar", []float64{3, 3, 3}, 1, now32),
+			},
+		},
+		{
+			"removeSeries(metric*,\"metric.foo\",\"metric.bar\",\"metric.baz\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric.foo", []float64{1, 1, 1}, 1, now32),
+					types.MakeMetricData("metric.foobar", []float64{2, 2, 2}, 1, now32),
+					types.MakeMetricData("metric.bar", []float64{3, 3, 3}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metric.foobar", []float64{2, 2, 2}, 1, now32),
+			},
+		},
+		{
+			"removeSeries(metric*,\"metric.f.*\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric.foo", []float64{1, 1, 1}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metric.foo", []float64{1, 1, 1}, 1, now32),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+}
+
+func TestRemoveSeriesErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "removeSeries(metric*)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {types.MakeMetricData("metric.foo", []float64{1, 1, 1}, 1, now32)},
+			},
+			Error: parser.ErrMissingArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
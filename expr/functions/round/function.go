@@ -2,7 +2,6 @@ package round
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -31,7 +30,7 @@ func New(_ string) []interfaces.FunctionMetadata {
 
 // round(seriesList,precision)
 func (f *round) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -45,9 +44,9 @@ func (f *round) Do(ctx context.Context, e parser.Expr, from, until int64, values
 	for _, a := range arg {
 		r := *a
 		if withPrecision {
-			r.Name = fmt.Sprintf("round(%s,%d)", a.Name, precision)
+			r.Name = helper.FuncName("round", a.Name, precision)
 		} else {
-			r.Name = fmt.Sprintf("round(%s)", a.Name)
+			r.Name = helper.FuncName("round", a.Name)
 		}
 		r.Values = make([]float64, len(a.Values))
 
@@ -71,7 +70,7 @@ func doRound(x float64, precision int) float64 {
 func (f *round) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"round": {
-			Description: "Takes one metric or a wildcard seriesList optionally followed by a precision, and rounds each\ndatapoint to the specified precision.\n\nExample:\n\n.. code-block:: none\n\n  &target=round(Server.instance01.threads.busy)\n  &target=round(Server.instance01.threads.busy,2)",
+			Description: "Takes one metric or a wildcard seriesList optionally followed by a precision, and rounds each\ndatapoint to the specified precision (0 by default). Precision can be negative to round to tens, hundreds, etc.\n\nExample:\n\n.. code-block:: none\n\n  &target=round(Server.instance01.threads.busy)\n  &target=round(Server.instance01.threads.busy,2)",
 			Function:    "round(seriesList, precision)",
 			Group:       "Transform",
 			Module:      "graphite.render.functions",
@@ -86,6 +85,7 @@ func (f *round) Description() map[string]types.FunctionDescription {
 					Name:     "precision",
 					Required: false,
 					Type:     types.Integer,
+					Default:  types.NewSuggestion(0),
 				},
 			},
 		},
@@ -22,7 +22,7 @@ func init() {
 	}
 }
 
-func TestHeatMap(t *testing.T) {
+func TestRound(t *testing.T) {
 	now32 := int64(time.Now().Unix())
 
 	tests := []th.EvalTestItem{
@@ -47,6 +47,13 @@ func TestHeatMap(t *testing.T) {
 			},
 			[]*types.MetricData{types.MakeMetricData("round(metric1)", []float64{0, 2, 2, math.NaN(), 91, -525, 245}, 1, now32)},
 		},
+		{
+			"round(metric1, 0)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{0.5, 1.5, 2.298, math.NaN(), 91.019, -524.82, 245}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("round(metric1,0)", []float64{0, 2, 2, math.NaN(), 91, -525, 245}, 1, now32)},
+		},
 		{
 			"round(metric1, -2)",
 			map[parser.MetricRequest][]*types.MetricData{
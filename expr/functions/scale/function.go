@@ -2,7 +2,6 @@ package scale
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
@@ -29,27 +28,60 @@ func New(configFile string) []interfaces.FunctionMetadata {
 }
 
 // scale(seriesList, factor)
+// factor can also be an expression that evaluates to a single series with a single value, e.x. nPercentile(a,50)
 func (f *scale) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	if len(e.Args()) < 1 {
+		return nil, parser.ErrMissingArgument
+	}
+	factor, factorName, err := helper.GetScalarArg(ctx, e, 1, from, until, values)
 	if err != nil {
 		return nil, err
 	}
-	scale, err := e.GetFloatArg(1)
+	timestamp, err := e.GetIntArgDefault(2, 0)
 	if err != nil {
 		return nil, err
 	}
-	timestamp, err := e.GetIntArgDefault(2, 0)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
 
+	transform := scaleTransform(factor, factorName, timestamp)
 	results := make([]*types.MetricData, 0, len(arg))
 	for _, a := range arg {
+		results = append(results, transform(a))
+	}
+	return results, nil
+}
+
+// Transform implements interfaces.StreamingFunction
+func (f *scale) Transform(ctx context.Context, e parser.Expr, from, until int64) (interfaces.SeriesTransform, int64, int64, error) {
+	if len(e.Args()) < 1 {
+		return nil, 0, 0, parser.ErrMissingArgument
+	}
+	// factor computed from a series has to be evaluated first
+	if len(e.Args()) > 1 && (e.Args()[1].IsName() || e.Args()[1].IsFunc()) {
+		return nil, 0, 0, interfaces.ErrNotStreamable
+	}
+	scale, err := e.GetFloatArg(1)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	timestamp, err := e.GetIntArgDefault(2, 0)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return scaleTransform(scale, scale, timestamp), from, until, nil
+}
+
+func scaleTransform(scale float64, scaleName interface{}, timestamp int) interfaces.SeriesTransform {
+	return func(a *types.MetricData) *types.MetricData {
 		r := *a
 		if timestamp == 0 {
-			r.Name = fmt.Sprintf("scale(%s,%g)", a.Name, scale)
+			r.Name = helper.FuncName("scale", a.Name, scaleName)
 		} else {
-			r.Name = fmt.Sprintf("scale(%s,%g,%d)", a.Name, scale, timestamp)
+			r.Name = helper.FuncName("scale", a.Name, scaleName, timestamp)
 		}
 		r.Values = make([]float64, len(a.Values))
 
@@ -62,9 +94,8 @@ func (f *scale) Do(ctx context.Context, e parser.Expr, from, until int64, values
 
 			currentTimestamp += a.StepTime
 		}
-		results = append(results, &r)
+		return &r
 	}
-	return results, nil
 }
 
 // Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
@@ -73,7 +104,8 @@ func (f *scale) Description() map[string]types.FunctionDescription {
 		"scale": {
 			Description: "Takes one metric or a wildcard seriesList followed by a constant, and multiplies the datapoint\n" +
 				"by the constant provided at each point.\n"+
-				"carbonapi extends this function by optional 3-rd parameter that accepts unix-timestamp. If provided, only values with timestamp newer than it will be scaled\n\n"+
+				"carbonapi extends this function by optional 3-rd parameter that accepts unix-timestamp. If provided, only values with timestamp newer than it will be scaled\n"+
+				"carbonapi also accepts a series with a single value as the factor, e.x. scale(a,nPercentile(b,50))\n\n"+
 				"Example:\n\n.. code-block:: none\n\n  &target=scale(Server.instance01.threads.busy,10)\n  &target=scale(Server.instance*.threads.busy,10)\n\n"+
 				"Alias: scaleAfterTimestamp",
 			Function:    "scale(seriesList, factor)",
@@ -102,7 +134,8 @@ func (f *scale) Description() map[string]types.FunctionDescription {
 		"scaleAfterTimestamp": {
 			Description: "Takes one metric or a wildcard seriesList followed by a constant, and multiplies the datapoint\n" +
 				"by the constant provided at each point.\n"+
-				"carbonapi extends this function by optional 3-rd parameter that accepts unix-timestamp. If provided, only values with timestamp newer than it will be scaled\n\n"+
+				"carbonapi extends this function by optional 3-rd parameter that accepts unix-timestamp. If provided, only values with timestamp newer than it will be scaled\n"+
+				"carbonapi also accepts a series with a single value as the factor, e.x. scale(a,nPercentile(b,50))\n\n"+
 				"Example:\n\n.. code-block:: none\n\n  &target=scale(Server.instance01.threads.busy,10)\n  &target=scale(Server.instance*.threads.busy,10)",
 			Function:    "scale(seriesList, factor)",
 			Group:       "Transform",
@@ -34,6 +34,26 @@ func TestFunction(t *testing.T) {
 			},
 			[]*types.MetricData{types.MakeMetricData("scale(metric1,2.5)", []float64{2.5, 5.0, math.NaN(), 10.0, 12.5}, 1, now32)},
 		},
+		{
+			"scale(metric*, 0.5)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32),
+					types.MakeMetricData("metric2", []float64{4, 5, 6}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("scale(metric1,0.5)", []float64{0.5, 1, 1.5}, 1, now32),
+				types.MakeMetricData("scale(metric2,0.5)", []float64{2, 2.5, 3}, 1, now32),
+			},
+		},
+		{
+			"scale(scale(metric1,2),0.5)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("scale(scale(metric1,2),0.5)", []float64{1, 2, 3}, 1, now32)},
+		},
 		{
 			fmt.Sprintf("scale(x.y.z, -2.5, %d)", int(now32+14)),
 			map[parser.MetricRequest][]*types.MetricData{
@@ -69,3 +89,75 @@ func TestFunction(t *testing.T) {
 	}
 
 }
+
+func TestScaleBySeries(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItem{
+		{
+			"scale(metric1,factor)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, math.NaN(), 4}, 1, now32)},
+				{"factor", 0, 1}:  {types.MakeMetricData("factor", []float64{2}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("scale(metric1,factor)", []float64{2, 4, math.NaN(), 8}, 1, now32)},
+		},
+		{
+			// constant series, e.x. result of nPercentile, is used as its value
+			"scale(metric1,factor)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, math.NaN(), 4}, 1, now32)},
+				{"factor", 0, 1}:  {types.MakeMetricData("factor", []float64{0.5, math.NaN(), 0.5, 0.5}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("scale(metric1,factor)", []float64{0.5, 1, math.NaN(), 2}, 1, now32)},
+		},
+		{
+			"scale(metric1,factor)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+				{"factor", 0, 1}:  {types.MakeMetricData("factor", []float64{math.NaN(), math.NaN()}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("scale(metric1,factor)", []float64{math.NaN(), math.NaN()}, 1, now32)},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+}
+
+func TestScaleBySeriesErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "scale(metric1,factor)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+				{"factor", 0, 1}:  {types.MakeMetricData("factor", []float64{1, 2}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+		{
+			Target: "scale(metric1,factor.*)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+				{"factor.*", 0, 1}: {
+					types.MakeMetricData("factor.a", []float64{2}, 1, now32),
+					types.MakeMetricData("factor.b", []float64{2}, 1, now32),
+				},
+			},
+			Error: parser.ErrBadType,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
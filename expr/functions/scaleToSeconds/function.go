@@ -2,7 +2,7 @@ package scaleToSeconds
 
 import (
 	"context"
-	"fmt"
+	"math"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
@@ -30,7 +30,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // scaleToSeconds(seriesList, seconds)
 func (f *scaleToSeconds) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -43,10 +43,14 @@ func (f *scaleToSeconds) Do(ctx context.Context, e parser.Expr, from, until int6
 
 	for _, a := range arg {
 		r := *a
-		r.Name = fmt.Sprintf("scaleToSeconds(%s,%d)", a.Name, int(seconds))
+		r.Name = helper.FuncName("scaleToSeconds", a.Name, seconds)
 		r.Values = make([]float64, len(a.Values))
 
-		factor := seconds / float64(a.StepTime)
+		// series without step can't be scaled, so all its points are NaN
+		factor := math.NaN()
+		if a.StepTime != 0 {
+			factor = seconds / float64(a.StepTime)
+		}
 
 		for i, v := range a.Values {
 			r.Values[i] = v * factor
@@ -74,7 +78,7 @@ func (f *scaleToSeconds) Description() map[string]types.FunctionDescription {
 				{
 					Name:     "seconds",
 					Required: true,
-					Type:     types.Integer,
+					Type:     types.Float,
 				},
 			},
 		},
@@ -1,6 +1,7 @@
 package scaleToSeconds
 
 import (
+	"context"
 	"math"
 	"testing"
 	"time"
@@ -33,6 +34,13 @@ func TestFunction(t *testing.T) {
 			},
 			[]*types.MetricData{types.MakeMetricData("scaleToSeconds(metric1,5)", []float64{5, 10, math.NaN(), 10, 10}, 60, now32)},
 		},
+		{
+			"scaleToSeconds(metric1,0.5)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{10, 20, math.NaN(), 30}, 10, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("scaleToSeconds(metric1,0.5)", []float64{0.5, 1, math.NaN(), 1.5}, 10, now32)},
+		},
 	}
 
 	for _, tt := range tests {
@@ -43,3 +51,33 @@ func TestFunction(t *testing.T) {
 	}
 
 }
+
+func TestZeroStep(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	input := types.MakeMetricData("metric1", []float64{60, 120, math.NaN()}, 60, now32)
+	// th.TestEvalExpr rejects results without step, so result is checked manually
+	input.StepTime = 0
+
+	exp, _, err := parser.ParseExpr("scaleToSeconds(metric1,5)")
+	if err != nil {
+		t.Fatalf("failed to parse expression: %v", err)
+	}
+	res, err := metadata.GetEvaluator().Eval(context.Background(), exp, 0, 1, map[parser.MetricRequest][]*types.MetricData{
+		{"metric1", 0, 1}: {input},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 1 {
+		t.Fatalf("unexpected number of results: got %d, want 1", len(res))
+	}
+	if res[0].Name != "scaleToSeconds(metric1,5)" {
+		t.Errorf("bad name: got %s, want scaleToSeconds(metric1,5)", res[0].Name)
+	}
+	for i, v := range res[0].Values {
+		if !math.IsNaN(v) {
+			t.Errorf("value %d: got %v, want NaN", i, v)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package secondYAxis
+
+import (
+	"context"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type secondYAxis struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &secondYAxis{}
+	functions := []string{"secondYAxis"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// secondYAxis(seriesList)
+func (f *secondYAxis) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	return helper.ForEachSeriesDo(ctx, e, from, until, values, func(a *types.MetricData, r *types.MetricData) *types.MetricData {
+		copy(r.Values, a.Values)
+		r.SecondYAxis = true
+		return r
+	})
+}
+
+// Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
+func (f *secondYAxis) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"secondYAxis": {
+			Name: "secondYAxis",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+			},
+			Module:      "graphite.render.functions",
+			Description: "Graph the series on the secondary Y axis.",
+			Function:    "secondYAxis(seriesList)",
+			Group:       "Graph",
+		},
+	}
+}
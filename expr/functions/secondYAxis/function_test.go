@@ -0,0 +1,61 @@
+package secondYAxis
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestFunction(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItem{
+		{
+			"secondYAxis(metric1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{0, 1, math.NaN(), -1}, 1, now32)},
+			},
+			[]*types.MetricData{th.WithGraphOptions(
+				types.MakeMetricData("secondYAxis(metric1)", []float64{0, 1, math.NaN(), -1}, 1, now32),
+				types.GraphOptions{SecondYAxis: true},
+			)},
+		},
+		{
+			"secondYAxis(metric*)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2}, 1, now32),
+					types.MakeMetricData("metric2", []float64{3, 4}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				th.WithGraphOptions(types.MakeMetricData("secondYAxis(metric1)", []float64{1, 2}, 1, now32), types.GraphOptions{SecondYAxis: true}),
+				th.WithGraphOptions(types.MakeMetricData("secondYAxis(metric2)", []float64{3, 4}, 1, now32), types.GraphOptions{SecondYAxis: true}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+
+}
@@ -42,7 +42,7 @@ func (f *seriesList) Do(ctx context.Context, e parser.Expr, from, until int64, v
 		return nil, err
 	}
 
-	numerators, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	numerators, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		if merry.Is(err, parser.ErrSeriesDoesNotExist) && !math.IsNaN(defaultValue) {
 			useConstant = true
@@ -61,7 +61,7 @@ func (f *seriesList) Do(ctx context.Context, e parser.Expr, from, until int64, v
 	}
 	sort.Slice(numerators, func(i, j int) bool { return numerators[i].Name < numerators[j].Name })
 
-	denominators, err := helper.GetSeriesArg(e.Args()[1], from, until, values)
+	denominators, err := helper.GetSeriesArg(ctx, e.Args()[1], from, until, values)
 	if err != nil {
 		if merry.Is(err, parser.ErrSeriesDoesNotExist) && !math.IsNaN(defaultValue) && !useConstant {
 			useConstant = true
@@ -84,6 +84,10 @@ func (f *seriesList) Do(ctx context.Context, e parser.Expr, from, until int64, v
 		return nil, err
 	}
 
+	if !useMatching && !useConstant && !sizeMatch {
+		return nil, types.ErrListLengthMismatch
+	}
+
 	var results []*types.MetricData
 	functionName := e.Target()[:len(e.Target())-len("Lists")]
 
@@ -109,7 +113,7 @@ func (f *seriesList) Do(ctx context.Context, e parser.Expr, from, until int64, v
 		}
 		for _, s := range single {
 			r := *s
-			r.Name = fmt.Sprintf("%s(%s,%s)", functionName, s.Name, s.Name)
+			r.Name = helper.FuncName(functionName, s.Name, s.Name)
 			r.Values = make([]float64, len(s.Values))
 			for i, v := range s.Values {
 				if math.IsNaN(v) {
@@ -172,7 +176,7 @@ func (f *seriesList) Do(ctx context.Context, e parser.Expr, from, until int64, v
 		} else {
 			denomName = strconv.FormatFloat(defaultValue, 'f', -1, 64)
 		}
-		r.Name = fmt.Sprintf("%s(%s,%s)", functionName, numerator.Name, denomName)
+		r.Name = helper.FuncName(functionName, numerator.Name, denomName)
 		r.Values = make([]float64, len(numerator.Values))
 
 		for i, v := range numerator.Values {
@@ -189,7 +193,7 @@ func (f *seriesList) Do(ctx context.Context, e parser.Expr, from, until int64, v
 
 			switch e.Target() {
 			case "divideSeriesLists":
-				if denominator.Values[i] == 0 {
+				if denomValue == 0 {
 					r.Values[i] = math.NaN()
 					continue
 				}
@@ -223,6 +227,10 @@ func (f *seriesList) Description() map[string]types.FunctionDescription {
 					Required: true,
 					Type:     types.SeriesList,
 				},
+				{
+					Name: "matching",
+					Type: types.Boolean,
+				},
 				{
 					Name:     "default",
 					Required: false,
@@ -231,7 +239,7 @@ func (f *seriesList) Description() map[string]types.FunctionDescription {
 			},
 		},
 		"diffSeriesLists": {
-			Description: "Iterates over a two lists and substracts list1[0} by list2[0}, list1[1} by list2[1} and so on.\nThe lists need to be the same length\nCarbonAPI-specific extension allows to specify default value as 3rd optional argument in case series doesn't exist or value is missing",
+			Description: "Iterates over a two lists and substracts list1[0} by list2[0}, list1[1} by list2[1} and so on.\nThe lists need to be the same length, or the second list can be a single series that is subtracted from every series of the first one\nCarbonAPI-specific extension allows to specify default value as 3rd optional argument in case series doesn't exist or value is missing",
 			Function:    "diffSeriesLists(firstSeriesList, secondSeriesList)",
 			Group:       "Combine",
 			Module:      "graphite.render.functions.custom",
@@ -247,6 +255,10 @@ func (f *seriesList) Description() map[string]types.FunctionDescription {
 					Required: true,
 					Type:     types.SeriesList,
 				},
+				{
+					Name: "matching",
+					Type: types.Boolean,
+				},
 				{
 					Name:     "default",
 					Required: false,
@@ -271,6 +283,10 @@ func (f *seriesList) Description() map[string]types.FunctionDescription {
 					Required: true,
 					Type:     types.SeriesList,
 				},
+				{
+					Name: "matching",
+					Type: types.Boolean,
+				},
 				{
 					Name:     "default",
 					Required: false,
@@ -295,6 +311,10 @@ func (f *seriesList) Description() map[string]types.FunctionDescription {
 					Required: true,
 					Type:     types.SeriesList,
 				},
+				{
+					Name: "matching",
+					Type: types.Boolean,
+				},
 				{
 					Name:     "default",
 					Required: false,
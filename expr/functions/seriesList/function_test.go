@@ -45,6 +45,35 @@ func TestFunction(t *testing.T) {
 	}
 }
 
+func TestFunctionErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "divideSeriesLists(metric[12],metric[345],false)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric[12]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5}, 1, now32),
+					types.MakeMetricData("metric2", []float64{2, 4, 6, 8, 10}, 1, now32),
+				},
+				{"metric[345]", 0, 1}: {
+					types.MakeMetricData("metric3", []float64{1, 2, 3, 4, 5}, 1, now32),
+					types.MakeMetricData("metric4", []float64{2, 4, 6, 8, 10}, 1, now32),
+					types.MakeMetricData("metric5", []float64{2, 4, 6, 8, 10}, 1, now32),
+				},
+			},
+			Error: types.ErrListLengthMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
+
 func TestSeriesListMultiReturn(t *testing.T) {
 	now32 := int64(time.Now().Unix())
 
@@ -91,6 +120,57 @@ func TestSeriesListMultiReturn(t *testing.T) {
 				"diffSeries(metric2,metric2)": {types.MakeMetricData("diffSeries(metric2,metric2)", []float64{0, 0, 0, 0, 0}, 1, now32)},
 			},
 		},
+		{
+			"diffSeriesLists(host.*.requests,baseline.*.requests)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"host.*.requests", 0, 1}: {
+					types.MakeMetricData("host.a.requests", []float64{10, 20, 30}, 1, now32),
+					types.MakeMetricData("host.b.requests", []float64{5, 6, 7}, 1, now32),
+				},
+				{"baseline.*.requests", 0, 1}: {
+					types.MakeMetricData("baseline.a.requests", []float64{1, 2, 3}, 1, now32),
+					types.MakeMetricData("baseline.b.requests", []float64{1, 1, 2}, 1, now32),
+				},
+			},
+			"diffSeriesListsNToN",
+			map[string][]*types.MetricData{
+				"diffSeries(host.a.requests,baseline.a.requests)": {types.MakeMetricData("diffSeries(host.a.requests,baseline.a.requests)", []float64{9, 18, 27}, 1, now32)},
+				"diffSeries(host.b.requests,baseline.b.requests)": {types.MakeMetricData("diffSeries(host.b.requests,baseline.b.requests)", []float64{4, 5, 5}, 1, now32)},
+			},
+		},
+		{
+			"diffSeriesLists(metric[12],baseline)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric[12]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5}, 1, now32),
+					types.MakeMetricData("metric2", []float64{2, 4, 6, 8, 10}, 1, now32),
+				},
+				{"baseline", 0, 1}: {types.MakeMetricData("baseline", []float64{1, 1, 1, 1, 1}, 1, now32)},
+			},
+			"diffSeriesListsNToOne",
+			map[string][]*types.MetricData{
+				"diffSeries(metric1,baseline)": {types.MakeMetricData("diffSeries(metric1,baseline)", []float64{0, 1, 2, 3, 4}, 1, now32)},
+				"diffSeries(metric2,baseline)": {types.MakeMetricData("diffSeries(metric2,baseline)", []float64{1, 3, 5, 7, 9}, 1, now32)},
+			},
+		},
+		{
+			"divideSeriesLists(metric[12],metric[13],true,2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric[12]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5}, 1, now32),
+					types.MakeMetricData("metric2", []float64{2, 4, 6, 8, 10}, 1, now32),
+				},
+				{"metric[13]", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5}, 1, now32),
+					types.MakeMetricData("metric3", []float64{2, 4, 6, 8, 10}, 1, now32),
+				},
+			},
+			"divideSeriesListMatchingWithDefault",
+			map[string][]*types.MetricData{
+				"divideSeries(metric1,metric1)": {types.MakeMetricData("divideSeries(metric1,metric1)", []float64{1, 1, 1, 1, 1}, 1, now32)},
+				"divideSeries(metric2,2)":       {types.MakeMetricData("divideSeries(metric2,2)", []float64{1, 2, 3, 4, 5}, 1, now32)},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,102 @@
+package sigmoid
+
+import (
+	"context"
+	"math"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type sigmoid struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &sigmoid{}
+	functions := []string{"sigmoid", "logit"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+var transforms = map[string]func(float64) float64{
+	"sigmoid": func(v float64) float64 {
+		return 1 / (1 + math.Exp(-v))
+	},
+	"logit": func(v float64) float64 {
+		if v <= 0 || v >= 1 {
+			return math.NaN()
+		}
+		return math.Log(v / (1 - v))
+	},
+}
+
+// sigmoid(seriesList), logit(seriesList)
+func (f *sigmoid) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+	transform := transforms[e.Target()]
+
+	var results []*types.MetricData
+
+	for _, a := range arg {
+		r := *a
+		r.Name = helper.FuncName(e.Target(), a.Name)
+		r.Values = make([]float64, len(a.Values))
+
+		for i, v := range a.Values {
+			if math.IsNaN(v) {
+				r.Values[i] = math.NaN()
+				continue
+			}
+			r.Values[i] = transform(v)
+		}
+		results = append(results, &r)
+	}
+	return results, nil
+}
+
+// Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
+func (f *sigmoid) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"sigmoid": {
+			Description: "Takes one metric or a wildcard seriesList and applies the sigmoid\nfunction `1 / (1 + exp(-x))` to each datapoint.\n\nExample:\n\n.. code-block:: none\n\n  &target=sigmoid(Server.instance01.threads.busy)",
+			Function:    "sigmoid(seriesList)",
+			Group:       "Transform",
+			Module:      "graphite.render.functions",
+			Name:        "sigmoid",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+			},
+		},
+		"logit": {
+			Description: "Takes one metric or a wildcard seriesList and applies the logit\nfunction `log(x / (1 - x))` to each datapoint.\nDatapoints outside of (0, 1) range are replaced with None.\n\nExample:\n\n.. code-block:: none\n\n  &target=logit(Server.instance01.threads.busy)",
+			Function:    "logit(seriesList)",
+			Group:       "Transform",
+			Module:      "graphite.render.functions",
+			Name:        "logit",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package sigmoid
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestFunction(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItem{
+		{
+			"sigmoid(metric1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{0, 1, -1, math.NaN(), 1000}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("sigmoid(metric1)",
+				[]float64{0.5, 0.7310585786300049, 0.2689414213699951, math.NaN(), 1}, 1, now32)},
+		},
+		{
+			"logit(metric1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{0.5, 0.7310585786300049, 0, 1, -1, 2, math.NaN()}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("logit(metric1)",
+				[]float64{0, 1, math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()}, 1, now32)},
+		},
+		{
+			"logit(sigmoid(metric1))",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{-2, 0, 3, math.NaN()}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("logit(sigmoid(metric1))",
+				[]float64{-2, 0, 3, math.NaN()}, 1, now32)},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+
+}
@@ -38,7 +38,7 @@ func (f *slo) Do(ctx context.Context, e parser.Expr, from, until int64, values m
 	)
 
 	// requested data points' window
-	argsWindowed, err = helper.GetSeriesArg(e.Args()[0], from, until, values)
+	argsWindowed, err = helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if len(argsWindowed) == 0 || err != nil {
 		return nil, err
 	}
@@ -58,7 +58,7 @@ func (f *slo) Do(ctx context.Context, e parser.Expr, from, until int64, values m
 	windowSize = until - from
 	if bucketSize > windowSize && !(from == 0 && until == 1) {
 		delta = bucketSize - windowSize
-		argsExtended, err = helper.GetSeriesArg(e.Args()[0], from-delta, until, values)
+		argsExtended, err = helper.GetSeriesArg(ctx, e.Args()[0], from-delta, until, values)
 
 		if err != nil {
 			return nil, err
@@ -108,9 +108,9 @@ func (f *slo) Do(ctx context.Context, e parser.Expr, from, until int64, values m
 		)
 
 		if isErrorBudget {
-			resultName = fmt.Sprintf("sloErrorBudget(%s, %s, %s, %v, %v)", argWnd.Name, intervalStringValue, methodName, value, objective)
+			resultName = helper.FuncName("sloErrorBudget", argWnd.Name, intervalStringValue, methodName, value, objective)
 		} else {
-			resultName = fmt.Sprintf("slo(%s, %s, %s, %v)", argWnd.Name, intervalStringValue, methodName, value)
+			resultName = helper.FuncName("slo", argWnd.Name, intervalStringValue, methodName, value)
 		}
 
 		// buckets qty is calculated based on requested window
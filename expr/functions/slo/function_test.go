@@ -45,7 +45,7 @@ func TestSlo(t *testing.T) {
 			},
 			[]*types.MetricData{
 				types.MakeMetricData(
-					"slo(x.y.z, 10sec, above, 2)",
+					"slo(x.y.z,10sec,above,2)",
 					// (1, 2) -> 0
 					// (3, 4) -> 1
 					// (5, nan) -> 1: all not-null elements are above 2
@@ -76,7 +76,7 @@ func TestSlo(t *testing.T) {
 			},
 			[]*types.MetricData{
 				types.MakeMetricData(
-					"slo(x.y.z, 4sec, below, 6)",
+					"slo(x.y.z,4sec,below,6)",
 					// all data points are nan because interval (4 sec) is less than step time (5 sec)
 					[]float64{nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan},
 					4,
@@ -122,7 +122,7 @@ func TestSloErrorBudget(t *testing.T) {
 			},
 			[]*types.MetricData{
 				types.MakeMetricData(
-					"sloErrorBudget(some.data.series, 5sec, aboveOrEqual, 2, 0.6)",
+					"sloErrorBudget(some.data.series,5sec,aboveOrEqual,2,0.6)",
 					[]float64{
 						0,     // 3 of 5 points match, slo is 0.6, no error budget remains
 						-1.75, // 1 of 4 points match, slo is 0.6, error budget is exceeded by (0.25 - 0.6) * 5 = -1.75
@@ -157,7 +157,7 @@ func TestSloErrorBudget(t *testing.T) {
 			},
 			[]*types.MetricData{
 				types.MakeMetricData(
-					"sloErrorBudget(some.data.series, 4sec, aboveOrEqual, 2, 0.6)",
+					"sloErrorBudget(some.data.series,4sec,aboveOrEqual,2,0.6)",
 					[]float64{
 						// all data points are nan because interval (4 sec) is less than step time (5 sec)
 						nan, nan, nan, nan, nan, nan, nan, nan,
@@ -2,14 +2,17 @@ package smartSummarize
 
 import (
 	"context"
-	"fmt"
 	"math"
+	"strconv"
+
+	"github.com/ansel1/merry"
 
 	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/pkg/parser"
+	utilctx "github.com/go-graphite/carbonapi/util/ctx"
 	pb "github.com/go-graphite/protocol/carbonapi_v3_pb"
 )
 
@@ -31,10 +34,10 @@ func New(configFile string) []interfaces.FunctionMetadata {
 	return res
 }
 
-// smartSummarize(seriesList, intervalString, alignToInterval=False)
+// smartSummarize(seriesList, intervalString, func='sum', alignTo=None, xFilesFactor=0)
 func (f *smartSummarize) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
 	// TODO(dgryski): make sure the arrays are all the same 'size'
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -55,6 +58,18 @@ func (f *smartSummarize) Do(ctx context.Context, e parser.Expr, from, until int6
 		return nil, err
 	}
 
+	xFilesFactor, err := e.GetFloatNamedOrPosArgDefault("xFilesFactor", 4, 0)
+	if err != nil {
+		return nil, err
+	}
+	if xFilesFactor < 0 || xFilesFactor > 1 {
+		return nil, merry.WithMessagef(parser.ErrBadType, "%s: xFilesFactor should be between 0 and 1, got %v", parser.ErrBadType, xFilesFactor)
+	}
+	_, xffOk := e.NamedArgs()["xFilesFactor"]
+	if !xffOk {
+		xffOk = len(e.Args()) > 4
+	}
+
 	start := args[0].StartTime
 	stop := args[0].StopTime
 	if alignToInterval != "" {
@@ -62,18 +77,23 @@ func (f *smartSummarize) Do(ctx context.Context, e parser.Expr, from, until int6
 		if err != nil {
 			return nil, err
 		}
-		start = helper.AlignStartToInterval(start, stop, int64(interval))
+		start = helper.AlignStartToInterval(start, stop, int64(interval), utilctx.GetTimezone(ctx))
 	}
 
 	buckets := helper.GetBuckets(start, stop, bucketSize)
 	results := make([]*types.MetricData, 0, len(args))
 	for _, arg := range args {
-		name := fmt.Sprintf("smartSummarize(%s,'%s','%s'", arg.Name, e.Args()[1].StringValue(), summarizeFunction)
+		if err := ctx.Err(); err != nil {
+			return nil, merry.Wrap(err)
+		}
+		nameArgs := []interface{}{arg.Name, helper.Quote(e.Args()[1].StringValue()), helper.Quote(summarizeFunction)}
 		if alignToInterval != "" {
-			name += fmt.Sprintf(",'%s')", alignToInterval)
-		} else {
-			name += ")"
+			nameArgs = append(nameArgs, helper.Quote(alignToInterval))
+		}
+		if xffOk {
+			nameArgs = append(nameArgs, "xFilesFactor="+strconv.FormatFloat(xFilesFactor, 'g', -1, 64))
 		}
+		name := helper.FuncName("smartSummarize", nameArgs...)
 
 		r := types.MetricData{
 			FetchResponse: pb.FetchResponse{
@@ -89,7 +109,12 @@ func (f *smartSummarize) Do(ctx context.Context, e parser.Expr, from, until int6
 
 		t := arg.StartTime // unadjusted
 		bucketEnd := start + bucketSize
-		values := make([]float64, 0, bucketSize/arg.StepTime)
+		// buckets on the edges of the series are checked against the points of a full bucket
+		bucketPoints := int(bucketSize / arg.StepTime)
+		if bucketPoints < 1 {
+			bucketPoints = 1
+		}
+		values := make([]float64, 0, bucketPoints)
 		ridx := 0
 		bucketItems := 0
 		for _, v := range arg.Values {
@@ -105,7 +130,10 @@ func (f *smartSummarize) Do(ctx context.Context, e parser.Expr, from, until int6
 			}
 
 			if t >= bucketEnd {
-				rv := consolidations.SummarizeValues(summarizeFunction, values, arg.XFilesFactor)
+				rv := math.NaN()
+				if consolidations.XFilesFactor(len(values), bucketPoints, float32(xFilesFactor)) {
+					rv = consolidations.SummarizeValues(summarizeFunction, values, arg.XFilesFactor)
+				}
 
 				r.Values[ridx] = rv
 				ridx++
@@ -117,7 +145,10 @@ func (f *smartSummarize) Do(ctx context.Context, e parser.Expr, from, until int6
 
 		// last partial bucket
 		if bucketItems > 0 {
-			rv := consolidations.SummarizeValues(summarizeFunction, values, arg.XFilesFactor)
+			rv := math.NaN()
+			if consolidations.XFilesFactor(len(values), bucketPoints, float32(xFilesFactor)) {
+				rv = consolidations.SummarizeValues(summarizeFunction, values, arg.XFilesFactor)
+			}
 			r.Values[ridx] = rv
 		}
 
@@ -130,8 +161,8 @@ func (f *smartSummarize) Do(ctx context.Context, e parser.Expr, from, until int6
 func (f *smartSummarize) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"smartSummarize": {
-			Description: "Smarter version of summarize.\nThe alignToFrom boolean parameter has been replaced by alignTo and no longer has any effect. Alignment can be to years, months, weeks, days, hours, and minutes.\nThis function can be used with aggregation functions average, median, sum, min, max, diff, stddev, count, range, multiply & last.",
-			Function:    "smartSummarize(seriesList, intervalString, func='sum', alignTo=None)",
+			Description: "Smarter version of summarize.\nThe alignToFrom boolean parameter has been replaced by alignTo and no longer has any effect. Alignment can be to years, months, weeks, days, hours, and minutes.\nThis function can be used with aggregation functions average, median, sum, min, max, diff, stddev, count, range, multiply & last.\nBuckets with a ratio of non-null points to the points of a full bucket below xFilesFactor are set to None (carbonapi only).",
+			Function:    "smartSummarize(seriesList, intervalString, func='sum', alignTo=None, xFilesFactor=0)",
 			Group:       "Transform",
 			Module:      "graphite.render.functions",
 			Name:        "smartSummarize",
@@ -166,6 +197,11 @@ func (f *smartSummarize) Description() map[string]types.FunctionDescription {
 					),
 					Type: types.Interval,
 				},
+				{
+					Default: types.NewSuggestion(0),
+					Name:    "xFilesFactor",
+					Type:    types.Float,
+				},
 			},
 		},
 	}
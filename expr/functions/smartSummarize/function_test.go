@@ -1,6 +1,7 @@
 package smartSummarize
 
 import (
+	"math"
 	"testing"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -78,6 +79,17 @@ func TestEvalSummarize(t *testing.T) {
 			0,
 			240,
 		},
+		{
+			"smartSummarize(metric1,'1minute','sum',xFilesFactor=0.9)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", generateValues(0, 90, 1), 1, 0)},
+			},
+			[]float64{1770, math.NaN()},
+			"smartSummarize(metric1,'1minute','sum',xFilesFactor=0.9)",
+			60,
+			0,
+			90,
+		},
 	}
 
 	for _, tt := range tests {
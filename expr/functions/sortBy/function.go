@@ -3,8 +3,11 @@ package sortBy
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
 
+	"github.com/ansel1/merry"
+
 	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
@@ -30,23 +33,26 @@ func New(configFile string) []interfaces.FunctionMetadata {
 	return res
 }
 
-// sortByMaxima(seriesList), sortByMinima(seriesList), sortByTotal(seriesList)
+// sortByMaxima(seriesList), sortByMinima(seriesList), sortByTotal(seriesList), sortBy(seriesList, func='average', reverse=False)
 func (f *sortBy) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	original, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	original, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
 
-	reverse, err := e.GetBoolArgDefault(2, false)
+	reverse, err := e.GetBoolNamedOrPosArgDefault("reverse", 2, false)
 	if err != nil {
 		return nil, err
 	}
 	ascending := !reverse
 
-	sortByFunc, err := e.GetStringArgDefault(1, "average")
+	sortByFunc, err := e.GetStringNamedOrPosArgDefault("func", 1, "average")
 	if err != nil {
 		return nil, err
 	}
+	if !consolidations.IsSummarizer(sortByFunc) {
+		return nil, merry.WithMessagef(parser.ErrBadType, "%s: unsupported aggregation function %q", parser.ErrBadType, sortByFunc)
+	}
 
 	aggFuncMap := map[string]struct {
 		name      string
@@ -72,22 +78,39 @@ func (f *sortBy) Do(ctx context.Context, e parser.Expr, from, until int64, value
 	return doSort(aggFunc.name, ascending, original), nil
 }
 
+// doSort sorts series by aggregated values keeping the order of the series with equal values. Series without any values,
+// or ones that aggregate to NaN, are placed last in original order whatever the direction is.
 func doSort(aggFuncName string, ascending bool, original []*types.MetricData) []*types.MetricData {
-	arg := make([]*types.MetricData, len(original))
-	copy(arg, original)
-	vals := make([]float64, len(arg))
-
-	for i, a := range arg {
-		vals[i] = consolidations.SummarizeValues(aggFuncName, a.Values, a.XFilesFactor)
+	arg := make([]*types.MetricData, 0, len(original))
+	vals := make([]float64, 0, len(original))
+	var absent []*types.MetricData
+
+	for _, a := range original {
+		v := consolidations.SummarizeValues(aggFuncName, a.Values, a.XFilesFactor)
+		if math.IsNaN(v) || !hasValues(a.Values) {
+			absent = append(absent, a)
+			continue
+		}
+		arg = append(arg, a)
+		vals = append(vals, v)
 	}
 
 	if ascending {
-		sort.Sort(helper.ByVals{Vals: vals, Series: arg})
+		sort.Stable(helper.ByVals{Vals: vals, Series: arg})
 	} else {
-		sort.Sort(sort.Reverse(helper.ByVals{Vals: vals, Series: arg}))
+		sort.Stable(sort.Reverse(helper.ByVals{Vals: vals, Series: arg}))
 	}
 
-	return arg
+	return append(arg, absent...)
+}
+
+func hasValues(values []float64) bool {
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			return true
+		}
+	}
+	return false
 }
 
 // Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
@@ -1,6 +1,7 @@
 package sortBy
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -85,6 +86,22 @@ func TestFunction(t *testing.T) {
 				types.MakeMetricData("metricB", []float64{3, 4, 5, 6, 7, 8}, 1, now32),
 			},
 		},
+		{
+			// standard deviation of a single point is NaN, so such series are placed last
+			"sortBy(metric*, 'stddev')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metricA", []float64{math.NaN(), 3, math.NaN()}, 1, now32),
+					types.MakeMetricData("metricB", []float64{1, 3, math.NaN()}, 1, now32),
+					types.MakeMetricData("metricC", []float64{1, 1, math.NaN()}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metricC", []float64{1, 1, math.NaN()}, 1, now32),
+				types.MakeMetricData("metricB", []float64{1, 3, math.NaN()}, 1, now32),
+				types.MakeMetricData("metricA", []float64{math.NaN(), 3, math.NaN()}, 1, now32),
+			},
+		},
 		{
 			"sortBy(metric*, 'median')",
 			map[parser.MetricRequest][]*types.MetricData{
@@ -116,6 +133,56 @@ func TestFunction(t *testing.T) {
 				types.MakeMetricData("metricA", []float64{0, 0, 0, 0, 0, 0}, 1, now32),
 			},
 		},
+		{
+			// equal values keep their order, absent series are last
+			"sortBy(metric*, 'sum')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metricA", []float64{math.NaN(), math.NaN()}, 1, now32),
+					types.MakeMetricData("metricB", []float64{2, 2}, 1, now32),
+					types.MakeMetricData("metricC", []float64{1, 3}, 1, now32),
+					types.MakeMetricData("metricD", []float64{1, math.NaN()}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metricD", []float64{1, math.NaN()}, 1, now32),
+				types.MakeMetricData("metricB", []float64{2, 2}, 1, now32),
+				types.MakeMetricData("metricC", []float64{1, 3}, 1, now32),
+				types.MakeMetricData("metricA", []float64{math.NaN(), math.NaN()}, 1, now32),
+			},
+		},
+		{
+			"sortBy(metric*, func='sum', reverse=true)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metricA", []float64{math.NaN(), math.NaN()}, 1, now32),
+					types.MakeMetricData("metricB", []float64{2, 2}, 1, now32),
+					types.MakeMetricData("metricC", []float64{1, 3}, 1, now32),
+					types.MakeMetricData("metricD", []float64{1, math.NaN()}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metricB", []float64{2, 2}, 1, now32),
+				types.MakeMetricData("metricC", []float64{1, 3}, 1, now32),
+				types.MakeMetricData("metricD", []float64{1, math.NaN()}, 1, now32),
+				types.MakeMetricData("metricA", []float64{math.NaN(), math.NaN()}, 1, now32),
+			},
+		},
+		{
+			"sortByMaxima(metric*)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metricA", []float64{math.NaN(), math.NaN()}, 1, now32),
+					types.MakeMetricData("metricB", []float64{2, 5}, 1, now32),
+					types.MakeMetricData("metricC", []float64{5, 1}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("metricB", []float64{2, 5}, 1, now32),
+				types.MakeMetricData("metricC", []float64{5, 1}, 1, now32),
+				types.MakeMetricData("metricA", []float64{math.NaN(), math.NaN()}, 1, now32),
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -126,3 +193,16 @@ func TestFunction(t *testing.T) {
 	}
 
 }
+
+func TestSortByErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tt := th.EvalTestItemWithError{
+		Target: "sortBy(metric*, 'foo')",
+		M: map[parser.MetricRequest][]*types.MetricData{
+			{"metric*", 0, 1}: {types.MakeMetricData("metricA", []float64{1, 2}, 1, now32)},
+		},
+		Error: parser.ErrBadType,
+	}
+	th.TestEvalExprWithError(t, &tt)
+}
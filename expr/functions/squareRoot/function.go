@@ -2,7 +2,6 @@ package squareRoot
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -31,7 +30,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // squareRoot(seriesList)
 func (f *squareRoot) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +38,7 @@ func (f *squareRoot) Do(ctx context.Context, e parser.Expr, from, until int64, v
 
 	for _, a := range arg {
 		r := *a
-		r.Name = fmt.Sprintf("squareRoot(%s)", a.Name)
+		r.Name = helper.FuncName("squareRoot", a.Name)
 		r.Values = make([]float64, len(a.Values))
 
 		for i, v := range a.Values {
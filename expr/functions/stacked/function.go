@@ -0,0 +1,78 @@
+package stacked
+
+import (
+	"context"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+type stacked struct {
+	interfaces.FunctionBase
+}
+
+func GetOrder() interfaces.Order {
+	return interfaces.Any
+}
+
+func New(configFile string) []interfaces.FunctionMetadata {
+	res := make([]interfaces.FunctionMetadata, 0)
+	f := &stacked{}
+	functions := []string{"stacked"}
+	for _, n := range functions {
+		res = append(res, interfaces.FunctionMetadata{Name: n, F: f})
+	}
+	return res
+}
+
+// stacked(seriesList, stackName="__DEFAULT__")
+func (f *stacked) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
+	if err != nil {
+		return nil, err
+	}
+
+	stackName, err := e.GetStringNamedOrPosArgDefault("stackName", 1, types.DefaultStackName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unlike graphite-web, values are not summed up here: the renderer stacks series with the same StackName itself
+	results := make([]*types.MetricData, 0, len(args))
+	for _, a := range args {
+		r := a.Copy(true)
+		r.Name = helper.FuncName("stacked", a.Name)
+		r.Stacked = true
+		r.StackName = stackName
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
+func (f *stacked) Description() map[string]types.FunctionDescription {
+	return map[string]types.FunctionDescription{
+		"stacked": {
+			Name: "stacked",
+			Params: []types.FunctionParam{
+				{
+					Name:     "seriesList",
+					Required: true,
+					Type:     types.SeriesList,
+				},
+				{
+					Name:    "stackName",
+					Type:    types.String,
+					Default: types.NewSuggestion(types.DefaultStackName),
+				},
+			},
+			Module:      "graphite.render.functions",
+			Description: "Takes one metric or a wildcard seriesList and change them so they are\nstacked. This is a way of stacking just a couple of metrics without having\nto use the stacked area mode (that stacks everything). By means of this a mixed\nstacked and non stacked graph can be made\n\nIt can also take an optional argument with a name of the stack, in case there is\nmore than one, e.g. for input and output metrics.\n\nExample:\n\n.. code-block:: none\n\n  &target=stacked(company.server.application01.ifconfig.TXPackets, 'tx')",
+			Function:    "stacked(seriesLists, stackName='__DEFAULT__')",
+			Group:       "Graph",
+		},
+	}
+}
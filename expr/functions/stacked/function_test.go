@@ -0,0 +1,77 @@
+package stacked
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestFunction(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItem{
+		{
+			"stacked(metric*)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, math.NaN(), 3}, 1, now32),
+					types.MakeMetricData("metric2", []float64{4, 5, 6}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				th.WithGraphOptions(
+					types.MakeMetricData("stacked(metric1)", []float64{1, math.NaN(), 3}, 1, now32),
+					types.GraphOptions{Stacked: true, StackName: types.DefaultStackName},
+				),
+				th.WithGraphOptions(
+					types.MakeMetricData("stacked(metric2)", []float64{4, 5, 6}, 1, now32),
+					types.GraphOptions{Stacked: true, StackName: types.DefaultStackName},
+				),
+			},
+		},
+		{
+			"stacked(metric1,'dc1')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+			},
+			[]*types.MetricData{th.WithGraphOptions(
+				types.MakeMetricData("stacked(metric1)", []float64{1, 2, 3}, 1, now32),
+				types.GraphOptions{Stacked: true, StackName: "dc1"},
+			)},
+		},
+		{
+			"stacked(metric1,stackName='dc2')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32)},
+			},
+			[]*types.MetricData{th.WithGraphOptions(
+				types.MakeMetricData("stacked(metric1)", []float64{1, 2, 3}, 1, now32),
+				types.GraphOptions{Stacked: true, StackName: "dc2"},
+			)},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprOrdered(t, &tt)
+		})
+	}
+
+}
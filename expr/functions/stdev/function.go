@@ -2,7 +2,6 @@ package stdev
 
 import (
 	"context"
-	"fmt"
 	"math"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
@@ -26,10 +25,10 @@ func New(configFile string) []interfaces.FunctionMetadata {
 	return res
 }
 
-// stdev(seriesList, points, missingThreshold=0.1)
-// Alias: stddev
+// stdev(seriesList, points, windowTolerance=0.1)
+// Not to be confused with stddev, which is a short form of stddevSeries and aggregates all series at each timestamp
 func (f *stdev) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -39,28 +38,30 @@ func (f *stdev) Do(ctx context.Context, e parser.Expr, from, until int64, values
 		return nil, err
 	}
 
-	missingThreshold, err := e.GetFloatArgDefault(2, 0.1)
+	windowTolerance, err := e.GetFloatNamedOrPosArgDefault("windowTolerance", 2, 0.1)
 	if err != nil {
 		return nil, err
 	}
 
-	minLen := int((1 - missingThreshold) * float64(points))
-
 	var result []*types.MetricData
 
 	for _, a := range arg {
 		w := &types.Windowed{Data: make([]float64, points)}
 
 		r := *a
-		r.Name = fmt.Sprintf("stdev(%s,%d)", a.Name, points)
+		r.Name = helper.FuncName("stdev", a.Name, points)
 		r.Values = make([]float64, len(a.Values))
 
 		for i, v := range a.Values {
 			w.Push(v)
-			r.Values[i] = w.Stdev()
-			if math.IsNaN(r.Values[i]) || (i >= minLen && w.Len() < minLen) {
+			// same as in graphite-web: emit value only if ratio of valid points in the window is at least windowTolerance
+			// unlike graphite-web, window with a single valid point is NaN, not 0
+			validPoints := w.Len()
+			if validPoints == 0 || float64(validPoints)/float64(points) < windowTolerance {
 				r.Values[i] = math.NaN()
+				continue
 			}
+			r.Values[i] = w.Stdev()
 		}
 		result = append(result, &r)
 	}
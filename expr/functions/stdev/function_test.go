@@ -0,0 +1,84 @@
+package stdev
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestStdev(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+	nan := math.NaN()
+
+	tests := []th.EvalTestItem{
+		{
+			"stdev(metric1,3)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 4, nan, nan, nan, 8}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("stdev(metric1,3)",
+				[]float64{nan, 0.5, 0.816496580927726, 0.816496580927726, 0.5, nan, nan, nan}, 1, now32)},
+		},
+		{
+			"stdev(metric1,3,0.5)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 4, nan, nan, nan, 8}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("stdev(metric1,3)",
+				[]float64{nan, 0.5, 0.816496580927726, 0.816496580927726, 0.5, nan, nan, nan}, 1, now32)},
+		},
+		{
+			// constant window is 0, window with a single valid point is NaN
+			"stdev(metric1,2)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{5, 5, 5, nan, 3, 7}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("stdev(metric1,2)",
+				[]float64{nan, 0, 0, nan, nan, 2}, 1, now32)},
+		},
+		{
+			"stdev(metric*,3)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*", 0, 1}: {
+					types.MakeMetricData("metric1", []float64{1, 2, 3}, 1, now32),
+					types.MakeMetricData("metric2", []float64{1, 3, 5}, 1, now32),
+				},
+			},
+			[]*types.MetricData{
+				types.MakeMetricData("stdev(metric1,3)", []float64{nan, 0.5, 0.816496580927726}, 1, now32),
+				types.MakeMetricData("stdev(metric2,3)", []float64{nan, 1, 1.632993161855452}, 1, now32),
+			},
+		},
+		{
+			"stdev(metric1,3,windowTolerance=1)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 4, nan, 6}, 1, now32)},
+			},
+			[]*types.MetricData{types.MakeMetricData("stdev(metric1,3)",
+				[]float64{nan, nan, 0.816496580927726, 0.816496580927726, nan, nan}, 1, now32)},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+}
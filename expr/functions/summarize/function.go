@@ -2,14 +2,17 @@ package summarize
 
 import (
 	"context"
-	"fmt"
 	"math"
+	"strconv"
+
+	"github.com/ansel1/merry"
 
 	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/pkg/parser"
+	utilctx "github.com/go-graphite/carbonapi/util/ctx"
 	pb "github.com/go-graphite/protocol/carbonapi_v3_pb"
 )
 
@@ -31,10 +34,10 @@ func New(configFile string) []interfaces.FunctionMetadata {
 	return res
 }
 
-// summarize(seriesList, intervalString, func='sum', alignToFrom=False)
+// summarize(seriesList, intervalString, func='sum', alignToFrom=False, xFilesFactor=0)
 func (f *summarize) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
 	// TODO(dgryski): make sure the arrays are all the same 'size'
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -67,17 +70,33 @@ func (f *summarize) Do(ctx context.Context, e parser.Expr, from, until int64, va
 		alignOk = len(e.Args()) > 3
 	}
 
+	xFilesFactor, err := e.GetFloatNamedOrPosArgDefault("xFilesFactor", 4, 0)
+	if err != nil {
+		return nil, err
+	}
+	if xFilesFactor < 0 || xFilesFactor > 1 {
+		return nil, merry.WithMessagef(parser.ErrBadType, "%s: xFilesFactor should be between 0 and 1, got %v", parser.ErrBadType, xFilesFactor)
+	}
+	_, xffOk := e.NamedArgs()["xFilesFactor"]
+	if !xffOk {
+		xffOk = len(e.Args()) > 4
+	}
+
 	start := args[0].StartTime
 	stop := args[0].StopTime
 	if !alignToFrom {
-		start, stop = helper.AlignToBucketSize(start, stop, bucketSize)
+		start, stop = helper.AlignToBucketSize(start, stop, bucketSize, utilctx.GetTimezone(ctx))
 	}
 
 	buckets := helper.GetBuckets(start, stop, bucketSize)
+	// the last bucket may be partial, the series still ends at its boundary, as in graphite-web
+	stop = start + buckets*bucketSize
 	results := make([]*types.MetricData, 0, len(args))
 	for _, arg := range args {
-
-		name := fmt.Sprintf("summarize(%s,'%s'", arg.Name, e.Args()[1].StringValue())
+		if err := ctx.Err(); err != nil {
+			return nil, merry.Wrap(err)
+		}
+		nameArgs := []interface{}{arg.Name, helper.Quote(e.Args()[1].StringValue())}
 		if funcOk || alignOk {
 			// we include the "func" argument in the presence of
 			// "alignToFrom", even if the former was omitted
@@ -87,12 +106,15 @@ func (f *summarize) Do(ctx context.Context, e parser.Expr, from, until int64, va
 			// so we show "summarize(foo,'5min','sum',true)" instead of "summarize(foo,'5min',true)"
 			//
 			// this does not match graphite's behaviour but seems more correct
-			name += fmt.Sprintf(",'%s'", summarizeFunction)
+			nameArgs = append(nameArgs, helper.Quote(summarizeFunction))
 		}
 		if alignOk {
-			name += fmt.Sprintf(",%v", alignToFrom)
+			nameArgs = append(nameArgs, alignToFrom)
+		}
+		if xffOk {
+			nameArgs = append(nameArgs, "xFilesFactor="+strconv.FormatFloat(xFilesFactor, 'g', -1, 64))
 		}
-		name += ")"
+		name := helper.FuncName("summarize", nameArgs...)
 
 		if arg.StepTime > bucketSize {
 			// We don't have enough data to do math
@@ -128,7 +150,10 @@ func (f *summarize) Do(ctx context.Context, e parser.Expr, from, until int64, va
 
 		t := arg.StartTime // unadjusted
 		bucketEnd := start + bucketSize
-		values := make([]float64, 0, bucketSize/arg.StepTime)
+		// buckets on the edges of the series have less points than a full one, so they are
+		// checked against the expected amount of points rather than against the present ones
+		bucketPoints := int(bucketSize / arg.StepTime)
+		values := make([]float64, 0, bucketPoints)
 		ridx := 0
 		bucketItems := 0
 		for _, v := range arg.Values {
@@ -144,7 +169,10 @@ func (f *summarize) Do(ctx context.Context, e parser.Expr, from, until int64, va
 			}
 
 			if t >= bucketEnd {
-				rv := consolidations.SummarizeValues(summarizeFunction, values, arg.XFilesFactor)
+				rv := math.NaN()
+				if consolidations.XFilesFactor(len(values), bucketPoints, float32(xFilesFactor)) {
+					rv = consolidations.SummarizeValues(summarizeFunction, values, arg.XFilesFactor)
+				}
 
 				r.Values[ridx] = rv
 				ridx++
@@ -156,7 +184,10 @@ func (f *summarize) Do(ctx context.Context, e parser.Expr, from, until int64, va
 
 		// last partial bucket
 		if bucketItems > 0 {
-			rv := consolidations.SummarizeValues(summarizeFunction, values, arg.XFilesFactor)
+			rv := math.NaN()
+			if consolidations.XFilesFactor(len(values), bucketPoints, float32(xFilesFactor)) {
+				rv = consolidations.SummarizeValues(summarizeFunction, values, arg.XFilesFactor)
+			}
 			r.Values[ridx] = rv
 		}
 
@@ -170,8 +201,8 @@ func (f *summarize) Do(ctx context.Context, e parser.Expr, from, until int64, va
 func (f *summarize) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
 		"summarize": {
-			Description: "Summarize the data into interval buckets of a certain size.\n\nBy default, the contents of each interval bucket are summed together. This is\nuseful for counters where each increment represents a discrete event and\nretrieving a \"per X\" value requires summing all the events in that interval.\n\nSpecifying 'average' instead will return the mean for each bucket, which can be more\nuseful when the value is a gauge that represents a certain value in time.\n\nThis function can be used with aggregation functions ``average``, ``median``, ``sum``, ``min``,\n``max``, ``diff``, ``stddev``, ``count``, ``range``, ``multiply`` & ``last``.\n\nBy default, buckets are calculated by rounding to the nearest interval. This\nworks well for intervals smaller than a day. For example, 22:32 will end up\nin the bucket 22:00-23:00 when the interval=1hour.\n\nPassing alignToFrom=true will instead create buckets starting at the from\ntime. In this case, the bucket for 22:32 depends on the from time. If\nfrom=6:30 then the 1hour bucket for 22:32 is 22:30-23:30.\n\nExample:\n\n.. code-block:: none\n\n  &target=summarize(counter.errors, \"1hour\") # total errors per hour\n  &target=summarize(nonNegativeDerivative(gauge.num_users), \"1week\") # new users per week\n  &target=summarize(queue.size, \"1hour\", \"avg\") # average queue size per hour\n  &target=summarize(queue.size, \"1hour\", \"max\") # maximum queue size during each hour\n  &target=summarize(metric, \"13week\", \"avg\", true)&from=midnight+20100101 # 2010 Q1-4",
-			Function:    "summarize(seriesList, intervalString, func='sum', alignToFrom=False)",
+			Description: "Summarize the data into interval buckets of a certain size.\n\nBy default, the contents of each interval bucket are summed together. This is\nuseful for counters where each increment represents a discrete event and\nretrieving a \"per X\" value requires summing all the events in that interval.\n\nSpecifying 'average' instead will return the mean for each bucket, which can be more\nuseful when the value is a gauge that represents a certain value in time.\n\nThis function can be used with aggregation functions ``average``, ``median``, ``sum``, ``min``,\n``max``, ``diff``, ``stddev``, ``count``, ``range``, ``multiply`` & ``last``.\n\nBy default, buckets are calculated by rounding to the nearest interval. This\nworks well for intervals smaller than a day. For example, 22:32 will end up\nin the bucket 22:00-23:00 when the interval=1hour.\n\nPassing alignToFrom=true will instead create buckets starting at the from\ntime. In this case, the bucket for 22:32 depends on the from time. If\nfrom=6:30 then the 1hour bucket for 22:32 is 22:30-23:30.\n\nExample:\n\n.. code-block:: none\n\n  &target=summarize(counter.errors, \"1hour\") # total errors per hour\n  &target=summarize(nonNegativeDerivative(gauge.num_users), \"1week\") # new users per week\n  &target=summarize(queue.size, \"1hour\", \"avg\") # average queue size per hour\n  &target=summarize(queue.size, \"1hour\", \"max\") # maximum queue size during each hour\n  &target=summarize(metric, \"13week\", \"avg\", true)&from=midnight+20100101 # 2010 Q1-4\n\nBuckets with a ratio of non-null points to the points of a full bucket below xFilesFactor are\nset to None (carbonapi only). The default of 0 emits a value if the bucket has any non-null point.",
+			Function:    "summarize(seriesList, intervalString, func='sum', alignToFrom=False, xFilesFactor=0)",
 			Group:       "Transform",
 			Module:      "graphite.render.functions",
 			Name:        "summarize",
@@ -202,6 +233,11 @@ func (f *summarize) Description() map[string]types.FunctionDescription {
 					Name:    "alignToFrom",
 					Type:    types.Boolean,
 				},
+				{
+					Default: types.NewSuggestion(0),
+					Name:    "xFilesFactor",
+					Type:    types.Float,
+				},
 			},
 		},
 	}
@@ -1,14 +1,17 @@
 package summarize
 
 import (
+	"context"
 	"math"
 	"testing"
+	"time"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/metadata"
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/pkg/parser"
 	th "github.com/go-graphite/carbonapi/tests"
+	utilctx "github.com/go-graphite/carbonapi/util/ctx"
 )
 
 func init() {
@@ -118,7 +121,7 @@ func TestEvalSummarize(t *testing.T) {
 			map[parser.MetricRequest][]*types.MetricData{
 				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 0, 0, 0.5, 1, 2, 1, 1, 1.5, 2, 3, 2, 2, 1.5, 3, 4, 3, 2, 3, 4.5, 5, 5, 5, 5, 5}, 1, now32)},
 			},
-			[]float64{0, 1, 2, 3, 5},
+			[]float64{0, 1, 1.75, 2.5, 5},
 			"summarize(metric1,'5s','p25')",
 			5,
 			now32,
@@ -129,7 +132,7 @@ func TestEvalSummarize(t *testing.T) {
 			map[parser.MetricRequest][]*types.MetricData{
 				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 0, 0, 0.5, 1, 2, 1, 1, 1.5, 2, 3, 2, 2, 1.5, 3, 4, 3, 2, 3, 4.5, 5, 5, 5, 5, 5}, 1, now32)},
 			},
-			[]float64{1, 2, 3, 4.498, 5},
+			[]float64{1, 2, 3, 4.5, 5},
 			"summarize(metric1,'5s','p99.9')",
 			5,
 			now32,
@@ -183,7 +186,7 @@ func TestEvalSummarize(t *testing.T) {
 			"summarize(metric1,'10min','sum',true)",
 			600,
 			tenThirtyTwo,
-			tenThirtyTwo + 25*60,
+			tenThirtyTwo + 30*60,
 		},
 		{
 			"summarize(metric1,'10min','sum',true)",
@@ -197,7 +200,93 @@ func TestEvalSummarize(t *testing.T) {
 			"summarize(metric1,'10min','sum',true)",
 			600,
 			tenThirtyTwo,
-			tenThirtyTwo + 25*60,
+			tenThirtyTwo + 30*60,
+		},
+		{
+			// range that isn't a multiple of the bucket: the last bucket is partial, but ends at the bucket boundary
+			"summarize(metric1,'4s','sum',true)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{
+					1, 2, 3, 4, 5, 6, 7, 8, 9, 10,
+				}, 1, now32+1)},
+			},
+			[]float64{10, 26, 19},
+			"summarize(metric1,'4s','sum',true)",
+			4,
+			now32 + 1,
+			now32 + 13,
+		},
+		{
+			"summarize(metric1,'4s')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{
+					1, 2, 3, 4, 5, 6, 7, 8, 9, 10,
+				}, 1, now32+1)},
+			},
+			[]float64{6, 22, 27},
+			"summarize(metric1,'4s')",
+			4,
+			now32,
+			now32 + 12,
+		},
+		{
+			"summarize(metric1,'5s','sum',false,0.5)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{
+					1, 1, 1, 1, 1,
+					2, 2, math.NaN(), math.NaN(), math.NaN(),
+					3, 3, 3, math.NaN(), math.NaN(),
+				}, 1, now32)},
+			},
+			[]float64{5, math.NaN(), 9},
+			"summarize(metric1,'5s','sum',false,xFilesFactor=0.5)",
+			5,
+			now32,
+			now32 + 15,
+		},
+		{
+			// the last incomplete bucket has less points than required
+			"summarize(metric1,'5s',xFilesFactor=0.5)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{
+					1, 1, 1, 1, 1,
+					1, 1,
+				}, 1, now32)},
+			},
+			[]float64{5, math.NaN()},
+			"summarize(metric1,'5s',xFilesFactor=0.5)",
+			5,
+			now32,
+			now32 + 10,
+		},
+		{
+			"summarize(metric1,'5s',xFilesFactor=0)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{
+					1, 1, 1, 1, 1,
+					1, 1,
+				}, 1, now32)},
+			},
+			[]float64{5, 2},
+			"summarize(metric1,'5s',xFilesFactor=0)",
+			5,
+			now32,
+			now32 + 10,
+		},
+		{
+			// standard deviation of a single point is not known
+			"summarize(metric1,'5s','stddev')",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{
+					1, 2, 3, 4, 5,
+					7, math.NaN(), math.NaN(), math.NaN(), math.NaN(),
+				}, 1, now32)},
+			},
+			[]float64{math.Sqrt2, math.NaN()},
+			"summarize(metric1,'5s','stddev')",
+			5,
+			now32,
+			now32 + 10,
 		},
 	}
 
@@ -205,3 +294,76 @@ func TestEvalSummarize(t *testing.T) {
 		th.TestSummarizeEvalExpr(t, &tt)
 	}
 }
+
+func TestSummarizeErrors(t *testing.T) {
+	now32 := int64(time.Now().Unix())
+
+	tests := []th.EvalTestItemWithError{
+		{
+			Target: "summarize(metric1,'5s',xFilesFactor=1.5)",
+			M: map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2}, 1, now32)},
+			},
+			Error: parser.ErrBadType,
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExprWithError(t, &tt)
+		})
+	}
+}
+
+func TestSummarizeTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone database is not available: %v", err)
+	}
+
+	// two days of hourly points starting at the local midnight
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, loc).Unix()
+	values := make([]float64, 48)
+	for i := range values {
+		values[i] = 1
+	}
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric1", 0, 1}: {types.MakeMetricData("metric1", values, 3600, start)},
+	}
+
+	exp, _, err := parser.ParseExpr(`summarize(metric1,"1d")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := New("")[0].F
+	tests := []struct {
+		name      string
+		loc       *time.Location
+		want      []float64
+		wantStart int64
+	}{
+		{"America/New_York", loc, []float64{24, 24}, start},
+		// local midnight is 04:00 UTC, so UTC days are split
+		{"UTC", time.UTC, []float64{20, 24, 4}, start - 4*3600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := utilctx.SetTimezone(context.Background(), tt.loc)
+			res, err := f.Do(ctx, exp, 0, 1, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res) != 1 {
+				t.Fatalf("unexpected number of results: %d", len(res))
+			}
+			if res[0].StartTime != tt.wantStart {
+				t.Errorf("start: got %d, want %d", res[0].StartTime, tt.wantStart)
+			}
+			if !th.NearlyEqual(res[0].Values, tt.want) {
+				t.Errorf("values: got %v, want %v", res[0].Values, tt.want)
+			}
+		})
+	}
+}
@@ -2,7 +2,8 @@ package timeShift
 
 import (
 	"context"
-	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/lomik/zapwriter"
 	"github.com/spf13/viper"
@@ -12,6 +13,7 @@ import (
 	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/pkg/parser"
+	utilctx "github.com/go-graphite/carbonapi/util/ctx"
 )
 
 type timeShift struct {
@@ -71,20 +73,31 @@ func New(configFile string) []interfaces.FunctionMetadata {
 	return res
 }
 
-// timeShift(seriesList, timeShift, resetEnd=True)
+// timeShift(seriesList, timeShift, resetEnd=True, alignDST=False)
 func (f *timeShift) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	// FIXME(civil): support alignDst
 	offs, err := e.GetIntervalArg(1, -1)
 	if err != nil {
 		return nil, err
 	}
 
-	resetEnd, err := e.GetBoolArgDefault(2, *f.config.ResetEndDefaultValue)
+	resetEnd, err := e.GetBoolNamedOrPosArgDefault("resetEnd", 2, *f.config.ResetEndDefaultValue)
 	if err != nil {
 		return nil, err
 	}
 
-	arg, err := helper.GetSeriesArg(e.Args()[0], from+int64(offs), until+int64(offs), values)
+	alignDST, err := e.GetBoolNamedOrPosArgDefault("alignDST", 3, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var margin int64
+	shift := int64(offs)
+	if alignDST {
+		margin = parser.AlignDSTMargin
+		shift += dstOffset(utilctx.GetTimezone(ctx), from, until, int64(offs))
+	}
+
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from+int64(offs)-margin, until+int64(offs)+margin, values)
 	if err != nil {
 		return nil, err
 	}
@@ -92,11 +105,14 @@ func (f *timeShift) Do(ctx context.Context, e parser.Expr, from, until int64, va
 	results := make([]*types.MetricData, 0, len(arg))
 
 	for _, a := range arg {
+		if alignDST {
+			a = trimSeries(a, from+shift, until+shift)
+		}
 		r := *a
-		r.Name = fmt.Sprintf("timeShift(%s,'%d',%v)", a.Name, offs, resetEnd)
-		r.StartTime = a.StartTime - int64(offs)
+		r.Name = helper.FuncName("timeShift", a.Name, helper.Quote(strconv.Itoa(int(offs))), resetEnd)
+		r.StartTime = a.StartTime - shift
 		if !resetEnd {
-			r.StopTime = a.StopTime - int64(offs)
+			r.StopTime = a.StopTime - shift
 		}
 		length := int((r.StopTime - r.StartTime) / r.StepTime)
 		if length < 0 {
@@ -109,6 +125,43 @@ func (f *timeShift) Do(ctx context.Context, e parser.Expr, from, until int64, va
 	return results, nil
 }
 
+// dstOffset returns how much shift should be corrected for the shifted interval to start at the same local time as
+// the requested one, e.x. 1h for the week-over-week shift from summer to winter time. Like graphite-web, it's done only
+// if neither requested interval, nor shifted one cross the change of UTC offset, as otherwise graph would be confusing.
+func dstOffset(loc *time.Location, from, until, offs int64) int64 {
+	_, reqFrom := time.Unix(from, 0).In(loc).Zone()
+	_, reqUntil := time.Unix(until, 0).In(loc).Zone()
+	_, shiftedFrom := time.Unix(from+offs, 0).In(loc).Zone()
+	_, shiftedUntil := time.Unix(until+offs, 0).In(loc).Zone()
+	if reqFrom != reqUntil || shiftedFrom != shiftedUntil {
+		return 0
+	}
+	return int64(reqFrom - shiftedFrom)
+}
+
+// trimSeries drops points of series outside of [from, until), which were fetched to align it to DST
+func trimSeries(a *types.MetricData, from, until int64) *types.MetricData {
+	r := *a
+	if skip := (from - r.StartTime + r.StepTime - 1) / r.StepTime; skip > 0 {
+		if skip > int64(len(r.Values)) {
+			skip = int64(len(r.Values))
+		}
+		r.StartTime += skip * r.StepTime
+		r.Values = r.Values[skip:]
+	}
+	if until < r.StopTime {
+		n := (until - r.StartTime + r.StepTime - 1) / r.StepTime
+		if n < 0 {
+			n = 0
+		}
+		if n < int64(len(r.Values)) {
+			r.Values = r.Values[:n]
+		}
+		r.StopTime = r.StartTime + int64(len(r.Values))*r.StepTime
+	}
+	return &r
+}
+
 // Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
 func (f *timeShift) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
@@ -144,13 +197,11 @@ func (f *timeShift) Description() map[string]types.FunctionDescription {
 					Name:    "resetEnd",
 					Type:    types.Boolean,
 				},
-				/*
-					{
-						Default: types.NewSuggestion(false),
-						Name:    "alignDst",
-						Type:    types.Boolean,
-					},
-				*/
+				{
+					Default: types.NewSuggestion(false),
+					Name:    "alignDST",
+					Type:    types.Boolean,
+				},
 			},
 		},
 	}
@@ -1,6 +1,7 @@
 package timeShift
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/pkg/parser"
 	th "github.com/go-graphite/carbonapi/tests"
+	utilctx "github.com/go-graphite/carbonapi/util/ctx"
 )
 
 func init() {
@@ -75,3 +77,64 @@ func TestTimeShift(t *testing.T) {
 	}
 
 }
+
+func TestTimeShiftAlignDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone database is not available: %v", err)
+	}
+
+	// Thursday after the switch to summer time, shifted week ago is still winter time
+	from := time.Date(2021, 4, 1, 0, 0, 0, 0, loc).Unix()
+	until := from + 6*3600
+	week := int64(7 * 86400)
+
+	exp, _, err := parser.ParseExpr(`timeShift(metric1,"7d",false,true)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// extra data is fetched around the shifted interval, as offset correction isn't known before evaluation
+	fetchFrom := -week - parser.AlignDSTMargin
+	fetchUntil := -week + parser.AlignDSTMargin
+	if got := exp.Metrics(); len(got) != 1 || got[0].From != fetchFrom || got[0].Until != fetchUntil {
+		t.Fatalf("unexpected metrics: %+v", got)
+	}
+
+	values := make([]float64, 10)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	m := map[parser.MetricRequest][]*types.MetricData{
+		{"metric1", from + fetchFrom, until + fetchUntil}: {types.MakeMetricData("metric1", values, 3600, from+fetchFrom)},
+	}
+
+	f := New("")[0].F
+	tests := []struct {
+		name string
+		loc  *time.Location
+		want []float64
+	}{
+		// shift is corrected by an hour, so midnight is overlaid with midnight
+		{"Europe/Berlin", loc, []float64{3, 4, 5, 6, 7, 8}},
+		{"UTC", time.UTC, []float64{2, 3, 4, 5, 6, 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := utilctx.SetTimezone(context.Background(), tt.loc)
+			got, err := f.Do(ctx, exp, from, until, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := types.MakeMetricData("timeShift(metric1,'-604800',false)", tt.want, 3600, from)
+			if len(got) != 1 {
+				t.Fatalf("expected 1 series, got %d", len(got))
+			}
+			if got[0].Name != want.Name || got[0].StartTime != want.StartTime || got[0].StopTime != want.StopTime || !th.NearlyEqual(got[0].Values, want.Values) {
+				t.Errorf("got %s [%d, %d) %v, want %s [%d, %d) %v", got[0].Name, got[0].StartTime, got[0].StopTime, got[0].Values,
+					want.Name, want.StartTime, want.StopTime, want.Values)
+			}
+		})
+	}
+}
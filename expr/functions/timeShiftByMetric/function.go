@@ -2,7 +2,6 @@ package timeShiftByMetric
 
 import (
 	"context"
-	"fmt"
 	"math"
 	"regexp"
 	"strings"
@@ -34,7 +33,7 @@ func New(configFile string) []interfaces.FunctionMetadata {
 
 // timeShiftByMetric(seriesList, markSource, versionRankIndex)
 func (f *timeShiftByMetric) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) (resultData []*types.MetricData, resultError error) {
-	params, err := f.extractCallParams(e, from, until, values)
+	params, err := f.extractCallParams(ctx, e, from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +84,7 @@ func (f *timeShiftByMetric) applyShift(params *callParams, offsets offsetByVersi
 		// checking if it is some version after all, otherwise this series will be omitted
 		if offsetIsSet {
 			r := *metric
-			r.Name = fmt.Sprintf("timeShiftByMetric(%s)", r.Name)
+			r.Name = helper.FuncName("timeShiftByMetric", r.Name)
 			r.StopTime += offset
 			r.StartTime += offset
 
@@ -108,13 +107,13 @@ func (f *timeShiftByMetric) calculateOffsets(params *callParams, versions versio
 }
 
 // extractCallParams (preliminarily) validates and extracts parameters of timeShiftByMetric's call as structure
-func (f *timeShiftByMetric) extractCallParams(e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) (*callParams, error) {
-	metrics, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+func (f *timeShiftByMetric) extractCallParams(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) (*callParams, error) {
+	metrics, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
 
-	marks, err := helper.GetSeriesArg(e.Args()[1], from, until, values)
+	marks, err := helper.GetSeriesArg(ctx, e.Args()[1], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -2,10 +2,10 @@ package timeSlice
 
 import (
 	"context"
-	"fmt"
 	"math"
 	"time"
 
+	"github.com/go-graphite/carbonapi/date"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/types"
@@ -30,22 +30,28 @@ func New(configFile string) []interfaces.FunctionMetadata {
 	return res
 }
 
+// timeSlice(seriesList, startSliceAt, endSliceAt='now')
 func (f *timeSlice) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	start32, err := e.GetIntervalArg(1, -1)
+	startStr, err := e.GetStringArg(1)
 	if err != nil {
 		return nil, err
 	}
-	start := int64(start32)
-
-	end, err := e.GetIntervalNamedOrPosArgDefault("endSliceAt", 2, -1, 0)
+	endStr, err := e.GetStringNamedOrPosArgDefault("endSliceAt", 2, "now")
 	if err != nil {
 		return nil, err
 	}
+
 	now := time.Now().Unix()
-	start += now
-	end += now
+	start, err := parseSliceAt(startStr, now)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseSliceAt(endStr, now)
+	if err != nil {
+		return nil, err
+	}
 
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -54,10 +60,10 @@ func (f *timeSlice) Do(ctx context.Context, e parser.Expr, from, until int64, va
 
 	for _, a := range arg {
 		r := *a
-		r.Name = fmt.Sprintf("timeSlice(%s, %d, %d)", a.Name, start, end)
+		r.Name = helper.FuncName("timeSlice", a.Name, start, end)
 		r.Values = make([]float64, len(a.Values))
 
-		current := from
+		current := a.StartTime
 		for i, v := range a.Values {
 			if current < start || current > end {
 				r.Values[i] = math.NaN()
@@ -72,6 +78,21 @@ func (f *timeSlice) Do(ctx context.Context, e parser.Expr, from, until int64, va
 	return results, nil
 }
 
+// parseSliceAt converts time specification to unix timestamp. It accepts everything that is accepted by `from` and `until`
+// (e.x. "-1h", "now", "00:00_20140101" or unix timestamp) and, for backward compatibility, intervals without a sign, that are
+// treated as relative to now (e.x. "1h" means "1 hour ago").
+func parseSliceAt(s string, now int64) (int64, error) {
+	if ts := date.DateParamToEpoch(s, "", 0, time.Local); ts != 0 {
+		return ts, nil
+	}
+
+	offset, err := parser.IntervalString(s, -1)
+	if err != nil {
+		return 0, err
+	}
+	return now + int64(offset), nil
+}
+
 // Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
 func (f *timeSlice) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
@@ -94,7 +115,7 @@ func (f *timeSlice) Description() map[string]types.FunctionDescription {
 				},
 				{
 					Name:    "endSliceAt",
-					Type:    types.Interval,
+					Type:    types.Date,
 					Default: types.NewSuggestion("now"),
 				},
 			},
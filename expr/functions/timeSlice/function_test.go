@@ -0,0 +1,55 @@
+package timeSlice
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/helper"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	th "github.com/go-graphite/carbonapi/tests"
+)
+
+func init() {
+	md := New("")
+	evaluator := th.EvaluatorFromFunc(md[0].F)
+	metadata.SetEvaluator(evaluator)
+	helper.SetEvaluator(evaluator)
+	for _, m := range md {
+		metadata.RegisterFunction(m.Name, m.F)
+	}
+}
+
+func TestTimeSlice(t *testing.T) {
+	start := time.Date(2014, time.January, 1, 0, 0, 0, 0, time.Local).Unix()
+	nan := math.NaN()
+
+	tests := []th.EvalTestItem{
+		{
+			"timeSlice(metric1,\"" + strconv.FormatInt(start+120, 10) + "\",\"" + strconv.FormatInt(start+240, 10) + "\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5, 6, 7}, 60, start)},
+			},
+			[]*types.MetricData{types.MakeMetricData("timeSlice(metric1,"+strconv.FormatInt(start+120, 10)+","+strconv.FormatInt(start+240, 10)+")",
+				[]float64{nan, nan, 3, 4, 5, nan, nan}, 60, start)},
+		},
+		{
+			"timeSlice(metric1,\"00:02_20140101\",\"00:04_20140101\")",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5, 6, 7}, 60, start)},
+			},
+			[]*types.MetricData{types.MakeMetricData("timeSlice(metric1,"+strconv.FormatInt(start+120, 10)+","+strconv.FormatInt(start+240, 10)+")",
+				[]float64{nan, nan, 3, 4, 5, nan, nan}, 60, start)},
+		},
+	}
+
+	for _, tt := range tests {
+		testName := tt.Target
+		t.Run(testName, func(t *testing.T) {
+			th.TestEvalExpr(t, &tt)
+		})
+	}
+}
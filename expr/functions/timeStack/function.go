@@ -2,7 +2,6 @@ package timeStack
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
@@ -50,14 +49,14 @@ func (f *timeStack) Do(ctx context.Context, e parser.Expr, from, until int64, va
 		offs := i * int64(unit)
 		fromNew := from + offs
 		untilNew := until + offs
-		arg, err := helper.GetSeriesArg(e.Args()[0], fromNew, untilNew, values)
+		arg, err := helper.GetSeriesArg(ctx, e.Args()[0], fromNew, untilNew, values)
 		if err != nil {
 			return nil, err
 		}
 
 		for _, a := range arg {
 			r := *a
-			r.Name = fmt.Sprintf("timeShift(%s,%d)", a.Name, offs)
+			r.Name = helper.FuncName("timeShift", a.Name, offs)
 			r.StartTime = a.StartTime - offs
 			r.StopTime = a.StopTime - offs
 			results = append(results, &r)
@@ -31,9 +31,9 @@ func New(configFile string) []interfaces.FunctionMetadata {
 	return res
 }
 
-// transformNull(seriesList, default=0)
+// transformNull(seriesList, default=0, referenceSeries=None, defaultOnAbsent=False)
 func (f *transformNull) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	arg, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	arg, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +41,16 @@ func (f *transformNull) Do(ctx context.Context, e parser.Expr, from, until int64
 	if err != nil {
 		return nil, err
 	}
-	defaultOnAbsent, err := e.GetBoolNamedOrPosArgDefault("defaultOnAbsent", 2, false)
+
+	// referenceSeries is the third argument as in graphite-web, defaultOnAbsent follows it if it's passed by position.
+	// Boolean third argument is still treated as defaultOnAbsent.
+	referenceSeriesExpr := e.GetNamedArg("referenceSeries")
+	defaultOnAbsentPos := 2
+	if referenceSeriesExpr.IsInterfaceNil() && len(e.Args()) > 2 && (e.Args()[2].IsName() || e.Args()[2].IsFunc()) {
+		referenceSeriesExpr = e.Args()[2]
+		defaultOnAbsentPos = 3
+	}
+	defaultOnAbsent, err := e.GetBoolNamedOrPosArgDefault("defaultOnAbsent", defaultOnAbsentPos, false)
 	if err != nil {
 		return nil, err
 	}
@@ -51,10 +60,9 @@ func (f *transformNull) Do(ctx context.Context, e parser.Expr, from, until int64
 		ok = len(e.Args()) > 1
 	}
 
-	var valMap []bool
-	referenceSeriesExpr := e.GetNamedArg("referenceSeries")
+	var referenceSeries []*types.MetricData
 	if !referenceSeriesExpr.IsInterfaceNil() {
-		referenceSeries, err := helper.GetSeriesArg(referenceSeriesExpr, from, until, values)
+		referenceSeries, err = helper.GetSeriesArg(ctx, referenceSeriesExpr, from, until, values)
 		if err != nil {
 			return nil, err
 		}
@@ -62,44 +70,31 @@ func (f *transformNull) Do(ctx context.Context, e parser.Expr, from, until int64
 		if len(referenceSeries) == 0 {
 			return nil, fmt.Errorf("reference series is not a valid metric")
 		}
-		length := len(referenceSeries[0].Values)
-		if length != len(arg[0].Values) {
-			return nil, fmt.Errorf("length of series and reference series must be the same")
-		}
-		valMap = make([]bool, length)
-
-		for _, a := range referenceSeries {
-			for i, v := range a.Values {
-				if !math.IsNaN(v) {
-					valMap[i] = true
-				}
-			}
-		}
+		// default is always rendered with reference series, as in graphite-web
+		ok = true
 	}
 
 	results := make([]*types.MetricData, 0, len(arg))
 	for _, a := range arg {
 		var name string
 		if ok {
-			name = fmt.Sprintf("transformNull(%s,%g)", a.Name, defv)
+			name = helper.FuncName("transformNull", a.Name, defv)
 		} else {
-			name = fmt.Sprintf("transformNull(%s)", a.Name)
+			name = helper.FuncName("transformNull", a.Name)
 		}
 
 		r := *a
 		r.Name = name
 		r.Values = make([]float64, len(a.Values))
 
+		t := a.StartTime
 		for i, v := range a.Values {
-			if math.IsNaN(v) {
-				if len(valMap) == 0 {
-					v = defv
-				} else if valMap[i] {
-					v = defv
-				}
+			if math.IsNaN(v) && (referenceSeries == nil || hasData(referenceSeries, t)) {
+				v = defv
 			}
 
 			r.Values[i] = v
+			t += a.StepTime
 		}
 
 		results = append(results, &r)
@@ -121,6 +116,21 @@ func (f *transformNull) Do(ctx context.Context, e parser.Expr, from, until int64
 	return results, nil
 }
 
+// hasData returns true if any of series has a non-null point at timestamp t. Series are matched by time, not by
+// index, so they don't have to be of the same range or step as the transformed one.
+func hasData(series []*types.MetricData, t int64) bool {
+	for _, s := range series {
+		if t < s.StartTime || s.StepTime <= 0 {
+			continue
+		}
+		i := (t - s.StartTime) / s.StepTime
+		if i < int64(len(s.Values)) && !math.IsNaN(s.Values[i]) {
+			return true
+		}
+	}
+	return false
+}
+
 // Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
 func (f *transformNull) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
@@ -139,7 +149,7 @@ func (f *transformNull) Description() map[string]types.FunctionDescription {
   This would take any page that didn't have values and supply negative 1 as a default.
   Any other numeric value may be used as well.
 `,
-			Function: "transformNull(seriesList, default=0, referenceSeries=None)",
+			Function: "transformNull(seriesList, default=0, referenceSeries=None, defaultOnAbsent=False)",
 			Group:    "Transform",
 			Module:   "graphite.render.functions",
 			Name:     "transformNull",
@@ -59,6 +59,41 @@ func TestTransformNull(t *testing.T) {
 			[]*types.MetricData{types.MakeMetricData("transformNull(metric1, default=5, defaultOnAbsent=True)",
 				[]float64{5, 5}, 1, 0)},
 		},
+		{
+			// reference of a different step with its own gaps is matched by time
+			`transformNull(metric1,5,metric2)`,
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), math.NaN(), math.NaN(), math.NaN(), 12}, 1, now)},
+				{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{1, math.NaN(), 5}, 2, now)},
+			},
+			[]*types.MetricData{types.MakeMetricData("transformNull(metric1,5)",
+				[]float64{1, 5, math.NaN(), math.NaN(), 5, 12}, 1, now)},
+		},
+		{
+			// nulls before the start of reference are kept
+			`transformNull(metric1,5,metric2)`,
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), 4, math.NaN()}, 1, now)},
+				{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{math.NaN(), 1, 1, 1}, 1, now+2)},
+			},
+			[]*types.MetricData{types.MakeMetricData("transformNull(metric1,5)",
+				[]float64{math.NaN(), math.NaN(), math.NaN(), 5, 4, 5}, 1, now)},
+		},
+		{
+			`transformNull(metric1, referenceSeries=metric2)`,
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {types.MakeMetricData("metric1", []float64{1, math.NaN(), math.NaN()}, 1, now)},
+				{"metric2", 0, 1}: {types.MakeMetricData("metric2", []float64{1, 1, math.NaN()}, 1, now)},
+			},
+			[]*types.MetricData{types.MakeMetricData("transformNull(metric1,0)",
+				[]float64{1, 0, math.NaN()}, 1, now)},
+		},
+		{
+			`transformNull(metric1,5,true)`,
+			map[parser.MetricRequest][]*types.MetricData{},
+			[]*types.MetricData{types.MakeMetricData("transformNull(metric1,5,true)",
+				[]float64{5, 5}, 1, 0)},
+		},
 	}
 
 	for _, tt := range tests {
@@ -3,6 +3,7 @@ package weightedAverage
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 
@@ -34,13 +35,13 @@ func New(configFile string) []interfaces.FunctionMetadata {
 // weightedAverage(seriesListAvg, seriesListWeight, *nodes)
 func (f *weightedAverage) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
 	aggKeyPairs := make(map[string]map[string]*types.MetricData)
-	var productList []*types.MetricData
+	var productList, weightList []*types.MetricData
 
-	avgs, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	avgs, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return nil, err
 	}
-	weights, err := helper.GetSeriesArg(e.Args()[1], from, until, values)
+	weights, err := helper.GetSeriesArg(ctx, e.Args()[1], from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +88,8 @@ func (f *weightedAverage) Do(ctx context.Context, e parser.Expr, from, until int
 	}
 	sort.Strings(weightNames)
 
+	// Only weights that have a matching value at the same timestamp contribute to the sum of weights,
+	// so NaN in either of the series excludes that pair at that timestamp.
 	for _, pair := range aggKeyPairs {
 		if _, ok := pair["avg"]; !ok {
 			continue
@@ -94,25 +97,30 @@ func (f *weightedAverage) Do(ctx context.Context, e parser.Expr, from, until int
 		if _, ok := pair["weight"]; !ok {
 			continue
 		}
-		product, err := helper.AggregateSeries(e, []*types.MetricData{pair["avg"], pair["weight"]}, consolidations.ConsolidationToFunc["multiply"])
+		product, err := helper.AggregateSeries(ctx, e, []*types.MetricData{pair["avg"], pair["weight"]}, pairProduct)
 		if err != nil {
 			return nil, err
 		}
 		productList = append(productList, product...)
+		weight, err := helper.AggregateSeries(ctx, e, []*types.MetricData{pair["avg"], pair["weight"]}, pairWeight)
+		if err != nil {
+			return nil, err
+		}
+		weightList = append(weightList, weight...)
 	}
 	if len(productList) == 0 {
 		return []*types.MetricData{}, nil
 	}
 
-	sumProducts, err := helper.AggregateSeries(e, productList, consolidations.AggSum)
+	sumProducts, err := helper.AggregateSeries(ctx, e, productList, consolidations.AggSum)
 	if err != nil {
 		return nil, err
 	}
-	sumWeights, err := helper.AggregateSeries(e, weights, consolidations.AggSum)
+	sumWeights, err := helper.AggregateSeries(ctx, e, weightList, consolidations.AggSum)
 	if err != nil {
 		return nil, err
 	}
-	weightedAverageSeries, err := helper.AggregateSeries(e, append(sumProducts, sumWeights...), func(v []float64) float64 { return v[0] / v[1] })
+	weightedAverageSeries, err := helper.AggregateSeries(ctx, e, append(sumProducts, sumWeights...), func(v []float64) float64 { return v[0] / v[1] })
 	if err != nil {
 		return nil, err
 	}
@@ -126,6 +134,22 @@ func (f *weightedAverage) Do(ctx context.Context, e parser.Expr, from, until int
 	return weightedAverageSeries, nil
 }
 
+// pairProduct returns value*weight, or NaN if any of them is absent
+func pairProduct(v []float64) float64 {
+	if math.IsNaN(v[0]) || math.IsNaN(v[1]) {
+		return math.NaN()
+	}
+	return v[0] * v[1]
+}
+
+// pairWeight returns weight only if the value is present as well
+func pairWeight(v []float64) float64 {
+	if math.IsNaN(v[0]) {
+		return math.NaN()
+	}
+	return v[1]
+}
+
 // Description is auto-generated description, based on output of https://github.com/graphite-project/graphite-web
 func (f *weightedAverage) Description() map[string]types.FunctionDescription {
 	return map[string]types.FunctionDescription{
@@ -150,7 +174,7 @@ func (f *weightedAverage) Description() map[string]types.FunctionDescription {
 				},
 			},
 			Group:       "Combine",
-			Description: "Takes a series of average values and a series of weights and\nproduces a weighted average for all values.\nThe corresponding values should share one or more zero-indexed nodes and/or tags.\n\nExample:\n\n.. code-block:: none\n\n  &target=weightedAverage(*.transactions.mean,*.transactions.count,0)\n\nEach node may be an integer referencing a node in the series name or a string identifying a tag.",
+			Description: "Takes a series of average values and a series of weights and\nproduces a weighted average for all values.\nThe corresponding values should share one or more zero-indexed nodes and/or tags.\nAt each timestamp only pairs where both value and weight are present are taken into account.\n\nExample:\n\n.. code-block:: none\n\n  &target=weightedAverage(*.transactions.mean,*.transactions.count,0)\n\nEach node may be an integer referencing a node in the series name or a string identifying a tag.",
 			Name:        "weightedAverage",
 		},
 	}
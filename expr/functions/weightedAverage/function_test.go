@@ -63,7 +63,7 @@ func TestAbsolute(t *testing.T) {
 			},
 			[]*types.MetricData{types.MakeMetricData(
 				"weightedAverage(metric1.dividend,metric2.dividend,metric3.dividend,metric5.dividend, metric1.divisor,metric3.divisor,metric4.divisor,metric5.divisor, 0)",
-				[]float64{0.75, 1.5, 1.5, 2.0, 5.0, 4.5, 7.0, 8.0, 6.75, 7.5, 8.25, 12.0, 9.75, 14.0, 15.0, 16.0, 17.0, 12.0, 9.5, 10.0}, 1, now32,
+				[]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, 1, now32,
 			),
 			},
 		},
@@ -79,6 +79,24 @@ func TestAbsolute(t *testing.T) {
 			},
 			[]*types.MetricData{},
 		},
+		{
+			"weightedAverage(metric*.value, metric*.weight, 0)",
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric*.value", 0, 1}: {
+					types.MakeMetricData("metric1.value", []float64{1, None, 3}, 1, now32),
+					types.MakeMetricData("metric2.value", []float64{4, 4, 4}, 1, now32),
+				},
+				{"metric*.weight", 0, 1}: {
+					types.MakeMetricData("metric1.weight", []float64{2, 2, None}, 1, now32),
+					types.MakeMetricData("metric2.weight", []float64{1, 1, 1}, 1, now32),
+				},
+			},
+			[]*types.MetricData{types.MakeMetricData(
+				"weightedAverage(metric1.value,metric2.value, metric1.weight,metric2.weight, 0)",
+				[]float64{2, 4, 4}, 1, now32,
+			),
+			},
+		},
 	}
 
 	for _, tt := range tests {
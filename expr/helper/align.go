@@ -1,11 +1,15 @@
 package helper
 
 import (
+	"context"
 	"math"
 	"time"
 
+	"github.com/ansel1/merry"
+
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/pkg/parser"
+	utilctx "github.com/go-graphite/carbonapi/util/ctx"
 )
 
 // GCD returns greatest common divisor calculated via Euclidean algorithm
@@ -149,11 +153,23 @@ func GetBuckets(start, stop, bucketSize int64) int64 {
 	return int64(math.Ceil(float64(stop-start) / float64(bucketSize)))
 }
 
-// AlignStartToInterval aligns start of serie to interval
-func AlignStartToInterval(start, stop, bucketSize int64) int64 {
+// TruncateInLocation rounds ts down to a multiple of size in local time of loc, e.x. to the local midnight for size
+// of 1 day. It's the same as truncating Unix time for UTC
+func TruncateInLocation(ts, size int64, loc *time.Location) int64 {
+	_, offset := time.Unix(ts, 0).In(loc).Zone()
+	local := ts + int64(offset)
+	rem := local % size
+	if rem < 0 {
+		rem += size
+	}
+	return ts - rem
+}
+
+// AlignStartToInterval aligns start of serie to interval: to the beginning of the day, hour or minute in local time of loc
+func AlignStartToInterval(start, stop, bucketSize int64, loc *time.Location) int64 {
 	for _, v := range []int64{86400, 3600, 60} {
 		if bucketSize >= v {
-			start -= start % v
+			start = TruncateInLocation(start, v, loc)
 			break
 		}
 	}
@@ -161,10 +177,10 @@ func AlignStartToInterval(start, stop, bucketSize int64) int64 {
 	return start
 }
 
-// AlignToBucketSize aligns start and stop of serie to specified bucket (step) size
-func AlignToBucketSize(start, stop, bucketSize int64) (int64, int64) {
-	start = time.Unix(start, 0).Truncate(time.Duration(bucketSize) * time.Second).Unix()
-	newStop := time.Unix(stop, 0).Truncate(time.Duration(bucketSize) * time.Second).Unix()
+// AlignToBucketSize aligns start and stop of serie to specified bucket (step) size in local time of loc
+func AlignToBucketSize(start, stop, bucketSize int64, loc *time.Location) (int64, int64) {
+	start = TruncateInLocation(start, bucketSize, loc)
+	newStop := TruncateInLocation(stop, bucketSize, loc)
 
 	// check if a partial bucket is needed
 	if stop != newStop {
@@ -243,6 +259,54 @@ func AlignSeries(args []*types.MetricData) []*types.MetricData {
 	return args
 }
 
+// Normalize brings series to the same grid, so they can be aggregated point by point. At first series are scaled
+// to the common step (unless ExtrapolatePoints is enabled, then AlignSeries takes care of steps), then they are padded
+// with NaNs to the same start, stop and number of points. Input series are not modified.
+// It returns normalized series together with their common start and step. If strict step is set in ctx, series
+// with different steps are not resampled and types.ErrStepMismatch is returned instead.
+func Normalize(ctx context.Context, args []*types.MetricData) ([]*types.MetricData, int64, int64, error) {
+	if len(args) == 0 {
+		return args, 0, 0, nil
+	}
+	if utilctx.GetStrictStep(ctx) {
+		if err := CheckSameStep(args); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	args = types.CopyMetricDataSlice(args)
+	if !ExtrapolatePoints {
+		args = ScaleToCommonStep(args, 0)
+	}
+	args = AlignSeries(args)
+
+	maxVals := 0
+	for _, arg := range args {
+		if len(arg.Values) > maxVals {
+			maxVals = len(arg.Values)
+		}
+	}
+	for _, arg := range args {
+		if len(arg.Values) < maxVals {
+			arg.Values = append(arg.Values, genNaNs(maxVals-len(arg.Values))...)
+			arg.StopTime = arg.StartTime + int64(maxVals)*arg.StepTime
+		}
+	}
+
+	return args, args[0].StartTime, args[0].StepTime, nil
+}
+
+// CheckSameStep returns types.ErrStepMismatch if series have different steps
+func CheckSameStep(args []*types.MetricData) error {
+	for _, arg := range args {
+		if arg.StepTime != args[0].StepTime {
+			return merry.WithMessagef(types.ErrStepMismatch, "%s: %s has step %d, %s has step %d",
+				types.ErrStepMismatch, args[0].Name, args[0].StepTime, arg.Name, arg.StepTime)
+		}
+	}
+	return nil
+}
+
 func genNaNs(length int) []float64 {
 	nans := make([]float64, length)
 	for i := range nans {
@@ -0,0 +1,126 @@
+package helper
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/ansel1/merry"
+
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+// CounterDelta returns the increase of a counter from prev to cur, as nonNegativeDerivative and perSecond compute it.
+// maxValue and minValue are the limits of the counter, NaN if not known. A counter that decreased is considered to be
+// wrapped from maxValue to minValue (0 if only maxValue is known), if only minValue is known the counter is considered
+// to be reset to it. NaN is returned if the decrease can't be explained that way, e.x. without limits or if prev is
+// above maxValue, so it isn't a counter of that size.
+func CounterDelta(prev, cur, maxValue, minValue float64) float64 {
+	hasMax := !math.IsNaN(maxValue)
+	hasMin := !math.IsNaN(minValue)
+	if hasMax && !hasMin {
+		minValue = 0
+	}
+
+	if diff := cur - prev; diff >= 0 {
+		return diff
+	} else if hasMax && maxValue >= cur {
+		if d := (maxValue - prev) + (cur - minValue) + 1; d >= 0 {
+			return d
+		}
+	} else if hasMin && minValue <= cur {
+		return cur - minValue
+	}
+	return math.NaN()
+}
+
+// CounterArgs are the arguments of functions that compute increase of counters, e.x. nonNegativeDerivative(seriesList,
+// maxValue=None, minValue=None, maxGap=0). MaxValue and MinValue are NaN if not passed.
+type CounterArgs struct {
+	MaxValue float64
+	MinValue float64
+	MaxGap   int
+
+	// hasMaxValue and hasMinValue are set if the limits were passed, even as None, so they are kept in the name
+	hasMaxValue bool
+	hasMinValue bool
+}
+
+// GetCounterArgs parses and validates maxValue, minValue and maxGap arguments of e that follow the seriesList
+func GetCounterArgs(e parser.Expr) (CounterArgs, error) {
+	var c CounterArgs
+	var err error
+
+	c.MaxValue, err = e.GetFloatNamedOrPosArgDefault("maxValue", 1, math.NaN())
+	if err != nil {
+		return c, err
+	}
+	c.MinValue, err = e.GetFloatNamedOrPosArgDefault("minValue", 2, math.NaN())
+	if err != nil {
+		return c, err
+	}
+	c.MaxGap, err = e.GetIntNamedOrPosArgDefault("maxGap", 3, 0)
+	if err != nil {
+		return c, err
+	}
+	if c.MaxGap < 0 {
+		return c, merry.WithMessagef(parser.ErrBadType, "%s: maxGap must be non-negative", parser.ErrBadType)
+	}
+	if !math.IsNaN(c.MaxValue) && !math.IsNaN(c.MinValue) && c.MaxValue <= c.MinValue {
+		return c, errors.New("minValue must be lower than maxValue")
+	}
+
+	_, ok := e.NamedArgs()["maxValue"]
+	c.hasMaxValue = ok || len(e.Args()) > 1
+	_, ok = e.NamedArgs()["minValue"]
+	c.hasMinValue = ok || len(e.Args()) > 2
+
+	return c, nil
+}
+
+// Name returns the name of the result series of fn for a series named name, e.x. nonNegativeDerivative(name,32,maxGap=1).
+// Limits are formatted without exponent, so 32-bit and 64-bit limits are rendered as they were passed.
+func (c CounterArgs) Name(fn, name string) string {
+	var res string
+	switch {
+	case c.hasMaxValue && c.hasMinValue:
+		res = FuncName(fn, name, formatCounterLimit(c.MaxValue), formatCounterLimit(c.MinValue))
+	case c.hasMinValue:
+		res = FuncName(fn, name, "minValue="+formatCounterLimit(c.MinValue))
+	case c.hasMaxValue:
+		res = FuncName(fn, name, formatCounterLimit(c.MaxValue))
+	default:
+		res = FuncName(fn, name)
+	}
+
+	if c.MaxGap > 0 {
+		res = res[:len(res)-1] + ",maxGap=" + strconv.Itoa(c.MaxGap) + ")"
+	}
+	return res
+}
+
+func formatCounterLimit(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// CounterIncrease returns increase of counter values between consecutive present points divided by scale: 1 for the
+// increase per point, step of the series for the increase per second. With MaxGap, increase over up to MaxGap absent
+// points is spread evenly over them.
+func (c CounterArgs) CounterIncrease(values []float64, scale float64) []float64 {
+	res := make([]float64, len(values))
+	prev, prevIdx := math.NaN(), -1
+	for i, v := range values {
+		res[i] = math.NaN()
+		if math.IsNaN(v) {
+			continue
+		}
+		if gap := i - prevIdx - 1; prevIdx >= 0 && gap <= c.MaxGap {
+			d := CounterDelta(prev, v, c.MaxValue, c.MinValue) / float64(gap+1) / scale
+			for j := prevIdx + 1; j <= i; j++ {
+				res[j] = d
+			}
+		}
+		prev, prevIdx = v, i
+	}
+	return res
+}
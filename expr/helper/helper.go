@@ -10,6 +10,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/ansel1/merry"
+	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/pkg/parser"
@@ -33,12 +34,14 @@ func SetEvaluator(e interfaces.Evaluator) {
 }
 
 // GetSeriesArg returns argument from series.
-func GetSeriesArg(arg parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+// Any series argument is a seriesList: a metric name, a glob or a function (e.x. group(a, b)) can evaluate to
+// any number of series, so functions should handle all of them instead of assuming a single one.
+func GetSeriesArg(ctx context.Context, arg parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
 	if !arg.IsName() && !arg.IsFunc() {
 		return nil, parser.ErrMissingTimeseries
 	}
 
-	a, err := evaluator.Eval(context.TODO(), arg, from, until, values)
+	a, err := evaluator.Eval(ctx, arg, from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -46,6 +49,74 @@ func GetSeriesArg(arg parser.Expr, from, until int64, values map[parser.MetricRe
 	return a, nil
 }
 
+// GetScalarArg returns n-th argument of e as a number. Besides a constant it accepts an expression that evaluates to
+// a single series with a single value, e.x. nPercentile(a,50) or a series of one point, so constants can be computed
+// by other functions. The series value is NaN if all of its points are NaN. The second returned value is the argument
+// as it should be rendered in the name of the result: the constant itself or the name of the series.
+func GetScalarArg(ctx context.Context, e parser.Expr, n int, from, until int64, values map[parser.MetricRequest][]*types.MetricData) (float64, interface{}, error) {
+	if len(e.Args()) <= n {
+		return 0, nil, parser.ErrMissingArgument
+	}
+	arg := e.Args()[n]
+	if !arg.IsName() && !arg.IsFunc() {
+		v, err := e.GetFloatArg(n)
+		return v, v, err
+	}
+
+	series, err := GetSeriesArg(ctx, arg, from, until, values)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(series) != 1 {
+		return 0, nil, merry.WithMessagef(parser.ErrBadType, "%s: %s should be a constant or a single series, got %d series", parser.ErrBadType, arg.ToString(), len(series))
+	}
+
+	v := math.NaN()
+	for _, x := range series[0].Values {
+		if math.IsNaN(x) {
+			continue
+		}
+		if !math.IsNaN(v) && x != v {
+			return 0, nil, merry.WithMessagef(parser.ErrBadType, "%s: %s should have a single value, got %v and %v", parser.ErrBadType, arg.ToString(), v, x)
+		}
+		v = x
+	}
+	return v, series[0].Name, nil
+}
+
+// FetchSeriesArg is GetSeriesArg for the time range that might be not fetched before evaluation. Missing data is fetched
+// if evaluator supports it, otherwise result is the same as of GetSeriesArg.
+func FetchSeriesArg(ctx context.Context, arg parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	if f, ok := evaluator.(interfaces.Fetcher); ok && (arg.IsName() || arg.IsFunc()) {
+		if err := f.Fetch(ctx, arg, from, until, values); err != nil {
+			return nil, err
+		}
+	}
+
+	return GetSeriesArg(ctx, arg, from, until, values)
+}
+
+// FuncName returns canonical name of the function applied to args: fn(arg1,arg2), without spaces after commas.
+// Arguments are formatted with their default format, e.x. 0.5 for float64 and 10 for int.
+func FuncName(fn string, args ...interface{}) string {
+	var sb strings.Builder
+	sb.WriteString(fn)
+	sb.WriteByte('(')
+	for i, arg := range args {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(fmt.Sprint(arg))
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// Quote wraps string argument in single quotes, the way string arguments are rendered in series names
+func Quote(s string) string {
+	return "'" + s + "'"
+}
+
 // RemoveEmptySeriesFromName removes empty series from list of names.
 func RemoveEmptySeriesFromName(args []*types.MetricData) string {
 	var argNames []string
@@ -57,11 +128,11 @@ func RemoveEmptySeriesFromName(args []*types.MetricData) string {
 }
 
 // GetSeriesArgs returns arguments of series
-func GetSeriesArgs(e []parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+func GetSeriesArgs(ctx context.Context, e []parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
 	var args []*types.MetricData
 
 	for _, arg := range e {
-		a, err := GetSeriesArg(arg, from, until, values)
+		a, err := GetSeriesArg(ctx, arg, from, until, values)
 		if err != nil && !merry.Is(err, parser.ErrSeriesDoesNotExist) {
 			return nil, err
 		}
@@ -77,8 +148,8 @@ func GetSeriesArgs(e []parser.Expr, from, until int64, values map[parser.MetricR
 
 // GetSeriesArgsAndRemoveNonExisting will fetch all required arguments, but will also filter out non existing Series
 // This is needed to be graphite-web compatible in cases when you pass non-existing Series to, for example, sumSeries
-func GetSeriesArgsAndRemoveNonExisting(e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
-	args, err := GetSeriesArgs(e.Args(), from, until, values)
+func GetSeriesArgsAndRemoveNonExisting(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error) {
+	args, err := GetSeriesArgs(ctx, e.Args(), from, until, values)
 	if err != nil {
 		return nil, err
 	}
@@ -117,61 +188,203 @@ func AggKey(arg *types.MetricData, nodesOrTags []parser.NodeOrTag) string {
 	return ""
 }
 
+// GroupByNodes groups series by AggKey of nodesOrTags, keys are returned in order of their first appearance
+func GroupByNodes(args []*types.MetricData, nodesOrTags []parser.NodeOrTag) (map[string][]*types.MetricData, []string) {
+	groups := make(map[string][]*types.MetricData)
+	var keys []string
+	for _, a := range args {
+		key := AggKey(a, nodesOrTags)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], a)
+	}
+	return groups, keys
+}
+
+// GroupByWildcards groups series by their names with nodes at positions removed, e.x. "a.b.c" with positions [1] goes
+// to group "a.c". Keys are returned in order of their first appearance
+func GroupByWildcards(args []*types.MetricData, positions []int) (map[string][]*types.MetricData, []string) {
+	groups := make(map[string][]*types.MetricData)
+	var keys []string
+	for _, a := range args {
+		metric := ExtractMetric(a.Name)
+		nodes := strings.Split(metric, ".")
+		var s []string
+		// Yes, this is O(n^2), but len(nodes) < 10 and len(fields) < 3
+		// Iterating an int slice is faster than a map for n ~ 30
+		// http://www.antoine.im/posts/someone_is_wrong_on_the_internet
+		for i, n := range nodes {
+			if !Contains(positions, i) {
+				s = append(s, n)
+			}
+		}
+
+		key := strings.Join(s, ".")
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], a)
+	}
+	return groups, keys
+}
+
+// RenameSeries returns series with names produced by rename from the names of args, for functions of alias family.
+// Values are shared with args, only name and "name" tag of the results are changed.
+func RenameSeries(args []*types.MetricData, rename func(name string) string) []*types.MetricData {
+	results := make([]*types.MetricData, 0, len(args))
+	for _, a := range args {
+		r := *a
+		r.Name = rename(a.Name)
+		r.Tags = make(map[string]string, len(a.Tags)+1)
+		for k, v := range a.Tags {
+			r.Tags[k] = v
+		}
+		r.Tags["name"] = r.Name
+		results = append(results, &r)
+	}
+	return results
+}
+
 type seriesFunc func(*types.MetricData, *types.MetricData) *types.MetricData
 
 // ForEachSeriesDo do action for each serie in list.
-func ForEachSeriesDo(e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData, function seriesFunc) ([]*types.MetricData, error) {
-	arg, err := GetSeriesArg(e.Args()[0], from, until, values)
+func ForEachSeriesDo(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData, function seriesFunc) ([]*types.MetricData, error) {
+	arg, err := GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
+		if merry.Is(err, context.Canceled, context.DeadlineExceeded) {
+			return nil, err
+		}
 		return nil, parser.ErrMissingTimeseries
 	}
 	var results []*types.MetricData
 
 	for _, a := range arg {
-		r := *a
-		r.Name = fmt.Sprintf("%s(%s)", e.Target(), a.Name)
+		if err := ctx.Err(); err != nil {
+			return nil, merry.Wrap(err)
+		}
+		// r shares nothing with a, so function is free to modify it
+		r := a.Copy(false)
+		r.Name = FuncName(e.Target(), a.Name)
 		r.Values = make([]float64, len(a.Values))
-		results = append(results, function(a, &r))
+		results = append(results, function(a, r))
 	}
 	return results, nil
 }
 
+// ctxCheckInterval is the number of points AggregateSeries computes between checks of the request context
+const ctxCheckInterval = 1024
+
 // AggregateFunc type that defined aggregate function
 type AggregateFunc func([]float64) float64
 
 // AggregateSeries aggregates series
-func AggregateSeries(e parser.Expr, args []*types.MetricData, function AggregateFunc) ([]*types.MetricData, error) {
-	args = AlignSeries(args)
+func AggregateSeries(ctx context.Context, e parser.Expr, args []*types.MetricData, function AggregateFunc) ([]*types.MetricData, error) {
+	r, err := aggregateResult(ctx, e, &args)
+	if err != nil {
+		return nil, err
+	}
+
+	// values is reused for every point and returned to the pool, function mustn't keep it
+	values := consolidations.GetBuffer(len(args))
+	defer consolidations.PutBuffer(values)
+	for i := range r.Values {
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, merry.Wrap(err)
+			}
+		}
+		for j, arg := range args {
+			values[j] = arg.Values[i]
+		}
+
+		r.Values[i] = math.NaN()
+		if len(values) > 0 {
+			r.Values[i] = function(values)
+		}
+	}
+
+	return []*types.MetricData{r}, nil
+}
+
+// SumSeries is AggregateSeries with consolidations.AggSum, but it adds up series one by one instead of collecting
+// values of every point, which is much faster for a large number of series
+func SumSeries(ctx context.Context, e parser.Expr, args []*types.MetricData) ([]*types.MetricData, error) {
+	return sumSeries(ctx, e, args, false)
+}
+
+// AverageSeries is AggregateSeries with consolidations.AggMean, see SumSeries
+func AverageSeries(ctx context.Context, e parser.Expr, args []*types.MetricData) ([]*types.MetricData, error) {
+	return sumSeries(ctx, e, args, true)
+}
 
-	needScale := false
-	for i := 1; i < len(args); i++ {
-		if args[i].StepTime != args[0].StepTime {
-			needScale = true
-			break
+func sumSeries(ctx context.Context, e parser.Expr, args []*types.MetricData, average bool) ([]*types.MetricData, error) {
+	r, err := aggregateResult(ctx, e, &args)
+	if err != nil {
+		return nil, err
+	}
+	sums := r.Values
+	counts := make([]int, len(sums))
+
+	for _, arg := range args {
+		if err := ctx.Err(); err != nil {
+			return nil, merry.Wrap(err)
+		}
+		values := arg.Values[:len(sums)]
+		counts := counts[:len(values)]
+		for i, v := range values {
+			if !math.IsNaN(v) {
+				sums[i] += v
+				counts[i]++
+			}
 		}
 	}
-	if needScale {
-		ScaleToCommonStep(args, 0)
+
+	for i, n := range counts {
+		switch {
+		case n == 0:
+			sums[i] = math.NaN()
+		case average:
+			sums[i] /= float64(n)
+		}
 	}
 
-	length := len(args[0].Values)
-	r := *args[0]
-	r.Name = fmt.Sprintf("%s(%s)", e.Target(), e.RawArgs())
-	r.Values = make([]float64, length)
+	return []*types.MetricData{r}, nil
+}
 
-	for i := range args[0].Values {
-		var values []float64
-		for _, arg := range args {
-			values = append(values, arg.Values[i])
+// aggregateResult normalizes args in place and returns series to store result of their aggregation into.
+// Normalization copies every series, so it's skipped if series are already aligned.
+func aggregateResult(ctx context.Context, e parser.Expr, args *[]*types.MetricData) (*types.MetricData, error) {
+	aligned := alignedSeries(*args)
+	if !aligned {
+		var err error
+		if *args, _, _, err = Normalize(ctx, *args); err != nil {
+			return nil, err
 		}
+	}
 
-		r.Values[i] = math.NaN()
-		if len(values) > 0 {
-			r.Values[i] = function(values)
+	r := *(*args)[0]
+	r.Name = FuncName(e.Target(), e.RawArgs())
+	r.Values = make([]float64, len((*args)[0].Values))
+	if aligned {
+		// series isn't a copy, so its tags can't be shared with the result
+		r.Tags = make(map[string]string, len((*args)[0].Tags))
+		for k, v := range (*args)[0].Tags {
+			r.Tags[k] = v
 		}
 	}
+	return &r, nil
+}
 
-	return []*types.MetricData{&r}, nil
+// alignedSeries returns true if all series have the same step, start time and number of points
+func alignedSeries(args []*types.MetricData) bool {
+	first := args[0]
+	for _, arg := range args[1:] {
+		if arg.StepTime != first.StepTime || arg.StartTime != first.StartTime || len(arg.Values) != len(first.Values) {
+			return false
+		}
+	}
+	return true
 }
 
 // ExtractMetric extracts metric out of function list
@@ -1,12 +1,18 @@
 package helper
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"testing"
+	"time"
+
+	"github.com/ansel1/merry"
 
 	"github.com/go-graphite/carbonapi/expr/tags"
 	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+	utilctx "github.com/go-graphite/carbonapi/util/ctx"
 )
 
 func TestExtractTags(t *testing.T) {
@@ -259,3 +265,316 @@ func TestScaleToCommonStep(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalize(t *testing.T) {
+	NaN := math.NaN()
+	tests := []struct {
+		name     string
+		metrics  []*types.MetricData
+		start    int64
+		step     int64
+		expected []*types.MetricData
+	}{
+		{
+			"Same step, different start and stop",
+			[]*types.MetricData{
+				types.MakeMetricData("metric1", []float64{1, 2, 3}, 2, 4),    // 4..10
+				types.MakeMetricData("metric2", []float64{1, 2, 3, 4}, 2, 0), // 0..8
+			},
+			0,
+			2,
+			[]*types.MetricData{
+				types.MakeMetricData("metric1", []float64{NaN, NaN, 1, 2, 3}, 2, 0), // 0..10
+				types.MakeMetricData("metric2", []float64{1, 2, 3, 4, NaN}, 2, 0),   // 0..10
+			},
+		},
+		{
+			"Different steps",
+			[]*types.MetricData{
+				types.MakeMetricData("metric1", []float64{1, 3, 5, 7, 9, 11, 13, 15, 17}, 1, 4), // 4..13
+				types.MakeMetricData("metric2", []float64{1, 2, 3, 4, 5}, 2, 4),                 // 4..14
+				types.MakeMetricData("metric3", []float64{1, 2, 3, 4, 5, 6}, 3, 3),              // 3..21
+			},
+			0,
+			6,
+			[]*types.MetricData{
+				types.MakeMetricData("metric1", []float64{2, 10, 17, NaN}, 6, 0), // 0..24
+				types.MakeMetricData("metric2", []float64{1, 3, 5, NaN}, 6, 0),   // 0..24
+				types.MakeMetricData("metric3", []float64{1, 2.5, 4.5, 6}, 6, 0), // 0..24
+			},
+		},
+		{
+			"Longest series goes first",
+			[]*types.MetricData{
+				types.MakeMetricData("metric1", []float64{1, 2, 3, 4, 5, 6}, 3, 3), // 3..21
+				types.MakeMetricData("metric2", []float64{1, 2, 3, 4, 5}, 2, 4),    // 4..14
+			},
+			0,
+			6,
+			[]*types.MetricData{
+				types.MakeMetricData("metric1", []float64{1, 2.5, 4.5, 6}, 6, 0), // 0..24
+				types.MakeMetricData("metric2", []float64{1, 3, 5, NaN}, 6, 0),   // 0..24
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := types.CopyMetricDataSlice(tt.metrics)
+			result, start, step, err := Normalize(context.Background(), tt.metrics)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if start != tt.start {
+				t.Errorf("start %v != expected %v", start, tt.start)
+			}
+			if step != tt.step {
+				t.Errorf("step %v != expected %v", step, tt.step)
+			}
+			if len(result) != len(tt.expected) {
+				t.Fatalf("Result has different length %v than expected %v", len(result), len(tt.expected))
+			}
+			for i, r := range result {
+				e := tt.expected[i]
+				if len(r.Values) != len(e.Values) {
+					t.Fatalf("Values of result[%v] has the different length %v than expected %v", i, len(r.Values), len(e.Values))
+				}
+				for v, rv := range r.Values {
+					ev := e.Values[v]
+					if math.IsNaN(rv) != math.IsNaN(ev) {
+						t.Errorf("One of result[%v][%v] is NaN, but not the second: result=%v, expected=%v", i, v, rv, ev)
+					} else if !math.IsNaN(rv) && (rv != ev) {
+						t.Errorf("result[%v][%v] %v != expected[%v][%v]: %v", i, v, rv, i, v, ev)
+					}
+				}
+				if r.StartTime != e.StartTime {
+					t.Errorf("result[%v].StartTime %v != expected[%v].StartTime %v", i, r.StartTime, i, e.StartTime)
+				}
+				if r.StopTime != e.StopTime {
+					t.Errorf("result[%v].StopTime %v != expected[%v].StopTime %v", i, r.StopTime, i, e.StopTime)
+				}
+				if r.StepTime != e.StepTime {
+					t.Errorf("result[%v].StepTime %v != expected[%v].StepTime %v", i, r.StepTime, i, e.StepTime)
+				}
+			}
+			for i, m := range tt.metrics {
+				if m.StartTime != original[i].StartTime || m.StepTime != original[i].StepTime || len(m.Values) != len(original[i].Values) {
+					t.Errorf("input metric %v was modified", m.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		v      float64
+		system string
+		want   string
+	}{
+		{0, "si", "0.00"},
+		{999, "si", "999.00"},
+		{1000, "si", "1.00K"},
+		{1500000, "si", "1.50M"},
+		{-2500000000, "si", "-2.50G"},
+		{3e12, "si", "3.00T"},
+		{1023, "binary", "1023.00"},
+		{1024, "binary", "1.00Ki"},
+		{1500000, "binary", "1.43Mi"},
+		{1073741824, "binary", "1.00Gi"},
+		{1500000, "", "1500000.00"},
+		{math.NaN(), "si", "NaN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v_%s", tt.v, tt.system), func(t *testing.T) {
+			if got := FormatUnits(tt.v, tt.system); got != tt.want {
+				t.Errorf("FormatUnits(%v, %q) = %q, want %q", tt.v, tt.system, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidUnitSystem(t *testing.T) {
+	for system, want := range map[string]bool{"si": true, "binary": true, "": false, "metric": false} {
+		if got := ValidUnitSystem(system); got != want {
+			t.Errorf("ValidUnitSystem(%q) = %v, want %v", system, got, want)
+		}
+	}
+}
+
+func TestFuncName(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"no args", FuncName("fn"), "fn()"},
+		{"series", FuncName("absolute", "metric1"), "absolute(metric1)"},
+		{"float", FuncName("scale", "metric1", 2.5), "scale(metric1,2.5)"},
+		{"big float", FuncName("nPercentile", "metric1", 1e6), "nPercentile(metric1,1e+06)"},
+		{"int", FuncName("keepLastValue", "metric1", 3), "keepLastValue(metric1,3)"},
+		{"quoted string", FuncName("movingAverage", "metric1", Quote("5min")), "movingAverage(metric1,'5min')"},
+		{"mixed", FuncName("slo", "metric1", "10sec", "above", 2.0), "slo(metric1,10sec,above,2)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenameSeries(t *testing.T) {
+	a := types.MakeMetricData("metric.A", []float64{1, 2, 3}, 1, 0)
+	a.Tags["dc"] = "x"
+
+	results := RenameSeries([]*types.MetricData{a}, func(name string) string { return name + ".b" })
+	if len(results) != 1 {
+		t.Fatalf("expected 1 series, got %d", len(results))
+	}
+	r := results[0]
+	if r.Name != "metric.A.b" || r.Tags["name"] != "metric.A.b" || r.Tags["dc"] != "x" {
+		t.Errorf("unexpected name or tags: %s %v", r.Name, r.Tags)
+	}
+	if a.Name != "metric.A" || a.Tags["name"] != "metric.A" {
+		t.Errorf("original series changed: %s %v", a.Name, a.Tags)
+	}
+	if &r.Values[0] != &a.Values[0] {
+		t.Errorf("expected values to be shared")
+	}
+}
+
+func TestTruncateInLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("timezone database is not available: %v", err)
+	}
+	ts := time.Date(2021, 6, 1, 13, 47, 12, 0, loc).Unix()
+
+	tests := []struct {
+		name string
+		size int64
+		loc  *time.Location
+		want int64
+	}{
+		{"day UTC", 86400, time.UTC, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC).Unix()},
+		{"day local", 86400, loc, time.Date(2021, 6, 1, 0, 0, 0, 0, loc).Unix()},
+		// offset is +05:30, so hours are aligned differently too
+		{"hour local", 3600, loc, time.Date(2021, 6, 1, 13, 0, 0, 0, loc).Unix()},
+		{"hour UTC", 3600, time.UTC, time.Date(2021, 6, 1, 13, 30, 0, 0, loc).Unix()},
+		{"minute local", 60, loc, time.Date(2021, 6, 1, 13, 47, 0, 0, loc).Unix()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TruncateInLocation(ts, tt.size, tt.loc); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeStrictStep(t *testing.T) {
+	ctx := utilctx.SetStrictStep(context.Background(), true)
+
+	// different ranges are padded as usual
+	_, _, _, err := Normalize(ctx, []*types.MetricData{
+		types.MakeMetricData("metric1", []float64{1, 2, 3}, 10, 0),
+		types.MakeMetricData("metric2", []float64{1, 2}, 10, 10),
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, _, _, err = Normalize(ctx, []*types.MetricData{
+		types.MakeMetricData("metric1", []float64{1, 2, 3, 4}, 10, 0),
+		types.MakeMetricData("metric2", []float64{1, 2}, 20, 0),
+	})
+	if !merry.Is(err, types.ErrStepMismatch) {
+		t.Errorf("expected ErrStepMismatch, got %v", err)
+	}
+}
+
+func TestCounterDelta(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name     string
+		prev     float64
+		cur      float64
+		maxValue float64
+		minValue float64
+		expected float64
+	}{
+		{"increase", 10, 15, nan, nan, 5},
+		{"no change", 10, 10, nan, nan, 0},
+		{"increase with maxValue", 10, 15, 255, nan, 5},
+		{"reset without maxValue", 15, 10, nan, nan, nan},
+		{"wrap with maxValue", 250, 4, 255, nan, 10},
+		{"wrap with maxValue and minValue", 250, 4, 255, 2, 8},
+		{"value above maxValue", 300, 4, 255, nan, nan},
+		{"current above maxValue", 250, 260, 255, nan, 10},
+		{"decrease to above maxValue", 300, 260, 255, nan, nan},
+		{"reset to minValue", 15, 10, nan, 5, 5},
+		{"reset below minValue", 15, 3, nan, 5, nan},
+		{"absent previous value", nan, 10, 255, nan, nan},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := CounterDelta(tt.prev, tt.cur, tt.maxValue, tt.minValue)
+			if value != tt.expected && !(math.IsNaN(value) && math.IsNaN(tt.expected)) {
+				t.Errorf("CounterDelta(%v, %v, %v, %v) = %v, want %v", tt.prev, tt.cur, tt.maxValue, tt.minValue, value, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCounterArgs(t *testing.T) {
+	nan := math.NaN()
+	values := []float64{2, 4, nan, 8, nan, nan, nan, 9}
+	tests := []struct {
+		target   string
+		name     string
+		scale    float64
+		expected []float64
+	}{
+		{"nonNegativeDerivative(metric1)", "nonNegativeDerivative(metric1)", 1, []float64{nan, 2, nan, nan, nan, nan, nan, nan}},
+		{"nonNegativeDerivative(metric1,maxGap=1)", "nonNegativeDerivative(metric1,maxGap=1)", 1, []float64{nan, 2, 2, 2, nan, nan, nan, nan}},
+		{"perSecond(metric1,maxGap=3)", "perSecond(metric1,maxGap=3)", 2, []float64{nan, 1, 1, 1, 0.125, 0.125, 0.125, 0.125}},
+		{"perSecond(metric1,4294967295)", "perSecond(metric1,4294967295)", 10, []float64{nan, 0.2, nan, nan, nan, nan, nan, nan}},
+		{"perSecond(metric1,minValue=1)", "perSecond(metric1,minValue=1)", 1, []float64{nan, 2, nan, nan, nan, nan, nan, nan}},
+		{"perSecond(metric1,32,1,maxGap=1)", "perSecond(metric1,32,1,maxGap=1)", 1, []float64{nan, 2, 2, 2, nan, nan, nan, nan}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			e, _, err := parser.ParseExpr(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := GetCounterArgs(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name := c.Name(e.Target(), "metric1"); name != tt.name {
+				t.Errorf("unexpected name: got %s, want %s", name, tt.name)
+			}
+			got := c.CounterIncrease(values, tt.scale)
+			for i := range got {
+				if got[i] != tt.expected[i] && !(math.IsNaN(got[i]) && math.IsNaN(tt.expected[i])) {
+					t.Errorf("unexpected increase: got %v, want %v", got, tt.expected)
+					break
+				}
+			}
+		})
+	}
+
+	for _, target := range []string{"perSecond(metric1,maxGap=-1)", "perSecond(metric1,1,2)"} {
+		e, _, err := parser.ParseExpr(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := GetCounterArgs(e); err == nil {
+			t.Errorf("%s: expected error", target)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ansel1/merry"
+	"github.com/go-graphite/carbonapi/expr/consolidations"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+// reducerStub is the metric name series are passed to reducer under
+const reducerStub = "stub"
+
+// CheckReducer returns an error if callback can't be used by Reduce.
+//
+// Reducer is either a name of aggregation function supported by aggregate (sum, average, max, ...) or a name of any
+// registered function that takes a seriesList as its only argument and returns a single series, e.x. sumSeries or a
+// custom function. Functions that return any other number of series fail in Reduce.
+func CheckReducer(callback string) error {
+	if _, ok := consolidations.ConsolidationToFunc[callback]; ok {
+		return nil
+	}
+	metadata.FunctionMD.RLock()
+	_, ok := metadata.FunctionMD.Functions[callback]
+	metadata.FunctionMD.RUnlock()
+	if !ok {
+		return merry.WithHTTPCode(ErrUnknownFunction(callback), 400)
+	}
+	return nil
+}
+
+// Reduce applies callback to series and returns the single series it produces, see CheckReducer for the callbacks
+// that can be used. The name of the result is the one given by the callback, e.x. sumSeries(stub). The result can be
+// modified by caller, series that callback returns as is (e.x. highestMax) are copied.
+func Reduce(ctx context.Context, callback string, series []*types.MetricData, from, until int64) (*types.MetricData, error) {
+	if err := CheckReducer(callback); err != nil {
+		return nil, err
+	}
+
+	var target string
+	if _, ok := consolidations.ConsolidationToFunc[callback]; ok {
+		target = fmt.Sprintf("aggregate(%s,%q)", reducerStub, callback)
+	} else {
+		target = fmt.Sprintf("%s(%s)", callback, reducerStub)
+	}
+	exp, _, err := parser.ParseExpr(target)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[parser.MetricRequest][]*types.MetricData{
+		{Metric: reducerStub, From: from, Until: until}: series,
+	}
+	results, err := evaluator.Eval(ctx, exp, from, until, values)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != 1 {
+		return nil, merry.WithMessagef(parser.ErrBadType, "%s: callback %s must return a single series, got %d", parser.ErrBadType, callback, len(results))
+	}
+	for _, s := range series {
+		if s == results[0] {
+			return s.Copy(true), nil
+		}
+	}
+	return results[0], nil
+}
@@ -0,0 +1,56 @@
+package helper
+
+import (
+	"fmt"
+	"math"
+)
+
+type unitPrefix struct {
+	prefix string
+	size   float64
+}
+
+var unitSystems = map[string][]unitPrefix{
+	"binary": {
+		{"Pi", 1125899906842624.0}, // 1024^5
+		{"Ti", 1099511627776.0},    // 1024^4
+		{"Gi", 1073741824.0},       // 1024^3
+		{"Mi", 1048576.0},          // 1024^2
+		{"Ki", 1024.0},
+	},
+	"si": {
+		{"P", 1000000000000000.0}, // 1000^5
+		{"T", 1000000000000.0},    // 1000^4
+		{"G", 1000000000.0},       // 1000^3
+		{"M", 1000000.0},          // 1000^2
+		{"K", 1000.0},
+	},
+}
+
+// ValidUnitSystem checks if system is one of the unit systems supported by ScaleUnits and FormatUnits: "si" or "binary"
+func ValidUnitSystem(system string) bool {
+	_, ok := unitSystems[system]
+	return ok
+}
+
+// ScaleUnits scales v to the biggest prefix of unit system that is not greater than v, the same way as graphite-web does
+func ScaleUnits(v float64, system string) (float64, string) {
+	for _, p := range unitSystems[system] {
+		if math.Abs(v) >= p.size {
+			v2 := v / p.size
+			if (v2-math.Floor(v2)) < 0.00000000001 && v > 1 {
+				v2 = math.Floor(v2)
+			}
+			return v2, p.prefix
+		}
+	}
+
+	return v, ""
+}
+
+// FormatUnits formats v with two decimal places and a prefix of unit system, e.x. 1500000 is "1.50M" in "si" and "1.43Mi" in "binary".
+// Values less than 1000 (1024) don't get any prefix, unknown systems are formatted without scaling.
+func FormatUnits(v float64, system string) string {
+	v, prefix := ScaleUnits(v, system)
+	return fmt.Sprintf("%.2f%s", v, prefix)
+}
@@ -5,8 +5,40 @@ package holtwinters
 
 import (
 	"math"
+
+	"github.com/ansel1/merry"
+
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+const (
+	// DefaultBootstrapInterval is the length of history used to bootstrap the forecast, one week
+	DefaultBootstrapInterval = 7 * 86400
+	// DefaultSeasonality is the length of the season, one day
+	DefaultSeasonality = 86400
 )
 
+// GetBootstrapIntervalAndSeasonality returns bootstrapInterval argument of e at position pos and seasonality argument
+// that follows it. bootstrapInterval has to be a multiple of seasonality, so the forecast starts with full seasons.
+func GetBootstrapIntervalAndSeasonality(e parser.Expr, pos int) (int64, int64, error) {
+	bootstrapInterval, err := e.GetIntervalNamedOrPosArgDefault("bootstrapInterval", pos, 1, DefaultBootstrapInterval)
+	if err != nil {
+		return 0, 0, err
+	}
+	seasonality, err := e.GetIntervalNamedOrPosArgDefault("seasonality", pos+1, 1, DefaultSeasonality)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if seasonality <= 0 || bootstrapInterval <= 0 {
+		return 0, 0, merry.WithMessagef(parser.ErrBadType, "%s: bootstrapInterval and seasonality must be positive", parser.ErrBadType)
+	}
+	if bootstrapInterval%seasonality != 0 {
+		return 0, 0, merry.WithMessagef(parser.ErrBadType, "%s: bootstrapInterval (%ds) must be a multiple of seasonality (%ds)", parser.ErrBadType, bootstrapInterval, seasonality)
+	}
+	return bootstrapInterval, seasonality, nil
+}
+
 func holtWintersIntercept(alpha, actual, lastSeason, lastIntercept, lastSlope float64) float64 {
 	return alpha*(actual-lastSeason) + (1-alpha)*(lastIntercept+lastSlope)
 }
@@ -26,16 +58,18 @@ func holtWintersDeviation(gamma, actual, prediction, lastSeasonalDev float64) fl
 	return gamma*math.Abs(actual-prediction) + (1-gamma)*lastSeasonalDev
 }
 
-// HoltWintersAnalysis do Holt-Winters Analysis
-func HoltWintersAnalysis(series []float64, step int64) ([]float64, []float64) {
+// HoltWintersAnalysis do Holt-Winters Analysis with season of seasonality seconds
+func HoltWintersAnalysis(series []float64, step int64, seasonality int64) ([]float64, []float64) {
 	const (
 		alpha = 0.1
 		beta  = 0.0035
 		gamma = 0.1
 	)
 
-	// season is currently one day
-	seasonLength := 24 * 60 * 60 / int(step)
+	seasonLength := int(seasonality / step)
+	if seasonLength < 1 {
+		seasonLength = 1
+	}
 
 	var (
 		intercepts  []float64
@@ -96,12 +130,16 @@ func HoltWintersAnalysis(series []float64, step int64) ([]float64, []float64) {
 		}
 
 		lastSeasonal := getLastSeasonal(i)
-		nextLastSeasonal := getLastSeasonal(i + 1)
 		lastSeasonalDev := getLastDeviation(i)
 
 		intercept := holtWintersIntercept(alpha, actual, lastSeasonal, lastIntercept, lastSlope)
 		slope := holtWintersSlope(beta, intercept, lastIntercept, lastSlope)
 		seasonal := holtWintersSeasonal(gamma, actual, intercept, lastSeasonal)
+		// with season of one point the next point is in the next season after the current one
+		nextLastSeasonal := seasonal
+		if seasonLength > 1 {
+			nextLastSeasonal = getLastSeasonal(i + 1)
+		}
 		nextPred = intercept + slope + nextLastSeasonal
 		deviation := holtWintersDeviation(gamma, actual, prediction, lastSeasonalDev)
 
@@ -115,13 +153,14 @@ func HoltWintersAnalysis(series []float64, step int64) ([]float64, []float64) {
 	return predictions, deviations
 }
 
-// HoltWintersConfidenceBands do Holt-Winters Confidence Bands
-func HoltWintersConfidenceBands(series []float64, step int64, delta float64, days int64) ([]float64, []float64) {
+// HoltWintersConfidenceBands do Holt-Winters Confidence Bands, first bootstrapInterval seconds of series are only used
+// to bootstrap the forecast
+func HoltWintersConfidenceBands(series []float64, step int64, delta float64, bootstrapInterval, seasonality int64) ([]float64, []float64) {
 	var lowerBand, upperBand []float64
 
-	predictions, deviations := HoltWintersAnalysis(series, step)
+	predictions, deviations := HoltWintersAnalysis(series, step, seasonality)
 
-	windowPoints := int(days * 86400 / step)
+	windowPoints := int(bootstrapInterval / step)
 
 	var (
 		predictionsOfInterest []float64
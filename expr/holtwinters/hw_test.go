@@ -0,0 +1,36 @@
+package holtwinters
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHoltWintersAnalysisSeasonality(t *testing.T) {
+	// series with a period of 2 points
+	series := make([]float64, 200)
+	for i := range series {
+		series[i] = float64(10 * (i % 2))
+	}
+
+	errorOf := func(seasonality int64) float64 {
+		predictions, _ := HoltWintersAnalysis(series, 1, seasonality)
+		if len(predictions) != len(series) {
+			t.Fatalf("seasonality %d: got %d predictions, want %d", seasonality, len(predictions), len(series))
+		}
+		var sum float64
+		for i := len(series) - 20; i < len(series); i++ {
+			sum += math.Abs(predictions[i] - series[i])
+		}
+		return sum
+	}
+
+	matching, daily := errorOf(2), errorOf(DefaultSeasonality)
+	if matching >= daily {
+		t.Errorf("forecast with matching seasonality should be closer: error %v, with daily seasonality %v", matching, daily)
+	}
+
+	// season shorter than the step is one point long
+	if predictions, _ := HoltWintersAnalysis(series, 10, 1); len(predictions) != len(series) {
+		t.Errorf("got %d predictions, want %d", len(predictions), len(series))
+	}
+}
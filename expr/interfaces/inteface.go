@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"errors"
 
 	"github.com/go-graphite/carbonapi/expr/types"
 	"github.com/go-graphite/carbonapi/pkg/parser"
@@ -27,6 +28,13 @@ type Evaluator interface {
 	Eval(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) ([]*types.MetricData, error)
 }
 
+// Fetcher is implemented by evaluators that can fetch data during evaluation. It's needed when function knows how much
+// data it needs only after it has seen the series, e.x. the step of series for a window given in points.
+type Fetcher interface {
+	// Fetch adds data of metrics of e for [from, until] to values, metrics that are already there aren't fetched again
+	Fetch(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) error
+}
+
 type Order int
 
 const (
@@ -56,6 +64,22 @@ type Function interface {
 	Description() map[string]types.FunctionDescription
 }
 
+// SeriesTransform computes result of a function for a single series. It must not modify the input series
+type SeriesTransform func(a *types.MetricData) *types.MetricData
+
+// ErrNotStreamable is returned by StreamingFunction.Transform when particular call can't be evaluated series by series,
+// expression is evaluated with Do then
+var ErrNotStreamable = errors.New("function can't be evaluated series by series")
+
+// StreamingFunction is implemented by functions that transform every series of their first argument independently
+// of the others, e.x. scale. Such functions can be evaluated series by series (see expr.EvalStream),
+// so results of wide series lists don't have to be kept in memory at once.
+type StreamingFunction interface {
+	// Transform parses arguments of e and returns transform that is applied to each series of the first argument,
+	// together with the time range the first argument should be evaluated with
+	Transform(ctx context.Context, e parser.Expr, from, until int64) (transform SeriesTransform, argFrom, argUntil int64, err error)
+}
+
 // Function is interface that all graphite functions should follow
 type RewriteFunction interface {
 	SetEvaluator(evaluator Evaluator)
@@ -0,0 +1,76 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/ansel1/merry"
+)
+
+// ErrTooManyFunctionCalls is returned when evaluation of a target calls more functions than allowed by WithFunctionCallsLimit
+var ErrTooManyFunctionCalls = errors.New("too many function calls")
+
+// ErrTooManySeries is returned when backend returns more series for a request than allowed by WithSeriesLimit
+var ErrTooManySeries = errors.New("too many series")
+
+type functionCallsKey struct{}
+
+type functionCalls struct {
+	limit int64
+	calls int64
+}
+
+// WithFunctionCallsLimit returns context that limits total number of function calls made by EvalExpr
+// (including calls of nested functions) to limit. The counter is shared by all evaluations with the returned
+// context, so it should be created for every target. limit <= 0 means no limit.
+func WithFunctionCallsLimit(ctx context.Context, limit int) context.Context {
+	if limit <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, functionCallsKey{}, &functionCalls{limit: int64(limit)})
+}
+
+// countFunctionCall returns ErrTooManyFunctionCalls if ctx has a limit of function calls and it's exceeded
+func countFunctionCall(ctx context.Context) error {
+	c, ok := ctx.Value(functionCallsKey{}).(*functionCalls)
+	if !ok {
+		return nil
+	}
+	if atomic.AddInt64(&c.calls, 1) > c.limit {
+		err := merry.WithMessagef(ErrTooManyFunctionCalls, "%s: maximum is %d", ErrTooManyFunctionCalls, c.limit)
+		return merry.WithHTTPCode(err, 400)
+	}
+	return nil
+}
+
+type seriesLimitKey struct{}
+
+type seriesLimit struct {
+	limit  int64
+	series int64
+}
+
+// WithSeriesLimit returns context that limits total number of series fetched from backend by all evaluations with
+// the returned context to limit, so a wildcard that matches too many series fails before it's evaluated. The counter
+// is shared, so it should be created for every request. limit <= 0 means no limit.
+func WithSeriesLimit(ctx context.Context, limit int) context.Context {
+	if limit <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, seriesLimitKey{}, &seriesLimit{limit: int64(limit)})
+}
+
+// countSeries adds n fetched series to the counter of ctx and returns ErrTooManySeries if it has a limit of series and
+// it's exceeded. n = 0 checks if the limit is already exceeded by previous fetches.
+func countSeries(ctx context.Context, n int) error {
+	c, ok := ctx.Value(seriesLimitKey{}).(*seriesLimit)
+	if !ok {
+		return nil
+	}
+	if series := atomic.AddInt64(&c.series, int64(n)); series > c.limit {
+		err := merry.WithMessagef(ErrTooManySeries, "%s: %d series matched, maximum is %d", ErrTooManySeries, series, c.limit)
+		return merry.WithHTTPCode(err, 400)
+	}
+	return nil
+}
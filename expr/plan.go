@@ -0,0 +1,91 @@
+package expr
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+// QueryPlan records what evaluation of a request did: metrics fetched from backend and series produced by every
+// target. It's kept in the context (see WithQueryPlan), so fetches made by functions during evaluation are recorded too.
+// Times are in seconds, as in the access log.
+type QueryPlan struct {
+	mu sync.Mutex
+
+	Fetches []*PlanFetch  `json:"fetches"`
+	Targets []*PlanTarget `json:"targets"`
+	// Runtime is the total time of the request, set by its handler
+	Runtime float64 `json:"runtime"`
+}
+
+// PlanFetch is a metric fetched from backend. Metrics are fetched in batches, Runtime is the time of the whole batch.
+type PlanFetch struct {
+	Metric  string  `json:"metric"`
+	From    int64   `json:"from"`
+	Until   int64   `json:"until"`
+	Series  int     `json:"series"`
+	Runtime float64 `json:"runtime"`
+	// Prefetched is true for metrics fetched for all targets at once before evaluation
+	Prefetched bool `json:"prefetched,omitempty"`
+}
+
+// PlanTarget is a target of the request with the number of series it produced
+type PlanTarget struct {
+	Target  string  `json:"target"`
+	Series  int     `json:"series"`
+	Runtime float64 `json:"runtime"`
+	Error   string  `json:"error,omitempty"`
+}
+
+type queryPlanKey struct{}
+
+// WithQueryPlan returns context that records fetches made by evaluation to plan
+func WithQueryPlan(ctx context.Context, plan *QueryPlan) context.Context {
+	return context.WithValue(ctx, queryPlanKey{}, plan)
+}
+
+// GetQueryPlan returns plan recorded in ctx or nil
+func GetQueryPlan(ctx context.Context) *QueryPlan {
+	plan, _ := ctx.Value(queryPlanKey{}).(*QueryPlan)
+	return plan
+}
+
+// AddTarget records result of target evaluation, err is nil if it succeeded
+func (p *QueryPlan) AddTarget(target string, series int, runtime time.Duration, err error) {
+	if p == nil {
+		return
+	}
+	t := &PlanTarget{Target: target, Series: series, Runtime: runtime.Seconds()}
+	if err != nil {
+		t.Error = err.Error()
+	}
+	p.mu.Lock()
+	p.Targets = append(p.Targets, t)
+	p.mu.Unlock()
+}
+
+// addFetch records a batch of requests fetched with a single backend call
+func (p *QueryPlan) addFetch(requests []parser.MetricRequest, metrics []*types.MetricData, runtime time.Duration, prefetched bool) {
+	if p == nil {
+		return
+	}
+	series := make(map[string]int, len(requests))
+	for _, m := range metrics {
+		series[m.PathExpression]++
+	}
+	p.mu.Lock()
+	for _, r := range requests {
+		p.Fetches = append(p.Fetches, &PlanFetch{
+			Metric:     r.Metric,
+			From:       r.From,
+			Until:      r.Until,
+			Series:     series[r.Metric],
+			Runtime:    runtime.Seconds(),
+			Prefetched: prefetched,
+		})
+	}
+	p.mu.Unlock()
+}
@@ -2,9 +2,10 @@ package aboveSeries
 
 import (
 	"context"
-	"fmt"
 	"regexp"
 
+	"github.com/ansel1/merry"
+
 	"github.com/go-graphite/carbonapi/expr/consolidations"
 	"github.com/go-graphite/carbonapi/expr/helper"
 	"github.com/go-graphite/carbonapi/expr/interfaces"
@@ -31,7 +32,7 @@ func New(configFile string) []interfaces.RewriteFunctionMetadata {
 }
 
 func (f *aboveSeries) Do(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData) (bool, []string, error) {
-	args, err := helper.GetSeriesArg(e.Args()[0], from, until, values)
+	args, err := helper.GetSeriesArg(ctx, e.Args()[0], from, until, values)
 	if err != nil {
 		return false, nil, err
 	}
@@ -53,18 +54,26 @@ func (f *aboveSeries) Do(ctx context.Context, e parser.Expr, from, until int64,
 
 	rre, err := regexp.Compile(search)
 	if err != nil {
-		return false, nil, err
+		return false, nil, merry.WithMessagef(parser.ErrInvalidRegex, "%s: %q: %v", parser.ErrInvalidRegex, search, err)
 	}
+	replace = helper.Backref.ReplaceAllString(replace, "$${$1}")
 
 	var rv []string
 	for _, a := range args {
 		if consolidations.MaxValue(a.Values) > max {
-			rv = append(rv, rre.ReplaceAllString(a.Name, replace))
+			target := rre.ReplaceAllString(a.Name, replace)
+			// target will be fetched later, so it must be a valid expression
+			_, rest, err := parser.ParseExpr(target)
+			if err != nil {
+				return false, nil, err
+			}
+			if rest != "" {
+				return false, nil, parser.ErrUnexpectedCharacter
+			}
+			rv = append(rv, target)
 		}
 	}
 
-	fmt.Printf("\n\n\n\n%+v\n\n\n", rv)
-
 	return true, rv, nil
 }
 
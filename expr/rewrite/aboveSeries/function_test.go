@@ -71,6 +71,33 @@ func TestDiffSeries(t *testing.T) {
 				Err:       nil,
 			},
 		},
+		{
+			`aboveSeries(statsd.timers.*.rate, 1000, '^(.*)\.rate$', '\1.median')`,
+			map[parser.MetricRequest][]*types.MetricData{
+				{"statsd.timers.*.rate", 0, 1}: {
+					types.MakeMetricData("statsd.timers.metric1.rate", []float64{500, 1500}, 1, now32),
+					types.MakeMetricData("statsd.timers.metric2.rate", []float64{500, 500}, 1, now32),
+				},
+			},
+			th.RewriteTestResult{
+				Rewritten: true,
+				Targets:   []string{"statsd.timers.metric1.median"},
+				Err:       nil,
+			},
+		},
+		{
+			`aboveSeries(metric1, 7, "Kotik", "Bog)")`,
+			map[parser.MetricRequest][]*types.MetricData{
+				{"metric1", 0, 1}: {
+					types.MakeMetricData("metricKotik", []float64{3, 4, 5, 6, 7, 8}, 1, now32),
+				},
+			},
+			th.RewriteTestResult{
+				Rewritten: false,
+				Targets:   nil,
+				Err:       parser.ErrUnexpectedCharacter,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,87 @@
+package expr
+
+import (
+	"context"
+
+	"github.com/ansel1/merry"
+
+	"github.com/go-graphite/carbonapi/cmd/carbonapi/config"
+	"github.com/go-graphite/carbonapi/expr/interfaces"
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+// FetchAndEvalStream fetches data like FetchAndEvalExp and evaluates expression with EvalStream
+func FetchAndEvalStream(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData, emit func(*types.MetricData) error) error {
+	config.Config.Limiter.Enter()
+	targetValues, err := _evaluator.fetch(ctx, e, from, until, values)
+	// unlike FetchAndEvalExp, limiter is released before evaluation, as writing results to the client can be slow
+	config.Config.Limiter.Leave()
+	if err != nil {
+		return err
+	}
+
+	return EvalStream(ctx, e, from, until, targetValues, emit)
+}
+
+// EvalStream evaluates expression and passes result series to emit one by one, stopping at the first error of emit.
+// Functions that implement interfaces.StreamingFunction are applied to the series of their argument as soon as they
+// are produced, so chains of them over a wide series list, e.x. scale(movingAverage(a.*,10),2), don't keep
+// intermediate results in memory. Any other expression is evaluated with Eval and its results are emitted afterwards.
+func EvalStream(ctx context.Context, e parser.Expr, from, until int64, values map[parser.MetricRequest][]*types.MetricData, emit func(*types.MetricData) error) error {
+	if e.IsFunc() && len(e.Args()) > 0 {
+		metadata.FunctionMD.RLock()
+		f, ok := metadata.FunctionMD.Functions[e.Target()]
+		metadata.FunctionMD.RUnlock()
+		if sf, isStreaming := f.(interfaces.StreamingFunction); ok && isStreaming {
+			return evalStreamTransform(ctx, e, sf, from, until, values, emit)
+		}
+	}
+
+	results, err := _evaluator.Eval(ctx, e, from, until, values)
+	if err != nil {
+		return err
+	}
+	return emitAll(results, emit)
+}
+
+func emitAll(results []*types.MetricData, emit func(*types.MetricData) error) error {
+	for _, r := range results {
+		if err := emit(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func evalStreamTransform(ctx context.Context, e parser.Expr, f interfaces.StreamingFunction, from, until int64, values map[parser.MetricRequest][]*types.MetricData, emit func(*types.MetricData) error) error {
+	transform, argFrom, argUntil, err := f.Transform(ctx, e, from, until)
+	if err == interfaces.ErrNotStreamable {
+		results, err := _evaluator.Eval(ctx, e, from, until, values)
+		if err != nil {
+			return err
+		}
+		return emitAll(results, emit)
+	}
+
+	// the same checks as in EvalExpr
+	if err := ctx.Err(); err != nil {
+		return merry.Wrap(err)
+	}
+	if err := countFunctionCall(ctx); err != nil {
+		return err
+	}
+
+	arg := e.Args()[0]
+	if !arg.IsName() && !arg.IsFunc() {
+		return functionError(e, parser.ErrMissingTimeseries)
+	}
+	if err != nil {
+		return functionError(e, err)
+	}
+
+	return EvalStream(ctx, arg, argFrom, argUntil, values, func(a *types.MetricData) error {
+		return emit(transform(a))
+	})
+}
@@ -0,0 +1,47 @@
+package types
+
+import "strconv"
+
+const DefaultStackName = "__DEFAULT__"
+
+// GraphOptions contains render hints, set by functions like drawAsInfinite. They don't affect values and are used by png/svg renderer
+type GraphOptions struct {
+	// extra options
+	XStep     float64
+	Color     string
+	Alpha     float64
+	LineWidth float64
+	Invisible bool
+
+	DrawAsInfinite bool
+	SecondYAxis    bool
+	Dashed         float64
+	HasAlpha       bool
+	HasLineWidth   bool
+	Stacked        bool
+	StackName      string
+}
+
+// appendGraphOptionsJSON appends render hints that are set as fields of JSON object of a series. Hints that are not set
+// are omitted, so series without them are marshaled the same way as graphite-web does.
+func appendGraphOptionsJSON(b []byte, o *GraphOptions) []byte {
+	if o.DrawAsInfinite {
+		b = append(b, `,"drawAsInfinite":true`...)
+	}
+	if o.Color != "" {
+		b = append(b, `,"color":`...)
+		b = strconv.AppendQuoteToASCII(b, o.Color)
+	}
+	if o.HasAlpha {
+		b = append(b, `,"alpha":`...)
+		b = strconv.AppendFloat(b, o.Alpha, 'f', -1, 64)
+	}
+	if o.SecondYAxis {
+		b = append(b, `,"secondYAxis":true`...)
+	}
+	if o.Stacked {
+		b = append(b, `,"stacked":true,"stackName":`...)
+		b = strconv.AppendQuoteToASCII(b, o.StackName)
+	}
+	return b
+}
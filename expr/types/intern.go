@@ -0,0 +1,70 @@
+package types
+
+import (
+	"context"
+
+	"github.com/go-graphite/carbonapi/expr/tags"
+	pb "github.com/go-graphite/protocol/carbonapi_v3_pb"
+)
+
+// StringPool deduplicates strings within a single request. Backends return the same path expression,
+// consolidation function and tags for a lot of series of a wide query, and every decoded series gets its own copy
+// of them otherwise. Metric names themselves are unique and are kept as is, tags extracted from the name are
+// substrings of it and don't take additional memory.
+//
+// StringPool is not safe for concurrent use, it's meant to be created per request and dropped afterwards.
+type StringPool map[string]string
+
+// NewStringPool returns an empty StringPool
+func NewStringPool() StringPool {
+	return make(StringPool)
+}
+
+// Intern returns the first seen string equal to s. nil pool returns s as is.
+func (p StringPool) Intern(s string) string {
+	if p == nil {
+		return s
+	}
+	if v, ok := p[s]; ok {
+		return v
+	}
+	p[s] = s
+	return s
+}
+
+// InternMetricData replaces strings of m that are shared between series with their pooled copies
+func (p StringPool) InternMetricData(m *MetricData) {
+	if p == nil {
+		return
+	}
+	m.PathExpression = p.Intern(m.PathExpression)
+	m.ConsolidationFunc = p.Intern(m.ConsolidationFunc)
+}
+
+type stringPoolKey struct{}
+
+// WithStringPool returns context that shares pool between all backend responses decoded for the request
+func WithStringPool(ctx context.Context, pool StringPool) context.Context {
+	return context.WithValue(ctx, stringPoolKey{}, pool)
+}
+
+// GetStringPool returns pool of the request or nil if it isn't set, so strings aren't interned
+func GetStringPool(ctx context.Context) StringPool {
+	pool, _ := ctx.Value(stringPoolKey{}).(StringPool)
+	return pool
+}
+
+// FromFetchResponses converts series of a backend response to MetricData, tags are extracted from names.
+// Strings repeated between series, e.x. path expression of a glob, are kept in a single copy in pool.
+func FromFetchResponses(metrics []pb.FetchResponse, pool StringPool) []*MetricData {
+	res := make([]*MetricData, 0, len(metrics))
+	for i := range metrics {
+		md := &MetricData{
+			FetchResponse: metrics[i],
+			Tags:          tags.ExtractTags(metrics[i].Name),
+		}
+		pool.InternMetricData(md)
+		res = append(res, md)
+	}
+	return res
+}
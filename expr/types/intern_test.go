@@ -0,0 +1,148 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+	"unsafe"
+
+	pb "github.com/go-graphite/protocol/carbonapi_v3_pb"
+)
+
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestStringPool(t *testing.T) {
+	pool := NewStringPool()
+	a := pool.Intern(string([]byte("foo.*")))
+	b := pool.Intern(string([]byte("foo.*")))
+	if a != b || stringData(a) != stringData(b) {
+		t.Errorf("equal strings are not interned")
+	}
+	if c := pool.Intern("bar"); c != "bar" {
+		t.Errorf("got %q, want %q", c, "bar")
+	}
+
+	var nilPool StringPool
+	if c := nilPool.Intern("bar"); c != "bar" {
+		t.Errorf("nil pool: got %q, want %q", c, "bar")
+	}
+}
+
+func TestUnmarshalJSONInterning(t *testing.T) {
+	got, err := UnmarshalJSON([]byte(`[` +
+		`{"target":"metric1","pathExpression":"metric*","datapoints":[[1,100]],"tags":{"dc":"eu"}},` +
+		`{"target":"metric2","pathExpression":"metric*","datapoints":[[2,100]],"tags":{"dc":"eu"}}]`))
+	if err != nil {
+		t.Fatalf("UnmarshalJSON returned error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("unexpected number of metrics: got %d, want 2", len(got))
+	}
+	if got[0].PathExpression != "metric*" || stringData(got[0].PathExpression) != stringData(got[1].PathExpression) {
+		t.Errorf("path expressions are not interned: %q, %q", got[0].PathExpression, got[1].PathExpression)
+	}
+	if got[0].Tags["dc"] != "eu" || stringData(got[0].Tags["dc"]) != stringData(got[1].Tags["dc"]) {
+		t.Errorf("tags are not interned: %v, %v", got[0].Tags, got[1].Tags)
+	}
+}
+
+func TestFromFetchResponses(t *testing.T) {
+	var r pb.MultiFetchResponse
+	if err := r.Unmarshal(wideFetchResponse(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	got := FromFetchResponses(r.Metrics, NewStringPool())
+	if len(got) != 2 {
+		t.Fatalf("unexpected number of metrics: got %d, want 2", len(got))
+	}
+	want := map[string]string{"name": "servers.dc1.rack1.host1.cpu.user", "dc": "dc1", "env": "production"}
+	if !reflect.DeepEqual(got[1].Tags, want) {
+		t.Errorf("got tags %v, want %v", got[1].Tags, want)
+	}
+	if stringData(got[0].PathExpression) != stringData(got[1].PathExpression) || stringData(got[0].ConsolidationFunc) != stringData(got[1].ConsolidationFunc) {
+		t.Errorf("path expressions and consolidation functions are not interned")
+	}
+}
+
+func TestStringPoolContext(t *testing.T) {
+	if pool := GetStringPool(context.Background()); pool != nil {
+		t.Errorf("got pool %v for context without it", pool)
+	}
+
+	// responses of different fetches of the same request share the pool
+	ctx := WithStringPool(context.Background(), NewStringPool())
+	var got []*MetricData
+	for i := 0; i < 2; i++ {
+		var r pb.MultiFetchResponse
+		if err := r.Unmarshal(wideFetchResponse(1)); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, FromFetchResponses(r.Metrics, GetStringPool(ctx))...)
+	}
+	if stringData(got[0].PathExpression) != stringData(got[1].PathExpression) {
+		t.Errorf("path expressions of different responses are not interned")
+	}
+}
+
+// wideFetchResponse returns a backend response for a wildcard that matches n series
+func wideFetchResponse(n int) []byte {
+	r := pb.MultiFetchResponse{Metrics: make([]pb.FetchResponse, 0, n)}
+	for i := 0; i < n; i++ {
+		r.Metrics = append(r.Metrics, pb.FetchResponse{
+			Name:              fmt.Sprintf("servers.dc1.rack%d.host%d.cpu.user;dc=dc1;env=production", i%100, i),
+			PathExpression:    "seriesByTag('name=~servers.dc1.*.cpu.user','env=production')",
+			ConsolidationFunc: "average",
+			StartTime:         100,
+			StopTime:          220,
+			StepTime:          60,
+			Values:            []float64{1, 2},
+		})
+	}
+	data, err := r.Marshal()
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// BenchmarkFromFetchResponsesWide reports heap retained by decoded 10k series with and without interning
+func BenchmarkFromFetchResponsesWide(b *testing.B) {
+	data := wideFetchResponse(10000)
+
+	for _, bm := range []struct {
+		name string
+		pool func() StringPool
+	}{
+		{"interned", NewStringPool},
+		{"plain", func() StringPool { return nil }},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var retained int64
+			var ms runtime.MemStats
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				runtime.ReadMemStats(&ms)
+				before := ms.HeapAlloc
+
+				var r pb.MultiFetchResponse
+				if err := r.Unmarshal(data); err != nil {
+					b.Fatal(err)
+				}
+				res := FromFetchResponses(r.Metrics, bm.pool())
+
+				runtime.GC()
+				runtime.ReadMemStats(&ms)
+				// heap may shrink if garbage of the previous iteration is collected, so the difference is signed
+				retained += int64(ms.HeapAlloc) - int64(before)
+				runtime.KeepAlive(res)
+			}
+			b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+		})
+	}
+}
@@ -4,7 +4,12 @@ import (
 	"bytes"
 	"math"
 	"math/rand"
+	"reflect"
 	"testing"
+
+	pbv2 "github.com/go-graphite/protocol/carbonapi_v2_pb"
+	pb "github.com/go-graphite/protocol/carbonapi_v3_pb"
+	pickle "github.com/lomik/og-rek"
 )
 
 func TestJSONResponse(t *testing.T) {
@@ -55,6 +60,133 @@ func TestJSONResponseNoNullPoints(t *testing.T) {
 	}
 }
 
+func TestJSONWriter(t *testing.T) {
+	tests := []struct {
+		name         string
+		results      []*MetricData
+		noNullPoints bool
+	}{
+		{"empty", nil, false},
+		{"single", []*MetricData{MakeMetricData("metric1", []float64{1, math.NaN(), 3}, 100, 100)}, false},
+		{
+			"several with nil",
+			[]*MetricData{
+				MakeMetricData("metric1", []float64{1, 1.5, 2.25, math.NaN()}, 100, 100),
+				nil,
+				MakeMetricData("metric2;foo=bar", []float64{math.NaN(), 2.5, 3.25, 4, 5}, 100, 100),
+			},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewJSONWriter(&buf, 1000, tt.noNullPoints)
+			for _, r := range tt.results {
+				if err := w.Write(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			want := MarshalJSON(tt.results, 1000, tt.noNullPoints)
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("got %s, want %s", buf.String(), string(want))
+			}
+			if w.Size() != int64(buf.Len()) {
+				t.Errorf("size: got %d, want %d", w.Size(), buf.Len())
+			}
+		})
+	}
+}
+
+func TestConsolidateJSON(t *testing.T) {
+	sum := MakeMetricData("metric1", []float64{1, 2, 3, 4, 5, math.NaN()}, 60, 60)
+	sum.ConsolidationFunc = "sum"
+	lttb := MakeMetricData("metric1", []float64{0, 1, 0, 10, 0, 1, 0, 0, -5, 0}, 60, 60)
+	lttb.ConsolidationFunc = "lttb"
+	lttbAbsent := MakeMetricData("metric1", []float64{0, 1, 0, 10, math.NaN(), 1}, 60, 60)
+	lttbAbsent.ConsolidationFunc = "lttb"
+
+	tests := []struct {
+		name          string
+		maxDataPoints int64
+		results       []*MetricData
+		out           []byte
+	}{
+		{
+			"disabled",
+			0,
+			[]*MetricData{MakeMetricData("metric1", []float64{1, 2, 3, 4}, 60, 60)},
+			[]byte(`[{"target":"metric1","datapoints":[[1,60],[2,120],[3,180],[4,240]],"tags":{"name":"metric1"}}]`),
+		},
+		{
+			"negative",
+			-1,
+			[]*MetricData{MakeMetricData("metric1", []float64{1, 2, 3, 4}, 60, 60)},
+			[]byte(`[{"target":"metric1","datapoints":[[1,60],[2,120],[3,180],[4,240]],"tags":{"name":"metric1"}}]`),
+		},
+		{
+			"small enough",
+			4,
+			[]*MetricData{MakeMetricData("metric1", []float64{1, 2, 3, 4}, 60, 60)},
+			[]byte(`[{"target":"metric1","datapoints":[[1,60],[2,120],[3,180],[4,240]],"tags":{"name":"metric1"}}]`),
+		},
+		{
+			"average",
+			2,
+			[]*MetricData{MakeMetricData("metric1", []float64{1, 2, 3, 4}, 60, 60)},
+			[]byte(`[{"target":"metric1","datapoints":[[1.5,60],[3.5,180]],"tags":{"name":"metric1"}}]`),
+		},
+		{
+			"lttb",
+			4,
+			[]*MetricData{lttb},
+			[]byte(`[{"target":"metric1","datapoints":[[0,60],[10,240],[-5,540],[0,600]],"tags":{"name":"metric1"}}]`),
+		},
+		{
+			"lttb falls back to average",
+			3,
+			[]*MetricData{lttbAbsent},
+			[]byte(`[{"target":"metric1","datapoints":[[0.5,60],[5,180],[1,300]],"tags":{"name":"metric1"}}]`),
+		},
+		{
+			"consolidationFunc",
+			3,
+			[]*MetricData{sum},
+			[]byte(`[{"target":"metric1","datapoints":[[3,60],[7,180],[5,300]],"tags":{"name":"metric1"}}]`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ConsolidateJSON(tt.maxDataPoints, tt.results)
+			b := MarshalJSON(tt.results, 1.0, false)
+			if !bytes.Equal(b, tt.out) {
+				t.Errorf("ConsolidateJSON(%d):\n    got %+v\n    want %+v", tt.maxDataPoints, string(b), string(tt.out))
+			}
+		})
+	}
+}
+
+func TestConsolidateJSONKeepsGraphOptions(t *testing.T) {
+	m := MakeMetricData("metric1", []float64{1, 2, 3, 4}, 60, 60)
+	m.SecondYAxis = true
+
+	ConsolidateJSON(2, []*MetricData{m})
+	c := m.Copy(true)
+
+	if !m.SecondYAxis || !c.SecondYAxis {
+		t.Errorf("SecondYAxis was lost: consolidated %v, copy %v", m.SecondYAxis, c.SecondYAxis)
+	}
+	if c.ValuesPerPoint != 2 {
+		t.Errorf("unexpected ValuesPerPoint of copy: got %d, want 2", c.ValuesPerPoint)
+	}
+}
+
 func TestRawResponse(t *testing.T) {
 
 	tests := []struct {
@@ -78,6 +210,206 @@ func TestRawResponse(t *testing.T) {
 	}
 }
 
+// pickleFixture is a single series in the format of graphite-web's seriesInfo without valuesPerPoint, which carbonapi
+// doesn't return. Generated by python's pickle.dumps(seriesInfo, protocol=2)
+var pickleFixture = []byte("\x80\x02]q\x00}q\x01(X\x04\x00\x00\x00nameq\x02X\x07\x00\x00\x00metric1q\x03X\x0e\x00\x00\x00pathExpressionq\x04h\x03" +
+	"X\x11\x00\x00\x00consolidationFuncq\x05X\x07\x00\x00\x00averageq\x06X\x05\x00\x00\x00startq\x07KdX\x03\x00\x00\x00endq\x08M\x90\x01" +
+	"X\x04\x00\x00\x00stepq\tKdX\x0c\x00\x00\x00xFilesFactorq\nG\x00\x00\x00\x00\x00\x00\x00\x00X\x06\x00\x00\x00valuesq\x0b]q\x0c" +
+	"(G?\xf0\x00\x00\x00\x00\x00\x00NG@\x0c\x00\x00\x00\x00\x00\x00eua.")
+
+func TestPickleResponse(t *testing.T) {
+	m := MakeMetricData("metric1", []float64{1, math.NaN(), 3.5}, 100, 100)
+	m.PathExpression = "metric1"
+	m.ConsolidationFunc = "average"
+
+	b, err := MarshalPickle([]*MetricData{m})
+	if err != nil {
+		t.Fatalf("MarshalPickle returned error: %v", err)
+	}
+
+	got, err := pickle.NewDecoder(bytes.NewReader(b)).Decode()
+	if err != nil {
+		t.Fatalf("failed to decode MarshalPickle result: %v", err)
+	}
+	want, err := pickle.NewDecoder(bytes.NewReader(pickleFixture)).Decode()
+	if err != nil {
+		t.Fatalf("failed to decode fixture: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MarshalPickle() decoded to %#v, want %#v", got, want)
+	}
+}
+
+func TestPickleResponseEmpty(t *testing.T) {
+	b, err := MarshalPickle(nil)
+	if err != nil {
+		t.Fatalf("MarshalPickle returned error: %v", err)
+	}
+
+	got, err := pickle.NewDecoder(bytes.NewReader(b)).Decode()
+	if err != nil {
+		t.Fatalf("failed to decode MarshalPickle result: %v", err)
+	}
+	if l, ok := got.([]interface{}); !ok || len(l) != 0 {
+		t.Errorf("MarshalPickle(nil) decoded to %#v, want empty list", got)
+	}
+}
+
+func TestProtobufV2Response(t *testing.T) {
+	results := []*MetricData{
+		MakeMetricData("metric1", []float64{1, math.NaN(), 2.25}, 100, 100),
+		MakeMetricData("metric2", []float64{math.NaN(), 4}, 60, 120),
+	}
+
+	b, err := MarshalProtobufV2(results)
+	if err != nil {
+		t.Fatalf("MarshalProtobufV2 returned error: %v", err)
+	}
+
+	var got pbv2.MultiFetchResponse
+	err = got.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("failed to unmarshal MarshalProtobufV2 result: %v", err)
+	}
+
+	want := pbv2.MultiFetchResponse{
+		Metrics: []pbv2.FetchResponse{
+			{
+				Name:      "metric1",
+				StartTime: 100,
+				StopTime:  400,
+				StepTime:  100,
+				Values:    []float64{1, 0, 2.25},
+				IsAbsent:  []bool{false, true, false},
+			},
+			{
+				Name:      "metric2",
+				StartTime: 120,
+				StopTime:  240,
+				StepTime:  60,
+				Values:    []float64{0, 4},
+				IsAbsent:  []bool{true, false},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MarshalProtobufV2() unmarshaled to %+v, want %+v", got, want)
+	}
+}
+
+func TestProtobufV3Response(t *testing.T) {
+	results := []*MetricData{
+		MakeMetricData("metric1", []float64{1, math.NaN(), 2.25}, 100, 100),
+	}
+	results[0].ConsolidationFunc = "sum"
+	results[0].XFilesFactor = 0.5
+
+	b, err := MarshalProtobufV3(results)
+	if err != nil {
+		t.Fatalf("MarshalProtobufV3 returned error: %v", err)
+	}
+
+	var got pb.MultiFetchResponse
+	err = got.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("failed to unmarshal MarshalProtobufV3 result: %v", err)
+	}
+
+	if len(got.Metrics) != 1 {
+		t.Fatalf("unexpected number of metrics: got %d, want 1", len(got.Metrics))
+	}
+	m := got.Metrics[0]
+	if m.Name != "metric1" || m.StartTime != 100 || m.StopTime != 400 || m.StepTime != 100 || m.ConsolidationFunc != "sum" || m.XFilesFactor != 0.5 {
+		t.Errorf("unexpected metric metadata: %+v", m)
+	}
+	if len(m.Values) != 3 || m.Values[0] != 1 || !math.IsNaN(m.Values[1]) || m.Values[2] != 2.25 {
+		t.Errorf("unexpected values: got %v, want [1 NaN 2.25]", m.Values)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []*MetricData
+	}{
+		{
+			"nulls",
+			`[{"target":"metric1","datapoints":[[1,100],[null,160],[3.5,220]]}]`,
+			[]*MetricData{MakeMetricData("metric1", []float64{1, math.NaN(), 3.5}, 60, 100)},
+		},
+		{
+			"step inference",
+			`[{"target":"metric1;foo=bar","datapoints":[[1,300],[2,600]],"tags":{"foo":"bar","name":"metric1"}},{"target":"metric2","datapoints":[[5,10],[6,20],[7,30]]}]`,
+			[]*MetricData{
+				MakeMetricData("metric1;foo=bar", []float64{1, 2}, 300, 300),
+				MakeMetricData("metric2", []float64{5, 6, 7}, 10, 10),
+			},
+		},
+		{
+			"single point",
+			`[{"target":"metric1","datapoints":[[1,100]]}]`,
+			[]*MetricData{MakeMetricData("metric1", []float64{1}, 60, 100)},
+		},
+		{
+			"no points",
+			`[{"target":"metric1","datapoints":[]}]`,
+			[]*MetricData{MakeMetricData("metric1", []float64{}, 60, 0)},
+		},
+		{
+			"numeric target",
+			`[{"target":2.5,"datapoints":[[2.5,100],[2.5,200]]}]`,
+			[]*MetricData{MakeMetricData("2.5", []float64{2.5, 2.5}, 100, 100)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalJSON([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("UnmarshalJSON returned error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("unexpected number of metrics: got %d, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				want.ConsolidationFunc = "avg"
+				g := got[i]
+				if g.Name != want.Name || g.StartTime != want.StartTime || g.StopTime != want.StopTime || g.StepTime != want.StepTime || g.ConsolidationFunc != want.ConsolidationFunc {
+					t.Errorf("metric %d: got %+v, want %+v", i, g.FetchResponse, want.FetchResponse)
+				}
+				if !reflect.DeepEqual(g.Tags, want.Tags) {
+					t.Errorf("metric %d: got tags %v, want %v", i, g.Tags, want.Tags)
+				}
+				if len(g.Values) != len(want.Values) {
+					t.Fatalf("metric %d: got values %v, want %v", i, g.Values, want.Values)
+				}
+				for j := range want.Values {
+					if g.Values[j] != want.Values[j] && !(math.IsNaN(g.Values[j]) && math.IsNaN(want.Values[j])) {
+						t.Errorf("metric %d: got values %v, want %v", i, g.Values, want.Values)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	tests := []string{
+		`{"target":"metric1"}`,
+		`[{"target":"metric1","datapoints":[[1,null]]}]`,
+		`[{"target":"metric1","datapoints":[[1,200],[2,100]]}]`,
+	}
+
+	for _, in := range tests {
+		if _, err := UnmarshalJSON([]byte(in)); err == nil {
+			t.Errorf("UnmarshalJSON(%s): expected error, got nil", in)
+		}
+	}
+}
+
 func getData(rangeSize int) []float64 {
 	var data = make([]float64, rangeSize)
 	var r = rand.New(rand.NewSource(99))
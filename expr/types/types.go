@@ -2,7 +2,10 @@ package types
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"math"
 	"sort"
 	"strconv"
@@ -21,6 +24,10 @@ var (
 	ErrWildcardNotAllowed = errors.New("found wildcard where series expected")
 	// ErrTooManyArguments is an eval error returned when too many arguments are provided.
 	ErrTooManyArguments = errors.New("too many arguments")
+	// ErrListLengthMismatch is an eval error returned when series lists that must be combined element-wise have different length.
+	ErrListLengthMismatch = errors.New("series lists must have the same length")
+	// ErrStepMismatch is an eval error returned when series with different steps are combined and strict step is requested.
+	ErrStepMismatch = errors.New("series have different steps")
 )
 
 // MetricData contains necessary data to represent parsed metric (ready to be send out or drawn)
@@ -29,8 +36,11 @@ type MetricData struct {
 
 	GraphOptions
 
-	ValuesPerPoint    int
-	aggregatedValues  []float64
+	ValuesPerPoint   int
+	aggregatedValues []float64
+	// aggregatedIndexes are indexes in Values of points selected by LTTB consolidation, aggregatedValues are evenly
+	// spaced by AggregatedTimeStep if it's nil
+	aggregatedIndexes []int
 	Tags              map[string]string
 	AggregateFunction func([]float64) float64 `json:"-"`
 }
@@ -56,9 +66,11 @@ func MarshalCSV(results []*MetricData) []byte {
 	return b
 }
 
-// ConsolidateJSON consolidates values to maxDataPoints size
+// ConsolidateJSON consolidates values to maxDataPoints size, using consolidation function of each series.
+// Series that already fit into maxDataPoints are left as is, as well as all the series when maxDataPoints <= 0.
+// Series with "lttb" consolidation keep maxDataPoints of their original points, see consolidations.LTTBIndexes.
 func ConsolidateJSON(maxDataPoints int64, results []*MetricData) {
-	if len(results) == 0 {
+	if maxDataPoints <= 0 || len(results) == 0 {
 		return
 	}
 	startTime := results[0].StartTime
@@ -81,10 +93,16 @@ func ConsolidateJSON(maxDataPoints int64, results []*MetricData) {
 	}
 
 	for _, r := range results {
+		if r.StepTime <= 0 {
+			continue
+		}
 		numberOfDataPoints := math.Floor(float64(timeRange) / float64(r.StepTime))
 		if numberOfDataPoints > float64(maxDataPoints) {
 			valuesPerPoint := math.Ceil(numberOfDataPoints / float64(maxDataPoints))
 			r.SetValuesPerPoint(int(valuesPerPoint))
+			if strings.ToLower(r.ConsolidationFunc) == consolidations.LTTB {
+				r.consolidateLTTB(int(maxDataPoints))
+			}
 		}
 	}
 }
@@ -105,69 +123,252 @@ func MarshalJSON(results []*MetricData, timestampMultiplier int64, noNullPoints
 		}
 		topComma = true
 
-		b = append(b, `{"target":`...)
-		b = strconv.AppendQuoteToASCII(b, r.Name)
-		b = append(b, `,"datapoints":[`...)
+		b = appendJSON(b, r, timestampMultiplier, noNullPoints)
+	}
+
+	b = append(b, ']')
+
+	return b
+}
 
-		var innerComma bool
-		t := r.StartTime * timestampMultiplier
-		for _, v := range r.AggregatedValues() {
-			if noNullPoints && math.IsNaN(v) {
-				t += r.AggregatedTimeStep() * timestampMultiplier
+// appendJSON appends JSON object of a single series to b
+func appendJSON(b []byte, r *MetricData, timestampMultiplier int64, noNullPoints bool) []byte {
+	b = append(b, `{"target":`...)
+	b = strconv.AppendQuoteToASCII(b, r.Name)
+	b = append(b, `,"datapoints":[`...)
+
+	var innerComma bool
+	t := r.StartTime * timestampMultiplier
+	for i, v := range r.AggregatedValues() {
+		if r.aggregatedIndexes != nil {
+			t = (r.StartTime + int64(r.aggregatedIndexes[i])*r.StepTime) * timestampMultiplier
+		}
+		if noNullPoints && math.IsNaN(v) {
+			t += r.AggregatedTimeStep() * timestampMultiplier
+		} else {
+			if innerComma {
+				b = append(b, ',')
+			}
+			innerComma = true
+
+			b = append(b, '[')
+
+			if math.IsNaN(v) || math.IsInf(v, 1) || math.IsInf(v, -1) {
+				b = append(b, "null"...)
 			} else {
-				if innerComma {
-					b = append(b, ',')
-				}
-				innerComma = true
+				b = strconv.AppendFloat(b, v, 'f', -1, 64)
+			}
 
-				b = append(b, '[')
+			b = append(b, ',')
 
-				if math.IsNaN(v) || math.IsInf(v, 1) || math.IsInf(v, -1) {
-					b = append(b, "null"...)
-				} else {
-					b = strconv.AppendFloat(b, v, 'f', -1, 64)
-				}
+			b = strconv.AppendInt(b, t, 10)
 
-				b = append(b, ',')
+			b = append(b, ']')
 
-				b = strconv.AppendInt(b, t, 10)
+			t += r.AggregatedTimeStep() * timestampMultiplier
+		}
+	}
 
-				b = append(b, ']')
+	b = append(b, `],"tags":{`...)
+	notFirstTag := false
+	responseTags := make([]string, 0, len(r.Tags))
+	for tag := range r.Tags {
+		responseTags = append(responseTags, tag)
+	}
+	sort.Strings(responseTags)
+	for _, tag := range responseTags {
+		v := r.Tags[tag]
+		if notFirstTag {
+			b = append(b, ',')
+		}
+		b = strconv.AppendQuoteToASCII(b, tag)
+		b = append(b, ':')
+		b = strconv.AppendQuoteToASCII(b, v)
+		notFirstTag = true
+	}
+	b = append(b, '}')
+
+	b = appendGraphOptionsJSON(b, &r.GraphOptions)
+
+	b = append(b, '}')
+
+	return b
+}
+
+// JSONWriter writes series to w in the same format as MarshalJSON one by one, so the whole response doesn't have to
+// be kept in memory. Output is valid JSON only after Close.
+type JSONWriter struct {
+	w                   io.Writer
+	timestampMultiplier int64
+	noNullPoints        bool
+
+	buf   []byte
+	count int
+	size  int64
+}
+
+// NewJSONWriter returns JSONWriter that writes to w
+func NewJSONWriter(w io.Writer, timestampMultiplier int64, noNullPoints bool) *JSONWriter {
+	return &JSONWriter{
+		w:                   w,
+		timestampMultiplier: timestampMultiplier,
+		noNullPoints:        noNullPoints,
+	}
+}
+
+// Write writes a single series, nil series are skipped as in MarshalJSON
+func (j *JSONWriter) Write(r *MetricData) error {
+	if r == nil {
+		return nil
+	}
+
+	b := j.buf[:0]
+	if j.count == 0 {
+		b = append(b, '[')
+	} else {
+		b = append(b, ',')
+	}
+	b = appendJSON(b, r, j.timestampMultiplier, j.noNullPoints)
+	j.buf = b
+	j.count++
+
+	return j.write(b)
+}
+
+// Close finishes the list of series. It doesn't close underlying writer
+func (j *JSONWriter) Close() error {
+	if j.count == 0 {
+		return j.write([]byte("[]"))
+	}
+	return j.write([]byte{']'})
+}
 
-				t += r.AggregatedTimeStep() * timestampMultiplier
+// Count returns number of series written so far
+func (j *JSONWriter) Count() int {
+	return j.count
+}
+
+// Size returns number of bytes written so far
+func (j *JSONWriter) Size() int64 {
+	return j.size
+}
+
+func (j *JSONWriter) write(b []byte) error {
+	n, err := j.w.Write(b)
+	j.size += int64(n)
+	return err
+}
+
+// jsonTarget is a target name in graphite's json response. graphite-web can return it as a number for constant series
+type jsonTarget string
+
+func (t *jsonTarget) UnmarshalJSON(d []byte) error {
+	var res interface{}
+	err := json.Unmarshal(d, &res)
+	if err != nil {
+		return err
+	}
+	switch v := res.(type) {
+	case float64:
+		*t = jsonTarget(strconv.FormatFloat(v, 'f', -1, 64))
+	case string:
+		*t = jsonTarget(v)
+	case bool:
+		*t = jsonTarget(strconv.FormatBool(v))
+	default:
+		return fmt.Errorf("unsupported type for target")
+	}
+
+	return nil
+}
+
+type jsonMetric struct {
+	Tags              map[string]json.RawMessage
+	Target            jsonTarget
+	PathExpression    jsonTarget
+	Datapoints        [][2]*float64
+	XFilesFactor      float32
+	ConsolidationFunc string
+}
+
+// UnmarshalJSON parses graphite's json render response (list of {"target": ..., "datapoints": [[value, timestamp], ...]}).
+// Step is inferred from the first two timestamps (60 if there are less than two points), start is the first timestamp.
+// null values are converted to NaN.
+func UnmarshalJSON(data []byte) ([]*MetricData, error) {
+	return unmarshalJSON(data, NewStringPool())
+}
+
+// unmarshalJSON decodes response like UnmarshalJSON, strings repeated between series are interned with pool
+func unmarshalJSON(data []byte, pool StringPool) ([]*MetricData, error) {
+	var tmp []jsonMetric
+
+	err := json.Unmarshal(data, &tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*MetricData, 0, len(tmp))
+	for _, m := range tmp {
+		stepTime := int64(60)
+		var startTime int64
+		values := make([]float64, len(m.Datapoints))
+		for i, p := range m.Datapoints {
+			if p[1] == nil {
+				return nil, fmt.Errorf("target %v: datapoint %d has no timestamp", m.Target, i)
+			}
+			if p[0] == nil {
+				values[i] = math.NaN()
+			} else {
+				values[i] = *p[0]
+			}
+		}
+		if len(m.Datapoints) > 0 {
+			startTime = int64(*m.Datapoints[0][1])
+		}
+		if len(m.Datapoints) > 1 {
+			stepTime = int64(*m.Datapoints[1][1]) - startTime
+			if stepTime <= 0 {
+				return nil, fmt.Errorf("target %v: timestamps are not increasing", m.Target)
 			}
 		}
 
-		b = append(b, `],"tags":{`...)
-		notFirstTag := false
-		responseTags := make([]string, 0, len(r.Tags))
-		for tag := range r.Tags {
-			responseTags = append(responseTags, tag)
+		if m.ConsolidationFunc == "" {
+			m.ConsolidationFunc = "avg"
 		}
-		sort.Strings(responseTags)
-		for _, tag := range responseTags {
-			v := r.Tags[tag]
-			if notFirstTag {
-				b = append(b, ',')
+
+		metricTags := tags.ExtractTags(string(m.Target))
+		for tag, rawValue := range m.Tags {
+			var value string
+			// Tags that are not strings are ignored
+			if json.Unmarshal(rawValue, &value) != nil {
+				continue
 			}
-			b = strconv.AppendQuoteToASCII(b, tag)
-			b = append(b, ':')
-			b = strconv.AppendQuoteToASCII(b, v)
-			notFirstTag = true
+			metricTags[pool.Intern(tag)] = pool.Intern(value)
 		}
 
-		b = append(b, `}}`...)
+		md := &MetricData{
+			FetchResponse: pb.FetchResponse{
+				Name:              string(m.Target),
+				StartTime:         startTime,
+				StopTime:          startTime + int64(len(values))*stepTime,
+				StepTime:          stepTime,
+				Values:            values,
+				XFilesFactor:      m.XFilesFactor,
+				PathExpression:    string(m.PathExpression),
+				ConsolidationFunc: m.ConsolidationFunc,
+			},
+			Tags: metricTags,
+		}
+		pool.InternMetricData(md)
+		res = append(res, md)
 	}
 
-	b = append(b, ']')
-
-	return b
+	return res, nil
 }
 
-// MarshalPickle marshals metric data to pickle format
-func MarshalPickle(results []*MetricData) []byte {
-
-	var p []map[string]interface{}
+// MarshalPickle marshals metric data to pickle format, the same list of dicts graphite-web returns. Absent values are encoded as None
+func MarshalPickle(results []*MetricData) ([]byte, error) {
+	p := make([]map[string]interface{}, 0, len(results))
 
 	for _, r := range results {
 		values := make([]interface{}, len(r.Values))
@@ -194,12 +395,15 @@ func MarshalPickle(results []*MetricData) []byte {
 	var buf bytes.Buffer
 
 	penc := pickle.NewEncoder(&buf)
-	_ = penc.Encode(p)
+	err := penc.Encode(p)
+	if err != nil {
+		return nil, err
+	}
 
-	return buf.Bytes()
+	return buf.Bytes(), nil
 }
 
-// MarshalProtobufV3 marshals metric data to protobuf
+// MarshalProtobufV2 marshals metric data to protobuf used by carbonzipper (carbonapi_v2_pb), absent values are marked in IsAbsent
 func MarshalProtobufV2(results []*MetricData) ([]byte, error) {
 	response := pbv2.MultiFetchResponse{}
 	for _, metric := range results {
@@ -285,6 +489,21 @@ func MarshalRaw(results []*MetricData) []byte {
 func (r *MetricData) SetValuesPerPoint(v int) {
 	r.ValuesPerPoint = v
 	r.aggregatedValues = nil
+	r.aggregatedIndexes = nil
+}
+
+// consolidateLTTB selects threshold points of the series with LTTB. Points aren't evenly spaced after that, so it's only
+// used for json, where every point has its own timestamp. Series that LTTB can't be applied to are averaged.
+func (r *MetricData) consolidateLTTB(threshold int) {
+	indexes := consolidations.LTTBIndexes(r.Values, threshold)
+	if indexes == nil {
+		return
+	}
+	r.aggregatedValues = make([]float64, len(indexes))
+	for i, idx := range indexes {
+		r.aggregatedValues[i] = r.Values[idx]
+	}
+	r.aggregatedIndexes = indexes
 }
 
 // AggregatedTimeStep aggregates time step
@@ -348,6 +567,7 @@ func (r *MetricData) AggregateValues() {
 // Copy returns the copy of r. If includeValues set to true, it copies values as well.
 func (r *MetricData) Copy(includeValues bool) *MetricData {
 	var values, aggregatedValues []float64
+	var aggregatedIndexes []int
 	values = make([]float64, 0)
 	appliedFunctions := make([]string, 0)
 	aggregatedValues = nil
@@ -360,6 +580,10 @@ func (r *MetricData) Copy(includeValues bool) *MetricData {
 			aggregatedValues = make([]float64, len(r.aggregatedValues))
 			copy(aggregatedValues, r.aggregatedValues)
 		}
+		if r.aggregatedIndexes != nil {
+			aggregatedIndexes = make([]int, len(r.aggregatedIndexes))
+			copy(aggregatedIndexes, r.aggregatedIndexes)
+		}
 
 		appliedFunctions = make([]string, len(r.AppliedFunctions))
 		copy(appliedFunctions, r.AppliedFunctions)
@@ -388,6 +612,7 @@ func (r *MetricData) Copy(includeValues bool) *MetricData {
 		GraphOptions:      r.GraphOptions,
 		ValuesPerPoint:    r.ValuesPerPoint,
 		aggregatedValues:  aggregatedValues,
+		aggregatedIndexes: aggregatedIndexes,
 		Tags:              tags,
 		AggregateFunction: r.AggregateFunction,
 	}
@@ -21,6 +21,23 @@ type Windowed struct {
 	nans   int
 }
 
+// Reset empties the window and sets its size to n, Data is reused if it's large enough
+func (w *Windowed) Reset(n int) {
+	if cap(w.Data) < n {
+		w.Data = make([]float64, n)
+	} else {
+		w.Data = w.Data[:n]
+		for i := range w.Data {
+			w.Data[i] = 0
+		}
+	}
+	w.head = 0
+	w.length = 0
+	w.sum = 0
+	w.sumsq = 0
+	w.nans = 0
+}
+
 // Push pushes data
 func (w *Windowed) Push(n float64) {
 	if len(w.Data) == 0 {
@@ -61,16 +78,31 @@ func (w *Windowed) Len() int {
 	return len(w.Data) - w.nans
 }
 
-// Stdev computes standard deviation of data
+// Stdev computes population standard deviation of data from running sums, it matches consolidations.Stddev of the
+// window. NaN if there are less than 2 valid points
 func (w *Windowed) Stdev() float64 {
+	if w.Len() < 2 {
+		return math.NaN()
+	}
+
+	return math.Sqrt(w.Variance())
+}
+
+// Variance computes population variance of valid (non-NaN) points, NaN if there are none
+func (w *Windowed) Variance() float64 {
 	l := w.Len()
 
 	if l == 0 {
-		return 0
+		return math.NaN()
 	}
 
 	n := float64(l)
-	return math.Sqrt(n*w.sumsq-(w.sum*w.sum)) / n
+	v := (n*w.sumsq - (w.sum * w.sum)) / (n * n)
+	if v < 0 {
+		// running sums can make it slightly negative for constant values
+		return 0
+	}
+	return v
 }
 
 // SumSQ returns sum of squares
@@ -0,0 +1,101 @@
+package types
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/go-graphite/carbonapi/expr/consolidations"
+)
+
+func bruteForceVariance(data []float64) float64 {
+	var sum float64
+	var n int
+	for _, v := range data {
+		if !math.IsNaN(v) {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return math.NaN()
+	}
+	mean := sum / float64(n)
+
+	var sumsq float64
+	for _, v := range data {
+		if !math.IsNaN(v) {
+			sumsq += (v - mean) * (v - mean)
+		}
+	}
+	return sumsq / float64(n)
+}
+
+func TestWindowedVariance(t *testing.T) {
+	const windowSize = 5
+
+	r := rand.New(rand.NewSource(42))
+	values := make([]float64, 200)
+	for i := range values {
+		if r.Intn(4) == 0 {
+			values[i] = math.NaN()
+		} else {
+			values[i] = r.Float64() * 100
+		}
+	}
+	// window with NaNs only
+	for i := 100; i < 100+windowSize; i++ {
+		values[i] = math.NaN()
+	}
+
+	w := &Windowed{Data: make([]float64, windowSize)}
+	for i, v := range values {
+		w.Push(v)
+
+		start := i + 1 - windowSize
+		if start < 0 {
+			start = 0
+		}
+		window := values[start : i+1]
+
+		want := bruteForceVariance(window)
+		got := w.Variance()
+		if math.IsNaN(want) != math.IsNaN(got) || (!math.IsNaN(want) && math.Abs(got-want) > 1e-6) {
+			t.Fatalf("Variance() at %d for %v: got %v, want %v", i, window, got, want)
+		}
+
+		// compared as squares, as running sums leave small rounding residue that is amplified by sqrt
+		wantStdev, _ := consolidations.Stddev(window)
+		gotStdev := w.Stdev()
+		if math.IsNaN(wantStdev) != math.IsNaN(gotStdev) || math.Abs(gotStdev*gotStdev-wantStdev*wantStdev) > 1e-6 {
+			t.Fatalf("Stdev() at %d for %v: got %v, want %v", i, window, gotStdev, wantStdev)
+		}
+	}
+}
+
+func TestWindowedVarianceConstant(t *testing.T) {
+	w := &Windowed{Data: make([]float64, 3)}
+	for i := 0; i < 10; i++ {
+		w.Push(0.1)
+		if v := w.Variance(); v < 0 || v > 1e-12 {
+			t.Fatalf("Variance() of constant values at %d: got %v, want 0", i, v)
+		}
+	}
+}
+
+func TestWindowedReset(t *testing.T) {
+	values := []float64{1, math.NaN(), 3, 7, 2, math.NaN(), 5}
+
+	w := &Windowed{}
+	for _, size := range []int{3, 5, 2, 2} {
+		w.Reset(size)
+		fresh := &Windowed{Data: make([]float64, size)}
+		for i, v := range values {
+			w.Push(v)
+			fresh.Push(v)
+			if w.Len() != fresh.Len() || w.Sum() != fresh.Sum() || w.SumSQ() != fresh.SumSQ() {
+				t.Fatalf("window of size %d at %d: got len %d sum %v, want len %d sum %v", size, i, w.Len(), w.Sum(), fresh.Len(), fresh.Sum())
+			}
+		}
+	}
+}
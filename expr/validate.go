@@ -0,0 +1,212 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/go-graphite/carbonapi/expr/metadata"
+	"github.com/go-graphite/carbonapi/expr/types"
+	"github.com/go-graphite/carbonapi/pkg/parser"
+)
+
+// ValidationErrorType describes what kind of problem was found by ParseAndValidate
+type ValidationErrorType string
+
+const (
+	// ValidationParseError is reported when target can't be parsed
+	ValidationParseError ValidationErrorType = "parse error"
+	// ValidationUnknownFunction is reported when target calls function that isn't registered
+	ValidationUnknownFunction ValidationErrorType = "unknown function"
+	// ValidationWrongArgumentCount is reported when function gets too few or too many arguments
+	ValidationWrongArgumentCount ValidationErrorType = "wrong argument count"
+	// ValidationBadConstant is reported when argument value doesn't match parameter type, e.x. string instead of integer
+	ValidationBadConstant ValidationErrorType = "bad constant"
+)
+
+// ValidationError is a single problem found by ParseAndValidate.
+// Position is a byte offset in the target where problem was found or -1 if it can't be determined.
+type ValidationError struct {
+	Type     ValidationErrorType `json:"type"`
+	Function string              `json:"function,omitempty"`
+	Position int                 `json:"position"`
+	Message  string              `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	if e.Function != "" {
+		return fmt.Sprintf("%s at %d: function=%s: %s", e.Type, e.Position, e.Function, e.Message)
+	}
+	return fmt.Sprintf("%s at %d: %s", e.Type, e.Position, e.Message)
+}
+
+// ValidationErrors is a list of all problems found in target
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ParseAndValidate parses target and checks function names, number of arguments and constant arguments against
+// registered function descriptions without fetching any data. It returns ValidationErrors or nil if target is valid.
+func ParseAndValidate(target string) error {
+	exp, e, err := parser.ParseExpr(target)
+	if err != nil || e != "" {
+		msg := "unexpected trailing characters"
+		if err != nil {
+			msg = err.Error()
+		}
+		return ValidationErrors{{
+			Type:     ValidationParseError,
+			Position: len(target) - len(e),
+			Message:  msg,
+		}}
+	}
+
+	v := validator{target: target}
+	v.validate(exp)
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
+
+type validator struct {
+	target string
+	// cursor is an offset after the last located function call, functions are visited in the order they appear in target
+	cursor int
+	errors ValidationErrors
+}
+
+// locate returns position of the function call in target, parser doesn't keep positions so it's searched for
+func (v *validator) locate(name string) int {
+	call := name + "("
+	if i := strings.Index(v.target[v.cursor:], call); i >= 0 {
+		pos := v.cursor + i
+		v.cursor = pos + len(call)
+		return pos
+	}
+	return strings.Index(v.target, call)
+}
+
+func (v *validator) add(errType ValidationErrorType, function string, pos int, format string, args ...interface{}) {
+	v.errors = append(v.errors, &ValidationError{
+		Type:     errType,
+		Function: function,
+		Position: pos,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) validate(exp parser.Expr) {
+	// seriesByTag is parsed as a metric name and sent to the backend as is, so tag specifiers are checked here
+	if exp.IsName() && strings.HasPrefix(exp.Target(), "seriesByTag(") {
+		pos := v.locate("seriesByTag")
+		if _, err := parser.ParseSeriesByTag(exp.Target()); err != nil {
+			v.add(ValidationBadConstant, "seriesByTag", pos, "%s", err.Error())
+		}
+		return
+	}
+	if !exp.IsFunc() {
+		return
+	}
+
+	name := exp.Target()
+	pos := v.locate(name)
+
+	metadata.FunctionMD.RLock()
+	_, isFunction := metadata.FunctionMD.Functions[name]
+	_, isRewrite := metadata.FunctionMD.RewriteFunctions[name]
+	description, hasDescription := metadata.FunctionMD.Descriptions[name]
+	metadata.FunctionMD.RUnlock()
+
+	if !isFunction && !isRewrite {
+		v.add(ValidationUnknownFunction, name, pos, "unknown function %q", name)
+	} else if hasDescription && len(description.Params) > 0 {
+		v.validateArgs(exp, pos, description.Params)
+	}
+
+	for _, arg := range exp.Args() {
+		v.validate(arg)
+	}
+
+	namedArgs := exp.NamedArgs()
+	names := make([]string, 0, len(namedArgs))
+	for k := range namedArgs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		v.validate(namedArgs[k])
+	}
+}
+
+func (v *validator) validateArgs(exp parser.Expr, pos int, params []types.FunctionParam) {
+	name := exp.Target()
+	args := exp.Args()
+	namedArgs := exp.NamedArgs()
+
+	multiple := false
+	for _, p := range params {
+		multiple = multiple || p.Multiple
+	}
+	if !multiple && len(args) > len(params) {
+		v.add(ValidationWrongArgumentCount, name, pos, "too many arguments: got %d, expected at most %d", len(args), len(params))
+	}
+
+	for i, p := range params {
+		// some descriptions mark parameters with defaults as required, defaults are what function will use
+		if _, ok := namedArgs[p.Name]; p.Required && p.Default == nil && i >= len(args) && !ok {
+			v.add(ValidationWrongArgumentCount, name, pos, "missing required argument %q", p.Name)
+		}
+	}
+
+	for i, arg := range args {
+		var p types.FunctionParam
+		switch {
+		case i < len(params):
+			p = params[i]
+		case params[len(params)-1].Multiple:
+			p = params[len(params)-1]
+		default:
+			continue
+		}
+		v.validateArg(name, pos, p, arg)
+	}
+
+	for _, p := range params {
+		if arg, ok := namedArgs[p.Name]; ok {
+			v.validateArg(name, pos, p, arg)
+		}
+	}
+}
+
+// validateArg checks only literal arguments that function can't accept for the parameter: series names and function
+// calls are left to the function itself, as some functions accept them in place of constants (e.x. legacy
+// mostDeviant(n, seriesList) form)
+func (v *validator) validateArg(name string, pos int, p types.FunctionParam, arg parser.Expr) {
+	if arg.IsName() || arg.IsFunc() {
+		return
+	}
+
+	switch p.Type {
+	case types.SeriesList, types.SeriesLists:
+		if arg.IsString() || arg.IsBool() {
+			v.add(ValidationBadConstant, name, pos, "argument %q should be a series list, got %s", p.Name, arg.ToString())
+		}
+	case types.Integer, types.Node:
+		// strings are allowed, e.x. highest(seriesList, "max") treats it as a function name
+		if arg.IsBool() || arg.IsConst() && arg.FloatValue() != math.Trunc(arg.FloatValue()) {
+			v.add(ValidationBadConstant, name, pos, "argument %q should be an integer, got %s", p.Name, arg.ToString())
+		}
+	case types.Float:
+		if !arg.IsConst() {
+			v.add(ValidationBadConstant, name, pos, "argument %q should be a number, got %s", p.Name, arg.ToString())
+		}
+	}
+}
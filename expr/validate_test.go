@@ -0,0 +1,74 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAndValidate(t *testing.T) {
+	tests := []struct {
+		target string
+		want   ValidationErrors
+	}{
+		{target: "metric1"},
+		{target: "sumSeries(metric1,metric2)"},
+		{target: "highestCurrent(metric*)"},
+		{target: "highestCurrent(metric*,0.1)"},
+		{target: "movingAverage(metric1,windowSize='5min')"},
+		{target: "mostDeviant(2,metric*)"},
+		{target: "sumSeries(seriesByTag('name=requests','host=~web.*'))"},
+		{
+			target: "sumSeries(metric1",
+			want:   ValidationErrors{{Type: ValidationParseError, Position: 17, Message: "missing comma"}},
+		},
+		{
+			target: "sumSeries(noSuchFunction(metric1))",
+			want: ValidationErrors{
+				{Type: ValidationUnknownFunction, Function: "noSuchFunction", Position: 10, Message: `unknown function "noSuchFunction"`},
+			},
+		},
+		{
+			target: "sumSeries(alias(metric1))",
+			want: ValidationErrors{
+				{Type: ValidationWrongArgumentCount, Function: "alias", Position: 10, Message: `missing required argument "newName"`},
+			},
+		},
+		{
+			target: "absolute(metric1,metric2)",
+			want: ValidationErrors{
+				{Type: ValidationWrongArgumentCount, Function: "absolute", Position: 0, Message: "too many arguments: got 2, expected at most 1"},
+			},
+		},
+		{
+			target: "group(metric1,limit(metric2,1.5),scale(metric3,'a'))",
+			want: ValidationErrors{
+				{Type: ValidationBadConstant, Function: "limit", Position: 14, Message: `argument "n" should be an integer, got 1.5`},
+				{Type: ValidationBadConstant, Function: "scale", Position: 33, Message: `argument "factor" should be a number, got 'a'`},
+			},
+		},
+		{
+			target: "sumSeries('metric1')",
+			want: ValidationErrors{
+				{Type: ValidationBadConstant, Function: "sumSeries", Position: 0, Message: `argument "seriesLists" should be a series list, got 'metric1'`},
+			},
+		},
+		{
+			target: "sumSeries(seriesByTag('env='))",
+			want: ValidationErrors{
+				{Type: ValidationBadConstant, Function: "seriesByTag", Position: 10, Message: "bad type: at least one tag specifier must require a non-empty value"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			err := ParseAndValidate(tt.target)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.want, err)
+		})
+	}
+}
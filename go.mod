@@ -4,7 +4,6 @@ go 1.14
 
 require (
 	bitbucket.org/tebeka/strftime v0.0.0-20140926081919-2194253a23c0
-	github.com/JaderDias/movingmedian v0.0.0-20170611140316-de8c410559fa
 	github.com/aclements/go-moremath v0.0.0-20190830160640-d16893ddf098 // indirect
 	github.com/ansel1/merry v1.5.1
 	github.com/bradfitz/gomemcache v0.0.0-20190913173617-a41fca850d0b
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+)
+
+var exprTypeNames = map[ExprType]string{
+	EtName:   "name",
+	EtFunc:   "func",
+	EtConst:  "const",
+	EtString: "string",
+	EtBool:   "bool",
+}
+
+// exprJSON is a debug representation of expr, it keeps all the fields as they are after parsing
+type exprJSON struct {
+	Target    string           `json:"target"`
+	Etype     string           `json:"etype"`
+	Val       interface{}      `json:"val"`
+	ValStr    string           `json:"valStr"`
+	Args      []*expr          `json:"args"`
+	NamedArgs map[string]*expr `json:"namedArgs"`
+	ArgString string           `json:"argString"`
+}
+
+// MarshalJSON dumps parsed expression tree, so it's possible to see how exactly target was interpreted
+func (e *expr) MarshalJSON() ([]byte, error) {
+	etype, ok := exprTypeNames[e.etype]
+	if !ok {
+		etype = strconv.Itoa(int(e.etype))
+	}
+
+	// json doesn't support NaN and infinities, they are written as strings
+	var val interface{} = e.val
+	if math.IsNaN(e.val) || math.IsInf(e.val, 0) {
+		val = strconv.FormatFloat(e.val, 'g', -1, 64)
+	}
+
+	args := e.args
+	if args == nil {
+		args = []*expr{}
+	}
+	namedArgs := e.namedArgs
+	if namedArgs == nil {
+		namedArgs = map[string]*expr{}
+	}
+
+	return json.Marshal(exprJSON{
+		Target:    e.target,
+		Etype:     etype,
+		Val:       val,
+		ValStr:    e.valStr,
+		Args:      args,
+		NamedArgs: namedArgs,
+		ArgString: e.argString,
+	})
+}
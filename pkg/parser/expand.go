@@ -0,0 +1,50 @@
+package parser
+
+import "strings"
+
+// GlobResolver resolves metric name or glob (e.x. "foo.*.bar") to the list of matching metric names.
+// In production it should be backed by a real index (e.x. find requests to backends).
+type GlobResolver interface {
+	Resolve(glob string) ([]string, error)
+}
+
+// GlobResolverFunc allows to use ordinary function as a GlobResolver
+type GlobResolverFunc func(glob string) ([]string, error)
+
+// Resolve calls f(glob)
+func (f GlobResolverFunc) Resolve(glob string) ([]string, error) {
+	return f(glob)
+}
+
+// ExpandMetrics resolves every metric of the expression (see Metrics) with the resolver and returns
+// a map from the metric as it's written in the expression to the list of metric names it matched.
+// Metric that is used several times is resolved only once.
+// seriesByTag expressions are resolved with ResolveTags if the resolver also implements TagResolver.
+func (e *expr) ExpandMetrics(resolver GlobResolver) (map[string][]string, error) {
+	tagResolver, hasTags := resolver.(TagResolver)
+	res := make(map[string][]string)
+	for _, m := range e.Metrics() {
+		if _, ok := res[m.Metric]; ok {
+			continue
+		}
+		var names []string
+		var err error
+		if hasTags && strings.HasPrefix(m.Metric, "seriesByTag(") {
+			var specs TagSpecs
+			specs, err = ParseSeriesByTag(m.Metric)
+			if err == nil {
+				names, err = tagResolver.ResolveTags(specs)
+			}
+		} else {
+			names, err = resolver.Resolve(m.Metric)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if names == nil {
+			names = []string{}
+		}
+		res[m.Metric] = names
+	}
+	return res, nil
+}
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"errors"
+	"path"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mapResolver []string
+
+func (m mapResolver) Resolve(glob string) ([]string, error) {
+	var res []string
+	for _, name := range m {
+		if ok, _ := path.Match(glob, name); ok {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func TestExpandMetrics(t *testing.T) {
+	resolver := mapResolver{"foo.a.bar", "foo.b.bar", "foo.c.baz", "metric1", "metric2"}
+
+	tests := []struct {
+		s    string
+		want map[string][]string
+	}{
+		{
+			"foo.*.bar",
+			map[string][]string{"foo.*.bar": {"foo.a.bar", "foo.b.bar"}},
+		},
+		{
+			"sumSeries(foo.*.bar, metric[12], metric1)",
+			map[string][]string{
+				"foo.*.bar":  {"foo.a.bar", "foo.b.bar"},
+				"metric[12]": {"metric1", "metric2"},
+				"metric1":    {"metric1"},
+			},
+		},
+		{
+			"scale(group(foo.*.baz, nonexistent), 2)",
+			map[string][]string{
+				"foo.*.baz":   {"foo.c.baz"},
+				"nonexistent": {},
+			},
+		},
+		{
+			"timeShift(metric1, '1d')",
+			map[string][]string{"metric1": {"metric1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			e, _, err := ParseExpr(tt.s)
+			if !assert.NoError(t, err) {
+				return
+			}
+			got, err := e.ExpandMetrics(resolver)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExpandMetricsError(t *testing.T) {
+	errResolve := errors.New("index is not ready")
+	resolver := GlobResolverFunc(func(glob string) ([]string, error) {
+		return nil, errResolve
+	})
+
+	e, _, err := ParseExpr("sumSeries(foo.*)")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = e.ExpandMetrics(resolver)
+	assert.Equal(t, errResolve, err)
+}
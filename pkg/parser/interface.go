@@ -48,6 +48,10 @@ var (
 	ErrSeriesDoesNotExist = errors.New("no timeseries with that name")
 	// ErrUnknownTimeUnits is an eval error returned when a time unit is unknown to system
 	ErrUnknownTimeUnits = errors.New("unknown time units")
+	// ErrInvalidRegex is an eval error returned when a regular expression argument can't be compiled
+	ErrInvalidRegex = errors.New("invalid regex")
+	// ErrExpressionTooDeep is a parse error returned when function calls are nested deeper than MaxExpressionDepth
+	ErrExpressionTooDeep = errors.New("expression is nested too deep")
 )
 
 // NodeOrTag structure contains either Node (=integer) or Tag (=string)
@@ -103,6 +107,8 @@ type Expr interface {
 
 	// Metrics returns list of metric requests
 	Metrics() []MetricRequest
+	// ExpandMetrics returns list of metric names that each metric of the expression matches, using resolver to expand globs
+	ExpandMetrics(resolver GlobResolver) (map[string][]string, error)
 
 	// GetIntervalArg returns interval typed argument.
 	GetIntervalArg(n int, defaultSign int) (int32, error)
@@ -2,18 +2,24 @@ package parser
 
 import (
 	"fmt"
-
 	"runtime/debug"
+
+	"github.com/ansel1/merry"
 )
 
 func (e *expr) doGetIntArg() (int, error) {
 	if e.etype != EtConst {
-		return 0, ErrBadType
+		return 0, e.errNotConst()
 	}
 
 	return int(e.val), nil
 }
 
+// errNotConst returns ErrBadType that mentions the argument, so user can see which one is wrong
+func (e *expr) errNotConst() error {
+	return merry.WithMessagef(ErrBadType, "%s: expected a number, got %s", ErrBadType, e.ToString())
+}
+
 func (e *expr) getNamedArg(name string) *expr {
 	if a, ok := e.namedArgs[name]; ok {
 		return a
@@ -24,7 +30,7 @@ func (e *expr) getNamedArg(name string) *expr {
 
 func (e *expr) doGetFloatArg() (float64, error) {
 	if e.etype != EtConst {
-		return 0, ErrBadType
+		return 0, e.errNotConst()
 	}
 
 	return e.val, nil
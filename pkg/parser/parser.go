@@ -54,10 +54,11 @@ func (e *expr) ToString() string {
 	case EtConst:
 		return e.valStr
 	case EtString:
-		s := e.valStr
-		s = strings.ReplaceAll(s, `\`, `\\`)
-		s = strings.ReplaceAll(s, `'`, `\'`)
-		return "'" + s + "'"
+		// parser doesn't support escape sequences, so the quote that is not a part of the string is used
+		if strings.ContainsRune(e.valStr, '\'') {
+			return `"` + e.valStr + `"`
+		}
+		return "'" + e.valStr + "'"
 	case EtBool:
 		return fmt.Sprint(e.val)
 	}
@@ -147,9 +148,14 @@ func (e *expr) Metrics() []MetricRequest {
 			if err != nil {
 				return nil
 			}
+			// with alignDST the shift is corrected by the change of UTC offset that is only known during evaluation
+			var margin int64
+			if alignDST, err := e.GetBoolNamedOrPosArgDefault("alignDST", 3, false); err == nil && alignDST {
+				margin = AlignDSTMargin
+			}
 			for i := range r {
-				r[i].From += int64(offs)
-				r[i].Until += int64(offs)
+				r[i].From += int64(offs) - margin
+				r[i].Until += int64(offs) + margin
 			}
 		case "timeStack":
 			offs, err := e.GetIntervalArg(1, -1)
@@ -182,10 +188,19 @@ func (e *expr) Metrics() []MetricRequest {
 
 			return r2
 		case "holtWintersForecast", "holtWintersConfidenceBands", "holtWintersAberration":
+			// bootstrapInterval (7 days by default) before the original start is used to bootstrap the forecast
+			pos := 2
+			if e.target == "holtWintersForecast" {
+				pos = 1
+			}
+			bootstrapInterval, err := e.GetIntervalNamedOrPosArgDefault("bootstrapInterval", pos, 1, 7*86400)
+			if err != nil {
+				return nil
+			}
 			for i := range r {
-				r[i].From -= 7 * 86400 // starts -7 days from where the original starts
+				r[i].From -= bootstrapInterval
 			}
-		case "movingAverage", "movingMedian", "movingMin", "movingMax", "movingSum":
+		case "movingWindow", "movingAverage", "movingMedian", "movingMin", "movingMax", "movingSum":
 			if len(e.args) < 2 {
 				return nil
 			}
@@ -206,6 +221,27 @@ func (e *expr) Metrics() []MetricRequest {
 	return nil
 }
 
+// FetchRequests returns unique metric requests of e with absolute time ranges that should be fetched to evaluate e
+// for [from, until]. Ranges are extended for functions that need data outside of it, see Metrics.
+func FetchRequests(e Expr, from, until int64) []MetricRequest {
+	metrics := e.Metrics()
+	res := make([]MetricRequest, 0, len(metrics))
+	seen := make(map[MetricRequest]struct{}, len(metrics))
+	for _, m := range metrics {
+		r := MetricRequest{
+			Metric: m.Metric,
+			From:   m.From + from,
+			Until:  m.Until + until,
+		}
+		if _, ok := seen[r]; ok {
+			continue
+		}
+		seen[r] = struct{}{}
+		res = append(res, r)
+	}
+	return res
+}
+
 func (e *expr) GetIntervalArg(n, defaultSign int) (int32, error) {
 	if len(e.args) <= n {
 		return 0, ErrMissingArgument
@@ -227,7 +263,7 @@ func (e *expr) GetIntervalNamedOrPosArgDefault(k string, n, defaultSign int, v i
 	var val string
 	var err error
 	if a := e.getNamedArg(k); a != nil {
-		val, err = e.doGetStringArg()
+		val, err = a.doGetStringArg()
 		if err != nil {
 			return 0, ErrBadType
 		}
@@ -452,6 +488,12 @@ func parseExprWithoutPipe(e string) (Expr, string, error) {
 		return nil, "", ErrMissingExpr
 	}
 
+	if e[0] == '-' {
+		if exp, rest, ok := parseNegation(e); ok {
+			return exp, rest, nil
+		}
+	}
+
 	if '0' <= e[0] && e[0] <= '9' || e[0] == '-' || e[0] == '+' {
 		val, valStr, e, err := parseConst(e)
 		r, _ := utf8.DecodeRuneInString(e)
@@ -492,7 +534,35 @@ func parseExprWithoutPipe(e string) (Expr, string, error) {
 		return exp, e, err
 	}
 
-	return &expr{target: name}, e, nil
+	return &expr{target: name, etype: EtName}, e, nil
+}
+
+// parseNegation handles unary minus in front of a function call or a metric name: `-foo(bar)` is parsed as `scale(foo(bar),-1)`.
+// It returns false if e doesn't start with such an expression (e.x. negative constant), so it should be parsed as usual.
+func parseNegation(e string) (*expr, string, bool) {
+	r, _ := utf8.DecodeRuneInString(e[1:])
+	if !unicode.IsLetter(r) {
+		return nil, e, false
+	}
+
+	exp, rest, err := parseExprWithoutPipe(e[1:])
+	if err != nil {
+		return nil, e, false
+	}
+	arg := exp.(*expr)
+	if arg.etype != EtName && arg.etype != EtFunc {
+		return nil, e, false
+	}
+
+	return &expr{
+		target: "scale",
+		etype:  EtFunc,
+		args: []*expr{
+			arg,
+			{val: -1, etype: EtConst, valStr: "-1"},
+		},
+		argString: arg.ToString() + ",-1",
+	}, rest, true
 }
 
 func parseExprInner(e string) (Expr, string, error) {
@@ -503,8 +573,46 @@ func parseExprInner(e string) (Expr, string, error) {
 	return pipe(exp.(*expr), e)
 }
 
+// AlignDSTMargin is how much more data is fetched before and after the shifted interval for timeShift with alignDST,
+// it should cover any change of UTC offset because of daylight saving time
+const AlignDSTMargin = 2 * 60 * 60
+
+// MaxExpressionDepth limits nesting of function calls in expression, e.x. `sumSeries(scale(a.b, 2))` has depth 2.
+// Deeper expressions are rejected with ErrExpressionTooDeep before parsing. 0 means no limit.
+var MaxExpressionDepth = 0
+
+// checkDepth returns ErrExpressionTooDeep if parentheses outside of quoted strings are nested deeper than MaxExpressionDepth
+func checkDepth(e string) error {
+	if MaxExpressionDepth <= 0 {
+		return nil
+	}
+	depth := 0
+	var quote byte
+	for i := 0; i < len(e); i++ {
+		switch c := e[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+			if depth > MaxExpressionDepth {
+				return merry.WithMessagef(ErrExpressionTooDeep, "%s: maximum depth is %d", ErrExpressionTooDeep, MaxExpressionDepth)
+			}
+		case c == ')':
+			depth--
+		}
+	}
+	return nil
+}
+
 // ParseExpr actually do all the parsing. It returns expression, original string and error (if any)
 func ParseExpr(e string) (Expr, string, error) {
+	if err := checkDepth(e); err != nil {
+		return nil, e, err
+	}
 	exp, e, err := parseExprInner(e)
 	if err != nil {
 		return exp, e, err
@@ -1,8 +1,11 @@
 package parser
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 
+	"github.com/ansel1/merry"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -12,14 +15,14 @@ func TestParseExpr(t *testing.T) {
 		e *expr
 	}{
 		{"metric",
-			&expr{target: "metric"},
+			&expr{target: "metric", etype: EtName},
 		},
 		{
 			"metric.foo",
-			&expr{target: "metric.foo"},
+			&expr{target: "metric.foo", etype: EtName},
 		},
 		{"metric.*.foo",
-			&expr{target: "metric.*.foo"},
+			&expr{target: "metric.*.foo", etype: EtName},
 		},
 		{
 			"func(metric)",
@@ -358,6 +361,55 @@ func TestParseExpr(t *testing.T) {
 		{"hello&world",
 			&expr{target: "hello&world"},
 		},
+		{
+			"-metric.foo",
+			&expr{
+				target: "scale",
+				etype:  EtFunc,
+				args: []*expr{
+					{target: "metric.foo"},
+					{val: -1, etype: EtConst, valStr: "-1"},
+				},
+				argString: "metric.foo,-1",
+			},
+		},
+		{
+			"offset(metric, -constantLine(5))",
+			&expr{
+				target: "offset",
+				etype:  EtFunc,
+				args: []*expr{
+					{target: "metric"},
+					{
+						target: "scale",
+						etype:  EtFunc,
+						args: []*expr{
+							{
+								target:    "constantLine",
+								etype:     EtFunc,
+								args:      []*expr{{val: 5, etype: EtConst, valStr: "5"}},
+								argString: "5",
+							},
+							{val: -1, etype: EtConst, valStr: "-1"},
+						},
+						argString: "constantLine(5),-1",
+					},
+				},
+				argString: "metric,scale(constantLine(5),-1)",
+			},
+		},
+		{
+			"func(metric-1, -1)",
+			&expr{
+				target: "func",
+				etype:  EtFunc,
+				args: []*expr{
+					{target: "metric-1"},
+					{val: -1, etype: EtConst, valStr: "-1"},
+				},
+				argString: "metric-1, -1",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -420,3 +472,180 @@ func TestDoGetBoolVar(t *testing.T) {
 		})
 	}
 }
+
+func TestStringArgRoundTrip(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{`alias(metric,"foo")`, "foo"},
+		{`alias(metric,'foo bar')`, "foo bar"},
+		{`alias(metric,"it's")`, "it's"},
+		{`alias(metric,'say "hi"')`, `say "hi"`},
+		{`alias(metric,"a,b(c)")`, "a,b(c)"},
+		{`aliasSub(metric,'^(\w+)\.(\d+)$','\2-\1')`, `\2-\1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			assert := assert.New(t)
+
+			e, _, err := ParseExpr(tt.s)
+			if !assert.NoError(err) {
+				return
+			}
+			n := len(e.Args()) - 1
+			assert.Equal(EtString, e.Args()[n].Type())
+			got, err := e.GetStringArg(n)
+			if assert.NoError(err) {
+				assert.Equal(tt.want, got)
+			}
+
+			args := make([]string, 0, len(e.Args()))
+			for _, a := range e.Args() {
+				args = append(args, a.ToString())
+			}
+			s := e.Target() + "(" + strings.Join(args, ",") + ")"
+
+			e2, _, err := ParseExpr(s)
+			if !assert.NoError(err, s) {
+				return
+			}
+			assert.Equal(EtString, e2.Args()[n].Type(), s)
+			got, err = e2.GetStringArg(n)
+			if assert.NoError(err) {
+				assert.Equal(tt.want, got, s)
+			}
+		})
+	}
+}
+
+func TestNumericArgErrors(t *testing.T) {
+	e, _, err := ParseExpr(`scale(metric,foo.bar)`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = e.GetFloatArg(1)
+	assert.True(t, merry.Is(err, ErrBadType), "unexpected error %v", err)
+	assert.Contains(t, err.Error(), "foo.bar")
+
+	_, err = e.GetIntArg(1)
+	assert.True(t, merry.Is(err, ErrBadType), "unexpected error %v", err)
+	assert.Contains(t, err.Error(), "foo.bar")
+
+	_, err = e.GetFloatArg(2)
+	assert.True(t, merry.Is(err, ErrMissingArgument), "unexpected error %v", err)
+
+	v, err := e.GetFloatArgDefault(2, 1.5)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1.5, v)
+	}
+}
+
+func TestParseExprDepthLimit(t *testing.T) {
+	defer func(depth int) { MaxExpressionDepth = depth }(MaxExpressionDepth)
+	MaxExpressionDepth = 3
+
+	for _, s := range []string{
+		"a.b",
+		"sumSeries(scale(absolute(a.b),2))",
+		"sumSeries(scale(a.b,2),scale(absolute(c.d),2))",
+		"alias(scale(a.b,2),'((((((')",
+		"sumSeries(a.b)|scale(2)|absolute()",
+	} {
+		if _, _, err := ParseExpr(s); err != nil {
+			t.Errorf("%s: unexpected error: %v", s, err)
+		}
+	}
+
+	deep := "a.b"
+	for i := 0; i < 1000; i++ {
+		deep = "absolute(" + deep + ")"
+	}
+	for _, s := range []string{
+		"sumSeries(scale(absolute(offset(a.b,1)),2))",
+		deep,
+	} {
+		if _, _, err := ParseExpr(s); !merry.Is(err, ErrExpressionTooDeep) {
+			t.Errorf("%.40s: got error %v, want %v", s, err, ErrExpressionTooDeep)
+		}
+	}
+
+	MaxExpressionDepth = 0
+	if _, _, err := ParseExpr(deep); err != nil {
+		t.Errorf("no limit: unexpected error: %v", err)
+	}
+}
+
+func TestFetchRequests(t *testing.T) {
+	const (
+		from  = 1000000
+		until = 1003600
+	)
+	week := int64(7 * 86400)
+
+	tests := []struct {
+		target string
+		want   []MetricRequest
+	}{
+		{"a.b", []MetricRequest{{"a.b", from, until}}},
+		{"divideSeries(a.b,a.b)", []MetricRequest{{"a.b", from, until}}},
+		{
+			"sumSeries(a.b,timeShift(a.b,'1h'))",
+			[]MetricRequest{{"a.b", from, until}, {"a.b", from - 3600, until - 3600}},
+		},
+		{"movingAverage(a.b,'10min')", []MetricRequest{{"a.b", from - 600, until}}},
+		// window in points depends on the step, that is unknown before fetching
+		{"movingAverage(a.b,10)", []MetricRequest{{"a.b", from, until}}},
+		{"holtWintersForecast(a.b)", []MetricRequest{{"a.b", from - week, until}}},
+		{"holtWintersForecast(a.b,'1d')", []MetricRequest{{"a.b", from - 86400, until}}},
+		{"holtWintersConfidenceBands(a.b,3,'2d')", []MetricRequest{{"a.b", from - 2*86400, until}}},
+		{"holtWintersAberration(a.b,bootstrapInterval='1d')", []MetricRequest{{"a.b", from - 86400, until}}},
+		{"holtWintersForecast(a.b,'2d','12h')", []MetricRequest{{"a.b", from - 2*86400, until}}},
+		{"holtWintersConfidenceBands(a.b,seasonality='1h')", []MetricRequest{{"a.b", from - week, until}}},
+		{
+			"sumSeries(movingSum(a.b,'1min'),holtWintersForecast(c.d,'1d'))",
+			[]MetricRequest{{"a.b", from - 60, until}, {"c.d", from - 86400, until}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			e, _, err := ParseExpr(tt.target)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, FetchRequests(e, from, until))
+		})
+	}
+}
+
+func TestExprMarshalJSON(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{
+			"metric",
+			`{"target":"metric","etype":"name","val":0,"valStr":"","args":[],"namedArgs":{},"argString":""}`,
+		},
+		{
+			`scale(a.*,2.5,key='x')`,
+			`{"target":"scale","etype":"func","val":0,"valStr":"","args":[` +
+				`{"target":"a.*","etype":"name","val":0,"valStr":"","args":[],"namedArgs":{},"argString":""},` +
+				`{"target":"","etype":"const","val":2.5,"valStr":"2.5","args":[],"namedArgs":{},"argString":""}],` +
+				`"namedArgs":{"key":{"target":"","etype":"string","val":0,"valStr":"x","args":[],"namedArgs":{},"argString":""}},` +
+				`"argString":"a.*,2.5,key='x'"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			e, _, err := ParseExpr(tt.s)
+			assert.NoError(t, err)
+			b, err := json.Marshal(e)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(b))
+		})
+	}
+}
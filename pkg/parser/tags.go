@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ansel1/merry"
+)
+
+// TagOp is a comparison operator of a seriesByTag tag specifier
+type TagOp string
+
+const (
+	// TagEq matches series with tag value exactly equal to the spec value
+	TagEq TagOp = "="
+	// TagNotEq matches series with tag value not equal to the spec value
+	TagNotEq TagOp = "!="
+	// TagMatch matches series with tag value matching the regular expression, anchored at the start of the value
+	TagMatch TagOp = "=~"
+	// TagNotMatch matches series with tag value not matching the regular expression
+	TagNotMatch TagOp = "!=~"
+)
+
+// TagSpec is a single tag specifier of seriesByTag, e.x. "host=~web.*"
+type TagSpec struct {
+	Tag   string
+	Op    TagOp
+	Value string
+
+	re *regexp.Regexp
+}
+
+// ParseTagSpec parses tag specifier in one of the forms "tag=value", "tag!=value", "tag=~regex" or "tag!=~regex"
+func ParseTagSpec(s string) (TagSpec, error) {
+	i := strings.IndexAny(s, "!=")
+	if i <= 0 {
+		return TagSpec{}, merry.WithMessagef(ErrBadType, "%s: invalid tag specifier %q", ErrBadType, s)
+	}
+
+	spec := TagSpec{Tag: strings.TrimSpace(s[:i])}
+	rest := s[i:]
+	for _, op := range []TagOp{TagNotMatch, TagMatch, TagNotEq, TagEq} {
+		if strings.HasPrefix(rest, string(op)) {
+			spec.Op = op
+			spec.Value = rest[len(op):]
+			break
+		}
+	}
+	if spec.Op == "" {
+		return TagSpec{}, merry.WithMessagef(ErrBadType, "%s: invalid tag specifier %q", ErrBadType, s)
+	}
+
+	if spec.Op == TagMatch || spec.Op == TagNotMatch {
+		re, err := regexp.Compile("^(?:" + spec.Value + ")")
+		if err != nil {
+			return TagSpec{}, merry.WithMessagef(ErrBadType, "%s: invalid regex in tag specifier %q: %v", ErrBadType, s, err)
+		}
+		spec.re = re
+	}
+
+	return spec, nil
+}
+
+// MatchValue reports if tag value satisfies the specifier. Missing tag should be passed as an empty value.
+func (s TagSpec) MatchValue(v string) bool {
+	switch s.Op {
+	case TagEq:
+		return v == s.Value
+	case TagNotEq:
+		return v != s.Value
+	case TagMatch:
+		return s.re.MatchString(v)
+	case TagNotMatch:
+		return !s.re.MatchString(v)
+	}
+	return false
+}
+
+// String returns the specifier as it's written in seriesByTag
+func (s TagSpec) String() string {
+	return s.Tag + string(s.Op) + s.Value
+}
+
+// TagSpecs is a list of tag specifiers of seriesByTag, series should match all of them
+type TagSpecs []TagSpec
+
+// Match reports if series with the tags matches all the specifiers.
+// Spec that matches an empty value also matches series that don't have that tag.
+func (specs TagSpecs) Match(tags map[string]string) bool {
+	for _, s := range specs {
+		if !s.MatchValue(tags[s.Tag]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSeriesByTag parses tag specifiers of seriesByTag expression, e.x. `seriesByTag('name=requests', 'host=~web.*')`.
+// At least one of the specifiers must require a non-empty value.
+func ParseSeriesByTag(target string) (TagSpecs, error) {
+	if !strings.HasPrefix(target, "seriesByTag(") {
+		return nil, merry.WithMessagef(ErrBadType, "%s: not a seriesByTag expression: %s", ErrBadType, target)
+	}
+
+	_, args, _, rest, err := parseArgList(target[len("seriesByTag"):])
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, ErrUnexpectedCharacter
+	}
+	if len(args) == 0 {
+		return nil, ErrMissingArgument
+	}
+
+	specs := make(TagSpecs, 0, len(args))
+	nonEmpty := false
+	for _, arg := range args {
+		if arg.etype != EtString {
+			return nil, merry.WithMessagef(ErrBadType, "%s: tag specifier should be a string, got %s", ErrBadType, arg.ToString())
+		}
+		spec, err := ParseTagSpec(arg.valStr)
+		if err != nil {
+			return nil, err
+		}
+		nonEmpty = nonEmpty || !spec.MatchValue("")
+		specs = append(specs, spec)
+	}
+	if !nonEmpty {
+		return nil, merry.WithMessagef(ErrBadType, "%s: at least one tag specifier must require a non-empty value", ErrBadType)
+	}
+
+	return specs, nil
+}
+
+// TagResolver resolves seriesByTag specifiers to the list of matching series names.
+// In production it should be backed by a tag database, GlobResolver that also implements it
+// is used by ExpandMetrics to resolve seriesByTag expressions.
+type TagResolver interface {
+	ResolveTags(specs TagSpecs) ([]string, error)
+}
@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tagIndex is a fixture tag database: series name to its tags
+type tagIndex map[string]map[string]string
+
+func (idx tagIndex) Resolve(glob string) ([]string, error) {
+	return nil, nil
+}
+
+func (idx tagIndex) ResolveTags(specs TagSpecs) ([]string, error) {
+	var res []string
+	for name, tags := range idx {
+		if specs.Match(tags) {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func TestParseTagSpec(t *testing.T) {
+	tests := []struct {
+		s    string
+		want TagSpec
+	}{
+		{"name=requests", TagSpec{Tag: "name", Op: TagEq, Value: "requests"}},
+		{"dc!=eu", TagSpec{Tag: "dc", Op: TagNotEq, Value: "eu"}},
+		{"host=~web.*", TagSpec{Tag: "host", Op: TagMatch, Value: "web.*"}},
+		{"host!=~db", TagSpec{Tag: "host", Op: TagNotMatch, Value: "db"}},
+		{"env=", TagSpec{Tag: "env", Op: TagEq, Value: ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseTagSpec(tt.s)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want.Tag, got.Tag)
+			assert.Equal(t, tt.want.Op, got.Op)
+			assert.Equal(t, tt.want.Value, got.Value)
+			assert.Equal(t, tt.s, got.String())
+		})
+	}
+
+	for _, s := range []string{"requests", "=requests", "host=~web[", "host!requests"} {
+		_, err := ParseTagSpec(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestTagSpecsMatch(t *testing.T) {
+	tags := map[string]string{"name": "requests", "host": "web01", "dc": "us"}
+
+	tests := []struct {
+		specs []string
+		want  bool
+	}{
+		{[]string{"name=requests"}, true},
+		{[]string{"name=requests", "host=~web.*"}, true},
+		{[]string{"name=requests", "host=~eb"}, false}, // regex is anchored at the start
+		{[]string{"name=requests", "host!=~db.*"}, true},
+		{[]string{"name=requests", "dc!=us"}, false},
+		{[]string{"name=requests", "env="}, true}, // empty value matches missing tag
+		{[]string{"name=requests", "env!="}, false},
+		{[]string{"name=requests", "env=~(prod)?$"}, true},
+	}
+
+	for _, tt := range tests {
+		var specs TagSpecs
+		for _, s := range tt.specs {
+			spec, err := ParseTagSpec(s)
+			if !assert.NoError(t, err) {
+				return
+			}
+			specs = append(specs, spec)
+		}
+		assert.Equal(t, tt.want, specs.Match(tags), tt.specs)
+	}
+}
+
+func TestParseSeriesByTag(t *testing.T) {
+	specs, err := ParseSeriesByTag(`seriesByTag('name=requests', "host=~web.*")`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"name=requests", "host=~web.*"}, []string{specs[0].String(), specs[1].String()})
+	}
+
+	for _, s := range []string{
+		"seriesByTag()",
+		"seriesByTag('env=')",
+		"seriesByTag('env=', 'dc!=us')",
+		"seriesByTag(name)",
+		"sumSeries('name=requests')",
+	} {
+		_, err := ParseSeriesByTag(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestExpandMetricsSeriesByTag(t *testing.T) {
+	index := tagIndex{
+		"requests;host=web01;dc=us": {"name": "requests", "host": "web01", "dc": "us"},
+		"requests;host=web02;dc=eu": {"name": "requests", "host": "web02", "dc": "eu"},
+		"requests;host=db01;dc=us":  {"name": "requests", "host": "db01", "dc": "us"},
+		"errors;host=web01;dc=us":   {"name": "errors", "host": "web01", "dc": "us"},
+	}
+
+	tests := []struct {
+		s    string
+		want map[string][]string
+	}{
+		{
+			`seriesByTag('name=requests', 'host=~web.*')`,
+			map[string][]string{
+				`seriesByTag('name=requests', 'host=~web.*')`: {"requests;host=web01;dc=us", "requests;host=web02;dc=eu"},
+			},
+		},
+		{
+			`sumSeries(seriesByTag('name=requests', 'dc!=eu'))`,
+			map[string][]string{
+				`seriesByTag('name=requests', 'dc!=eu')`: {"requests;host=db01;dc=us", "requests;host=web01;dc=us"},
+			},
+		},
+		{
+			`seriesByTag('host!=~web.*', 'name=errors')`,
+			map[string][]string{
+				`seriesByTag('host!=~web.*', 'name=errors')`: {},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			e, _, err := ParseExpr(tt.s)
+			if !assert.NoError(t, err) {
+				return
+			}
+			got, err := e.ExpandMetrics(index)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+
+	e, _, err := ParseExpr(`seriesByTag('env=')`)
+	if assert.NoError(t, err) {
+		_, err = e.ExpandMetrics(index)
+		assert.Error(t, err)
+	}
+}
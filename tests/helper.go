@@ -519,6 +519,9 @@ func TestEvalExprModifiedOrigin(t *testing.T, tt *EvalTestItem, from, until int6
 		if actual.StepTime != want.StepTime {
 			t.Errorf("different StepTime for %s metric %s: got %v, Want %v", testName, actual.Name, actual.StepTime, want.StepTime)
 		}
+		if actual.GraphOptions != want.GraphOptions {
+			t.Errorf("different GraphOptions for %s metric %s: got %+v, Want %+v", testName, actual.Name, actual.GraphOptions, want.GraphOptions)
+		}
 		if actual.StartTime != want.StartTime {
 			t.Errorf("different StartTime for %s metric %s: got %v, Want %v", testName, actual.Name, actual.StartTime, want.StartTime)
 		}
@@ -529,6 +532,12 @@ func TestEvalExprModifiedOrigin(t *testing.T, tt *EvalTestItem, from, until int6
 	return nil
 }
 
+// WithGraphOptions sets render hints of m, to be used in expected results
+func WithGraphOptions(m *types.MetricData, options types.GraphOptions) *types.MetricData {
+	m.GraphOptions = options
+	return m
+}
+
 func TestEvalExpr(t *testing.T, tt *EvalTestItem) {
 	originalMetrics := DeepClone(tt.M)
 	err := TestEvalExprModifiedOrigin(t, tt, 0, 1, false)
@@ -3,6 +3,7 @@ package ctx
 import (
 	"context"
 	"net/http"
+	"time"
 )
 
 type key int
@@ -15,6 +16,9 @@ const (
 	headersToPassKey
 	headersToLogKey
 	maxDataPoints
+	timezoneKey
+	strictStepKey
+	consolidateByKey
 )
 
 func ifaceToString(v interface{}) string {
@@ -80,6 +84,40 @@ func GetMaxDatapoints(ctx context.Context) int64 {
 	return getCtxInt64(ctx, maxDataPoints)
 }
 
+// SetTimezone sets timezone of the request, it's used by functions that depend on local time (e.x. timeShift with alignDST)
+func SetTimezone(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, timezoneKey, loc)
+}
+
+// GetTimezone returns timezone of the request or UTC if it isn't set
+func GetTimezone(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(timezoneKey).(*time.Location); ok && loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// SetStrictStep makes functions that combine series fail with an error on different steps instead of resampling them
+func SetStrictStep(ctx context.Context, strict bool) context.Context {
+	return context.WithValue(ctx, strictStepKey, strict)
+}
+
+// GetStrictStep returns true if series with different steps mustn't be resampled
+func GetStrictStep(ctx context.Context) bool {
+	strict, _ := ctx.Value(strictStepKey).(bool)
+	return strict
+}
+
+// SetConsolidateBy sets consolidation function of fetched series, as if every metric was wrapped in consolidateBy
+func SetConsolidateBy(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, consolidateByKey, name)
+}
+
+// GetConsolidateBy returns consolidation function of fetched series or empty string to keep the one set by backend
+func GetConsolidateBy(ctx context.Context) string {
+	return getCtxString(ctx, consolidateByKey)
+}
+
 func ParseCtx(h http.HandlerFunc, uuidKey string) http.HandlerFunc {
 	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		uuid := req.Header.Get(uuidKey)